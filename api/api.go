@@ -1,16 +1,22 @@
 package api
 
 import (
-	"log"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
 
 	"github.com/BarTar213/movies-service/config"
+	"github.com/BarTar213/movies-service/health"
 	"github.com/BarTar213/movies-service/metrics"
 	"github.com/BarTar213/movies-service/middleware"
+	"github.com/BarTar213/movies-service/models"
+	"github.com/BarTar213/movies-service/provider"
 	"github.com/BarTar213/movies-service/storage"
 	"github.com/BarTar213/movies-service/tmdb"
+	"github.com/BarTar213/movies-service/utils"
 	notificator "github.com/BarTar213/notificator/client"
 	"github.com/gin-gonic/gin"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 type Api struct {
@@ -19,9 +25,14 @@ type Api struct {
 	Config      *config.Config
 	Storage     storage.Storage
 	TmdbClient  tmdb.Client
+	Providers   provider.Provider
 	Notificator notificator.Client
 	Metrics     *metrics.Metrics
-	Logger      *log.Logger
+	Health      *health.Checker
+	Tasks       *Tasks
+	Logger      *slog.Logger
+
+	server *http.Server
 }
 
 func WithConfig(conf *config.Config) func(a *Api) {
@@ -30,7 +41,7 @@ func WithConfig(conf *config.Config) func(a *Api) {
 	}
 }
 
-func WithLogger(logger *log.Logger) func(a *Api) {
+func WithLogger(logger *slog.Logger) func(a *Api) {
 	return func(a *Api) {
 		a.Logger = logger
 	}
@@ -48,6 +59,12 @@ func WithTmdbClient(tmdb tmdb.Client) func(a *Api) {
 	}
 }
 
+func WithProviders(providers provider.Provider) func(a *Api) {
+	return func(a *Api) {
+		a.Providers = providers
+	}
+}
+
 func WithNotificator(notificator notificator.Client) func(a *Api) {
 	return func(a *Api) {
 		a.Notificator = notificator
@@ -60,27 +77,68 @@ func WithMetrics(metrics *metrics.Metrics) func(a *Api) {
 	}
 }
 
+func WithHealth(checker *health.Checker) func(a *Api) {
+	return func(a *Api) {
+		a.Health = checker
+	}
+}
+
+func WithTasks(tasks *Tasks) func(a *Api) {
+	return func(a *Api) {
+		a.Tasks = tasks
+	}
+}
+
 func NewApi(options ...func(api *Api)) *Api {
 	a := &Api{
-		Router: gin.Default(),
+		//gin logger is replaced by structured logging middleware
+		Router: gin.New(),
 	}
 
 	for _, option := range options {
 		option(a)
 	}
 
-	moviesHndl := NewMovieHandlers(a.Storage, a.TmdbClient, a.Logger)
-	commentsHndl := NewCommentHandlers(a.Storage, a.Notificator, a.Logger)
+	if a.Providers == nil {
+		a.Providers = provider.NewTmdb(a.TmdbClient)
+	}
+	if a.Metrics == nil {
+		a.Metrics = metrics.New()
+	}
+	if a.Health == nil {
+		a.Health = newHealthChecker(a)
+	}
+	if a.Tasks == nil {
+		a.Tasks = NewTasks(backgroundTimeout, a.Logger)
+	}
 
+	moviesHndl := NewMovieHandlers(a.Config, a.Storage, a.TmdbClient, a.Providers, a.Metrics, a.Tasks, a.Logger)
+	commentsHndl := NewCommentHandlers(a.Storage, a.Notificator, a.Metrics, a.Tasks, a.Logger)
+	healthHndl := NewHealthHandlers(a.Health)
+	peopleHndl := NewPersonHandlers(a.Storage, a.TmdbClient, a.Tasks, a.Logger)
+
+	utils.UseTagFieldNames()
+	a.Router.Use(middleware.Tracing())
+	//recovery is inside of metrics, so panicked requests are recorded with their 500 status
+	a.Router.Use(middleware.Metrics(a.Metrics))
 	a.Router.Use(gin.Recovery())
+	a.Router.Use(middleware.RequestId())
+	a.Router.Use(middleware.Logging(a.Logger))
+	a.Router.Use(middleware.Timeout(a.Config.Api.Timeout))
+	a.Router.NoRoute(func(c *gin.Context) {
+		utils.RespondError(c, http.StatusNotFound, models.CodeRouteNotFound, "route not found")
+	})
 
 	standard := a.Router.Group("")
 	{
 		movies := standard.Group("/movies")
 		{
 			movies.GET("", moviesHndl.ListMovies)
-			movies.GET("/:movieId", moviesHndl.GetMovie)
+			//serves also now-playing, upcoming, popular and discover lists
+			movies.GET("/:movieId", moviesHndl.GetMovieOrList)
 			movies.GET("/:movieId/credits", moviesHndl.GetCredits)
+			movies.GET("/:movieId/images", moviesHndl.GetImages)
+			movies.GET("/:movieId/videos", moviesHndl.GetVideos)
 		}
 
 		comments := standard.Group("/comments")
@@ -88,9 +146,17 @@ func NewApi(options ...func(api *Api)) *Api {
 			comments.GET("", commentsHndl.ListComments)
 		}
 
+		people := standard.Group("/people")
+		{
+			people.GET("/:personId", peopleHndl.GetPerson)
+			people.GET("/:personId/movies", peopleHndl.ListPersonMovies)
+		}
+
 		standard.GET("/trending", moviesHndl.GetTrendingMovies)
 		standard.GET("/ranking", moviesHndl.GetTopRatedMovies)
-		standard.GET("/metrics", gin.WrapH(promhttp.Handler()))
+		standard.GET("/metrics", gin.WrapH(a.Metrics.Handler()))
+		standard.GET("/healthz", healthHndl.Liveness)
+		standard.GET("/readyz", healthHndl.Readiness)
 	}
 
 	authorized := a.Router.Group("")
@@ -121,11 +187,33 @@ func NewApi(options ...func(api *Api)) *Api {
 		}
 
 		authorized.GET("/rating", moviesHndl.ListRatedMovies)
+
+		admin := authorized.Group("/admin")
+		admin.Use(middleware.CheckRole(adminRole))
+		{
+			admin.POST("/movies/:movieId/credits/refresh", moviesHndl.RefreshCredits)
+		}
+	}
+
+	a.server = &http.Server{
+		Addr:    a.Config.Api.Port,
+		Handler: a.Router,
 	}
 
 	return a
 }
 
+// Run serves requests until Shutdown is called
 func (a *Api) Run() error {
-	return a.Router.Run(a.Config.Api.Port)
+	err := a.server.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Shutdown stops accepting connections, waits for in-flight requests and then for background tasks
+// they submitted, work still running when ctx is done is cancelled
+func (a *Api) Shutdown(ctx context.Context) error {
+	return errors.Join(a.server.Shutdown(ctx), a.Tasks.Drain(ctx))
 }
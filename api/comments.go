@@ -3,11 +3,13 @@ package api
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/BarTar213/movies-service/logging"
+	"github.com/BarTar213/movies-service/metrics"
 	"github.com/BarTar213/movies-service/models"
 	"github.com/BarTar213/movies-service/storage"
 	"github.com/BarTar213/movies-service/utils"
@@ -18,18 +20,24 @@ import (
 
 const (
 	commentId = "commId"
+
+	commentLikeTemplate = "commentLike"
 )
 
 type CommentHandlers struct {
 	storage     storage.Storage
 	notificator notificator.Client
-	logger      *log.Logger
+	metrics     *metrics.Metrics
+	tasks       *Tasks
+	logger      *slog.Logger
 }
 
-func NewCommentHandlers(storage storage.Storage, notificator notificator.Client, logger *log.Logger) *CommentHandlers {
+func NewCommentHandlers(storage storage.Storage, notificator notificator.Client, metrics *metrics.Metrics, tasks *Tasks, logger *slog.Logger) *CommentHandlers {
 	return &CommentHandlers{
 		storage:     storage,
 		notificator: notificator,
+		metrics:     metrics,
+		tasks:       tasks,
 		logger:      logger,
 	}
 }
@@ -37,20 +45,20 @@ func NewCommentHandlers(storage storage.Storage, notificator notificator.Client,
 func (h *CommentHandlers) ListComments(c *gin.Context) {
 	id, err := strconv.Atoi(c.Query(movieIdQuery))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.Response{Error: invalidMovieIdParamErr})
+		utils.RespondError(c, http.StatusBadRequest, models.CodeInvalidParam, invalidMovieIdParamErr)
 		return
 	}
 
 	params := models.PaginationParams{OrderBy: "create_date"}
 	err = c.ShouldBindQuery(&params)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.Response{Error: invalidPaginationQueryParams})
+		utils.RespondBindingError(c, models.CodeInvalidQuery, invalidPaginationQueryParams, err)
 		return
 	}
 
-	comments, err := h.storage.ListMovieComments(id, &params)
+	comments, err := h.storage.ListMovieComments(c.Request.Context(), id, &params)
 	if err != nil {
-		handlePostgresError(c, h.logger, err, commentResource)
+		handleStorageError(c, h.logger, err, commentResource)
 		return
 	}
 
@@ -62,29 +70,34 @@ func (h *CommentHandlers) LikeComment(c *gin.Context) {
 
 	commentId, err := strconv.Atoi(c.Param(commentId))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.Response{Error: invalidCommentIdParamErr})
+		utils.RespondError(c, http.StatusBadRequest, models.CodeInvalidParam, invalidCommentIdParamErr)
 		return
 	}
 
 	liked, err := strconv.ParseBool(c.Query(likedParam))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.Response{Error: invalidLikedParamErr})
+		utils.RespondError(c, http.StatusBadRequest, models.CodeInvalidParam, invalidLikedParamErr)
 		return
 	}
 
+	action := metrics.LikeAction
 	if liked {
-		err = h.storage.DeleteCommentLike(account.ID, commentId)
+		action = metrics.UnlikeAction
+		err = h.storage.DeleteCommentLike(c.Request.Context(), account.ID, commentId)
 	} else {
 		comment := &models.Comment{}
-		err = h.storage.LikeComment(account.ID, commentId, comment)
+		err = h.storage.LikeComment(c.Request.Context(), account.ID, commentId, comment)
 		if err == nil {
-			go h.sendNotification(comment, account)
+			h.tasks.Go(c.Request.Context(), "send notification", func(ctx context.Context) {
+				h.sendNotification(ctx, comment, account)
+			})
 		}
 	}
 	if err != nil {
-		handlePostgresError(c, h.logger, err, commentResource)
+		handleStorageError(c, h.logger, err, commentResource)
 		return
 	}
+	h.metrics.Likes.WithLabelValues(metrics.CommentResource, action).Inc()
 
 	c.JSON(http.StatusOK, models.Response{})
 }
@@ -94,14 +107,14 @@ func (h *CommentHandlers) AddComment(c *gin.Context) {
 
 	movieId, err := strconv.Atoi(c.Query(movieIdQuery))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.Response{Error: invalidMovieIdParamErr})
+		utils.RespondError(c, http.StatusBadRequest, models.CodeInvalidParam, invalidMovieIdParamErr)
 		return
 	}
 
 	comment := models.Comment{}
 	err = c.ShouldBindJSON(&comment)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.Response{Error: invalidRequestBodyErr})
+		utils.RespondBindingError(c, models.CodeInvalidBody, invalidRequestBodyErr, err)
 		return
 	}
 
@@ -111,11 +124,12 @@ func (h *CommentHandlers) AddComment(c *gin.Context) {
 	comment.MovieId = movieId
 	comment.UserId = account.ID
 
-	err = h.storage.AddMovieComment(&comment)
+	err = h.storage.AddMovieComment(c.Request.Context(), &comment)
 	if err != nil {
-		handlePostgresError(c, h.logger, err, commentResource)
+		handleStorageError(c, h.logger, err, commentResource)
 		return
 	}
+	h.metrics.Comments.WithLabelValues(metrics.CreatedAction).Inc()
 
 	c.JSON(http.StatusCreated, comment)
 }
@@ -125,14 +139,14 @@ func (h *CommentHandlers) UpdateComment(c *gin.Context) {
 
 	commentId, err := strconv.Atoi(c.Param(commentId))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.Response{Error: invalidCommentIdParamErr})
+		utils.RespondError(c, http.StatusBadRequest, models.CodeInvalidParam, invalidCommentIdParamErr)
 		return
 	}
 
 	comment := models.Comment{}
 	err = c.ShouldBindJSON(&comment)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.Response{Error: invalidRequestBodyErr})
+		utils.RespondBindingError(c, models.CodeInvalidBody, invalidRequestBodyErr, err)
 		return
 	}
 
@@ -140,11 +154,13 @@ func (h *CommentHandlers) UpdateComment(c *gin.Context) {
 	comment.UserId = account.ID
 	comment.UpdateDate = time.Now()
 
-	err = h.storage.UpdateComment(&comment)
+	//comment of other user or unknown one isn't updated and reported as not found, so it isn't counted
+	err = h.storage.UpdateComment(c.Request.Context(), &comment)
 	if err != nil {
-		handlePostgresError(c, h.logger, err, commentResource)
+		handleStorageError(c, h.logger, err, commentResource)
 		return
 	}
+	h.metrics.Comments.WithLabelValues(metrics.EditedAction).Inc()
 
 	c.JSON(http.StatusOK, comment)
 }
@@ -154,7 +170,7 @@ func (h *CommentHandlers) DeleteComment(c *gin.Context) {
 
 	commentId, err := strconv.Atoi(c.Param(commentId))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.Response{Error: invalidCommentIdParamErr})
+		utils.RespondError(c, http.StatusBadRequest, models.CodeInvalidParam, invalidCommentIdParamErr)
 		return
 	}
 
@@ -162,11 +178,14 @@ func (h *CommentHandlers) DeleteComment(c *gin.Context) {
 	comment.Id = commentId
 	comment.UserId = account.ID
 
-	err = h.storage.DeleteComment(&comment)
+	deleted, err := h.storage.DeleteComment(c.Request.Context(), &comment)
 	if err != nil {
-		handlePostgresError(c, h.logger, err, commentResource)
+		handleStorageError(c, h.logger, err, commentResource)
 		return
 	}
+	if deleted {
+		h.metrics.Comments.WithLabelValues(metrics.DeletedAction).Inc()
+	}
 
 	c.JSON(http.StatusOK, models.Response{})
 }
@@ -176,21 +195,23 @@ func (h *CommentHandlers) ListLikedComments(c *gin.Context) {
 
 	id, err := strconv.Atoi(c.Param(movieIdKey))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.Response{Error: err.Error()})
+		utils.RespondError(c, http.StatusBadRequest, models.CodeInvalidParam, invalidMovieIdParamErr)
 		return
 	}
 
-	commentIds, err := h.storage.ListLikedCommentsForMovie(id, account.ID)
+	commentIds, err := h.storage.ListLikedCommentsForMovie(c.Request.Context(), id, account.ID)
 	if err != nil {
-		handlePostgresError(c, h.logger, err, commentResource)
+		handleStorageError(c, h.logger, err, commentResource)
 		return
 	}
 
 	c.JSON(http.StatusOK, commentIds)
 }
 
+func (h *CommentHandlers) sendNotification(ctx context.Context, comment *models.Comment, account *models.AccountInfo) {
+	h.metrics.NotificationsInFlight.Inc()
+	defer h.metrics.NotificationsInFlight.Dec()
 
-func (h *CommentHandlers) sendNotification(comment *models.Comment, account *models.AccountInfo) {
 	internal := &senders.Internal{
 		ResourceID: comment.Id,
 		Resource:   "comment",
@@ -200,8 +221,11 @@ func (h *CommentHandlers) sendNotification(comment *models.Comment, account *mod
 			"user": account.Login,
 		},
 	}
-	_, _, err := h.notificator.SendInternal(context.Background(), "commentLike", internal)
-	if err != nil{
-		h.logger.Printf("send internal notification: %s", err)
+	_, _, err := h.notificator.SendInternal(ctx, commentLikeTemplate, internal)
+	if err != nil {
+		h.metrics.Notifications.WithLabelValues(commentLikeTemplate, metrics.FailedOutcome).Inc()
+		h.logger.ErrorContext(ctx, "send internal notification", slog.String("template", commentLikeTemplate), logging.Err(err))
+		return
 	}
+	h.metrics.Notifications.WithLabelValues(commentLikeTemplate, metrics.SentOutcome).Inc()
 }
@@ -4,13 +4,14 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"testing"
 
 	"github.com/BarTar213/movies-service/config"
+	"github.com/BarTar213/movies-service/metrics"
 	"github.com/BarTar213/movies-service/mock"
 	"github.com/BarTar213/movies-service/models"
 	"github.com/BarTar213/movies-service/storage"
@@ -26,7 +27,9 @@ func TestNewCommentHandlers(t *testing.T) {
 	type args struct {
 		storage     storage.Storage
 		notificator notificator.Client
-		logger      *log.Logger
+		metrics     *metrics.Metrics
+		tasks       *Tasks
+		logger      *slog.Logger
 	}
 	tests := []struct {
 		name string
@@ -38,17 +41,18 @@ func TestNewCommentHandlers(t *testing.T) {
 			args: args{
 				storage:     &mock.Storage{},
 				notificator: &mock.Notificator{},
-				logger:      &log.Logger{},
+				logger:      &slog.Logger{},
 			},
 			want: &CommentHandlers{
-				storage: &mock.Storage{},
-				logger:  &log.Logger{},
+				storage:     &mock.Storage{},
+				notificator: &mock.Notificator{},
+				logger:      &slog.Logger{},
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := NewCommentHandlers(tt.args.storage, tt.args.notificator, tt.args.logger); !reflect.DeepEqual(got, tt.want) {
+			if got := NewCommentHandlers(tt.args.storage, tt.args.notificator, tt.args.metrics, tt.args.tasks, tt.args.logger); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("NewCommentHandlers() = %v, want %v", got, tt.want)
 			}
 		})
@@ -59,7 +63,7 @@ func TestCommentHandlers_AddComment(t *testing.T) {
 	type fields struct {
 		storage storage.Storage
 		conf    *config.Config
-		logger  *log.Logger
+		logger  *slog.Logger
 	}
 	tests := []struct {
 		name       string
@@ -170,7 +174,7 @@ func TestCommentHandlers_DeleteComment(t *testing.T) {
 	type fields struct {
 		storage storage.Storage
 		conf    *config.Config
-		logger  *log.Logger
+		logger  *slog.Logger
 	}
 	tests := []struct {
 		name       string
@@ -251,7 +255,7 @@ func TestCommentHandlers_GetComments(t *testing.T) {
 	type fields struct {
 		storage storage.Storage
 		conf    *config.Config
-		logger  *log.Logger
+		logger  *slog.Logger
 	}
 	tests := []struct {
 		name       string
@@ -365,7 +369,7 @@ func TestCommentHandlers_LikeComment(t *testing.T) {
 	type fields struct {
 		storage storage.Storage
 		conf    *config.Config
-		logger  *log.Logger
+		logger  *slog.Logger
 	}
 	tests := []struct {
 		name       string
@@ -483,6 +487,7 @@ func TestCommentHandlers_LikeComment(t *testing.T) {
 				WithConfig(tt.fields.conf),
 				WithLogger(tt.fields.logger),
 				WithStorage(tt.fields.storage),
+				WithNotificator(&mock.Notificator{}),
 			)
 
 			w := httptest.NewRecorder()
@@ -500,7 +505,7 @@ func TestCommentHandlers_UpdateComment(t *testing.T) {
 	type fields struct {
 		storage storage.Storage
 		conf    *config.Config
-		logger  *log.Logger
+		logger  *slog.Logger
 	}
 	tests := []struct {
 		name       string
@@ -1,43 +1,98 @@
 package api
 
 import (
+	"context"
+	"log/slog"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/BarTar213/movies-service/logging"
 	"github.com/BarTar213/movies-service/models"
+	"github.com/BarTar213/movies-service/storage"
+	"github.com/BarTar213/movies-service/utils"
 	"github.com/gin-gonic/gin"
-	"github.com/go-pg/pg/v10"
 	"github.com/pkg/errors"
 )
 
+const (
+	summaryCastLimit = 10
+
+	directorJob       = "Director"
+	composerJob       = "Original Music Composer"
+	musicJob          = "Music"
+	writingDepartment = "Writing"
+)
+
 func (h *MovieHandlers) GetCredits(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param(movieIdKey))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.Response{Error: err.Error()})
+		utils.RespondError(c, http.StatusBadRequest, models.CodeInvalidParam, invalidMovieIdParamErr)
+		return
+	}
+
+	params := &models.CreditsParams{}
+	err = c.ShouldBindQuery(params)
+	if err != nil {
+		utils.RespondBindingError(c, models.CodeInvalidQuery, invalidCreditsQueryParams, err)
 		return
 	}
 
 	credits := &models.Credit{}
-	err = h.storage.GetCredits(id, credits)
-	if err != nil && err != pg.ErrNoRows {
-		handlePostgresError(c, h.logger, err, creditsResource)
+	err = h.storage.GetCredits(c.Request.Context(), id, credits)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		handleStorageError(c, h.logger, err, creditsResource)
 		return
 	}
 
-	if err == pg.ErrNoRows {
-		status, err := h.tmdb.GetCredits(id, credits)
+	if errors.Is(err, storage.ErrNotFound) {
+		status, err := h.fetchCredits(c.Request.Context(), id, credits)
 		if err != nil || status != http.StatusOK {
 			handleTMDBError(c, h.logger, status, err, creditsResource)
 			return
 		}
-		credits.MovieId = id
-		go h.AddCredits(credits)
+		h.tasks.Go(c.Request.Context(), "store credits", func(ctx context.Context) {
+			h.AddCredits(ctx, credits)
+		})
+	} else if h.creditsExpired(credits) {
+		h.tasks.Go(c.Request.Context(), "refresh credits", func(ctx context.Context) {
+			h.refreshExpiredCredits(ctx, id)
+		})
+	}
+
+	if params.Summary {
+		c.JSON(http.StatusOK, summarizeCredits(credits, params))
+		return
+	}
+	c.JSON(http.StatusOK, filterCredits(credits, params))
+}
+
+func (h *MovieHandlers) RefreshCredits(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param(movieIdKey))
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, models.CodeInvalidParam, invalidMovieIdParamErr)
+		return
+	}
+
+	credits := &models.Credit{}
+	status, err := h.fetchCredits(c.Request.Context(), id, credits)
+	if err != nil || status != http.StatusOK {
+		handleTMDBError(c, h.logger, status, err, creditsResource)
+		return
+	}
+
+	err = h.AddCredits(c.Request.Context(), credits)
+	if err != nil {
+		handleStorageError(c, h.logger, err, creditsResource)
+		return
 	}
 
 	c.JSON(http.StatusOK, credits)
 }
 
-func (h *MovieHandlers) AddCredits(credits *models.Credit) error {
+func (h *MovieHandlers) AddCredits(ctx context.Context, credits *models.Credit) error {
 	for i := 0; i < len(credits.Crew); i++ {
 		credits.Crew[i].CreditId = credits.Id
 	}
@@ -45,12 +100,115 @@ func (h *MovieHandlers) AddCredits(credits *models.Credit) error {
 		credits.Cast[i].CreditId = credits.Id
 	}
 
-	err := h.storage.AddCredits(credits)
+	err := h.storage.AddCredits(ctx, credits)
 	if err != nil {
 		err := errors.WithMessage(err, "AddCredits")
-		h.logger.Print(err)
+		h.logger.ErrorContext(ctx, "add credits", slog.Int("movie_id", credits.MovieId), logging.Err(err))
 		return err
 	}
 
 	return nil
 }
+
+func (h *MovieHandlers) fetchCredits(ctx context.Context, movieId int, credits *models.Credit) (int, error) {
+	status, err := h.providers.GetCredits(ctx, movieId, credits)
+	if err != nil || status != http.StatusOK {
+		return status, err
+	}
+	credits.MovieId = movieId
+	credits.FetchedAt = time.Now()
+
+	return status, nil
+}
+
+// credits never expire when TTL is not configured
+func (h *MovieHandlers) creditsExpired(credits *models.Credit) bool {
+	ttl := h.conf.Tmdb.CreditsTTL
+	if ttl <= 0 {
+		return false
+	}
+	return time.Since(credits.FetchedAt) > ttl
+}
+
+// refreshes credits unless another refresh for the same movie is already running
+func (h *MovieHandlers) refreshExpiredCredits(ctx context.Context, movieId int) {
+	_, running := h.refreshing.LoadOrStore(movieId, struct{}{})
+	if running {
+		return
+	}
+	defer h.refreshing.Delete(movieId)
+
+	credits := &models.Credit{}
+	status, err := h.fetchCredits(ctx, movieId, credits)
+	if err != nil || status != http.StatusOK {
+		h.logger.WarnContext(ctx, "refresh credits", slog.Int("movie_id", movieId), slog.Int("status", status), logging.Err(err))
+		return
+	}
+
+	h.AddCredits(ctx, credits)
+}
+
+// returns copy of credits so the original can be safely stored in background
+func filterCredits(credits *models.Credit, params *models.CreditsParams) *models.Credit {
+	filtered := &models.Credit{
+		Id:        credits.Id,
+		MovieId:   credits.MovieId,
+		FetchedAt: credits.FetchedAt,
+		Cast:      make([]*models.Cast, 0, len(credits.Cast)),
+		Crew:      make([]*models.Crew, 0, len(credits.Crew)),
+	}
+
+	for _, cast := range credits.Cast {
+		if params.MaxOrder != nil && cast.Order > *params.MaxOrder {
+			continue
+		}
+		filtered.Cast = append(filtered.Cast, cast)
+	}
+
+	for _, crew := range credits.Crew {
+		if len(params.Department) > 0 && !strings.EqualFold(crew.Department, params.Department) {
+			continue
+		}
+		if len(params.Job) > 0 && !strings.EqualFold(crew.Job, params.Job) {
+			continue
+		}
+		filtered.Crew = append(filtered.Crew, crew)
+	}
+
+	return filtered
+}
+
+func summarizeCredits(credits *models.Credit, params *models.CreditsParams) *models.CreditsSummary {
+	summary := &models.CreditsSummary{
+		Directors: make([]*models.Crew, 0),
+		Writers:   make([]*models.Crew, 0),
+		Composers: make([]*models.Crew, 0),
+		Cast:      make([]*models.Cast, 0, summaryCastLimit),
+	}
+
+	maxOrder := summaryCastLimit - 1
+	if params.MaxOrder != nil {
+		maxOrder = *params.MaxOrder
+	}
+	for _, cast := range credits.Cast {
+		if cast.Order <= maxOrder {
+			summary.Cast = append(summary.Cast, cast)
+		}
+	}
+	sort.SliceStable(summary.Cast, func(i, j int) bool {
+		return summary.Cast[i].Order < summary.Cast[j].Order
+	})
+
+	for _, crew := range credits.Crew {
+		switch {
+		case crew.Job == directorJob:
+			summary.Directors = append(summary.Directors, crew)
+		case crew.Department == writingDepartment:
+			summary.Writers = append(summary.Writers, crew)
+		case crew.Job == composerJob || crew.Job == musicJob:
+			summary.Composers = append(summary.Composers, crew)
+		}
+	}
+
+	return summary
+}
@@ -1,11 +1,14 @@
 package api
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/BarTar213/movies-service/config"
 	"github.com/BarTar213/movies-service/mock"
@@ -20,12 +23,13 @@ func TestMovieHandlers_GetCredits(t *testing.T) {
 		storage    storage.Storage
 		conf       *config.Config
 		tmdbClient tmdb.Client
-		logger     *log.Logger
+		logger     *slog.Logger
 	}
 	tests := []struct {
 		name       string
 		fields     fields
 		movieId    string
+		query      string
 		wantStatus int
 	}{
 		{
@@ -39,6 +43,42 @@ func TestMovieHandlers_GetCredits(t *testing.T) {
 			movieId:    validId,
 			wantStatus: http.StatusOK,
 		},
+		{
+			name: "positive_get_credits_filtered",
+			fields: fields{
+				storage:    &mock.Storage{},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			movieId:    validId,
+			query:      "?max_order=5&department=Directing&job=Director",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "positive_get_credits_summary",
+			fields: fields{
+				storage:    &mock.Storage{},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			movieId:    validId,
+			query:      "?summary=true",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "negative_get_credits_invalid_query_params",
+			fields: fields{
+				storage:    &mock.Storage{},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			movieId:    validId,
+			query:      "?max_order=invalidOrder",
+			wantStatus: http.StatusBadRequest,
+		},
 		{
 			name: "negative_get_credits_invalid_movie_id",
 			fields: fields{
@@ -63,6 +103,19 @@ func TestMovieHandlers_GetCredits(t *testing.T) {
 			movieId:    validId,
 			wantStatus: http.StatusInternalServerError,
 		},
+		{
+			name: "positive_get_credits_expired",
+			fields: fields{
+				storage: &mock.Storage{},
+				conf: &config.Config{
+					Tmdb: config.Tmdb{CreditsTTL: time.Hour},
+				},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			movieId:    validId,
+			wantStatus: http.StatusOK,
+		},
 		{
 			name: "positive_get_credits_storage_error",
 			fields: fields{
@@ -88,7 +141,7 @@ func TestMovieHandlers_GetCredits(t *testing.T) {
 			)
 
 			w := httptest.NewRecorder()
-			reqUrl := fmt.Sprintf("/movies/%s/credits", tt.movieId)
+			reqUrl := fmt.Sprintf("/movies/%s/credits%s", tt.movieId, tt.query)
 			req, _ := http.NewRequest(http.MethodGet, reqUrl, nil)
 
 			a.Router.ServeHTTP(w, req)
@@ -101,7 +154,7 @@ func TestMovieHandlers_AddCredits(t *testing.T) {
 	type fields struct {
 		storage storage.Storage
 		tmdb    tmdb.Client
-		logger  *log.Logger
+		logger  *slog.Logger
 	}
 	type args struct {
 		credits *models.Credit
@@ -164,9 +217,213 @@ func TestMovieHandlers_AddCredits(t *testing.T) {
 				tmdb:    tt.fields.tmdb,
 				logger:  tt.fields.logger,
 			}
-			if err := h.AddCredits(tt.args.credits); (err != nil) != tt.wantErr {
+			if err := h.AddCredits(context.Background(), tt.args.credits); (err != nil) != tt.wantErr {
 				t.Errorf("AddCredits() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
+
+func TestMovieHandlers_RefreshCredits(t *testing.T) {
+	type fields struct {
+		storage    storage.Storage
+		conf       *config.Config
+		tmdbClient tmdb.Client
+		logger     *slog.Logger
+	}
+	tests := []struct {
+		name       string
+		fields     fields
+		account    *models.AccountInfo
+		movieId    string
+		wantStatus int
+	}{
+		{
+			name: "positive_refresh_credits",
+			fields: fields{
+				storage:    &mock.Storage{},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			account: &models.AccountInfo{
+				ID:    1,
+				Login: accountLogin,
+				Role:  adminRole,
+			},
+			movieId:    validId,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "negative_refresh_credits_not_admin",
+			fields: fields{
+				storage:    &mock.Storage{},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			account: &models.AccountInfo{
+				ID:    1,
+				Login: accountLogin,
+				Role:  accountRole,
+			},
+			movieId:    validId,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "negative_refresh_credits_invalid_movie_id",
+			fields: fields{
+				storage:    &mock.Storage{},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			account: &models.AccountInfo{
+				ID:    1,
+				Login: accountLogin,
+				Role:  adminRole,
+			},
+			movieId:    invalidId,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "negative_refresh_credits_tmdb_error",
+			fields: fields{
+				storage: &mock.Storage{},
+				conf:    &config.Config{},
+				tmdbClient: &mock.Tmdb{
+					GetCreditsErr:    true,
+					GetCreditsStatus: http.StatusInternalServerError,
+				},
+				logger: logger,
+			},
+			account: &models.AccountInfo{
+				ID:    1,
+				Login: accountLogin,
+				Role:  adminRole,
+			},
+			movieId:    validId,
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "negative_refresh_credits_storage_error",
+			fields: fields{
+				storage: &mock.Storage{
+					AddCreditsErr: true,
+				},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			account: &models.AccountInfo{
+				ID:    1,
+				Login: accountLogin,
+				Role:  adminRole,
+			},
+			movieId:    validId,
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			a := NewApi(
+				WithConfig(tt.fields.conf),
+				WithLogger(tt.fields.logger),
+				WithStorage(tt.fields.storage),
+				WithTmdbClient(tt.fields.tmdbClient),
+			)
+
+			w := httptest.NewRecorder()
+			reqUrl := fmt.Sprintf("/admin/movies/%s/credits/refresh", tt.movieId)
+			req, _ := http.NewRequest(http.MethodPost, reqUrl, nil)
+			setAccountHeaders(req, tt.account)
+
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func Test_filterCredits(t *testing.T) {
+	credits := &models.Credit{
+		Cast: []*models.Cast{
+			{Id: 1, Order: 0},
+			{Id: 2, Order: 1},
+			{Id: 3, Order: 2},
+		},
+		Crew: []*models.Crew{
+			{Id: 4, Department: "Directing", Job: "Director"},
+			{Id: 5, Department: "Writing", Job: "Screenplay"},
+			{Id: 6, Department: "Directing", Job: "Assistant Director"},
+		},
+	}
+	tests := []struct {
+		name     string
+		params   *models.CreditsParams
+		wantCast []*models.Cast
+		wantCrew []*models.Crew
+	}{
+		{
+			name:     "no_filters",
+			params:   &models.CreditsParams{},
+			wantCast: credits.Cast,
+			wantCrew: credits.Crew,
+		},
+		{
+			name:     "max_order",
+			params:   &models.CreditsParams{MaxOrder: intPointer(1)},
+			wantCast: credits.Cast[:2],
+			wantCrew: credits.Crew,
+		},
+		{
+			name:     "department",
+			params:   &models.CreditsParams{Department: "directing"},
+			wantCast: credits.Cast,
+			wantCrew: []*models.Crew{credits.Crew[0], credits.Crew[2]},
+		},
+		{
+			name:     "department_and_job",
+			params:   &models.CreditsParams{Department: "Directing", Job: "Director"},
+			wantCast: credits.Cast,
+			wantCrew: credits.Crew[:1],
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filterCredits(credits, tt.params)
+			if !reflect.DeepEqual(got.Cast, tt.wantCast) {
+				t.Errorf("filterCredits() cast = %v, want %v", got.Cast, tt.wantCast)
+			}
+			if !reflect.DeepEqual(got.Crew, tt.wantCrew) {
+				t.Errorf("filterCredits() crew = %v, want %v", got.Crew, tt.wantCrew)
+			}
+		})
+	}
+}
+
+func Test_summarizeCredits(t *testing.T) {
+	credits := &models.Credit{
+		Cast: []*models.Cast{
+			{Id: 1, Order: 12},
+			{Id: 2, Order: 1},
+			{Id: 3, Order: 0},
+		},
+		Crew: []*models.Crew{
+			{Id: 4, Department: "Directing", Job: "Director"},
+			{Id: 5, Department: "Writing", Job: "Screenplay"},
+			{Id: 6, Department: "Sound", Job: "Original Music Composer"},
+			{Id: 7, Department: "Camera", Job: "Director of Photography"},
+		},
+	}
+
+	want := &models.CreditsSummary{
+		Directors: []*models.Crew{credits.Crew[0]},
+		Writers:   []*models.Crew{credits.Crew[1]},
+		Composers: []*models.Crew{credits.Crew[2]},
+		Cast:      []*models.Cast{credits.Cast[2], credits.Cast[1]},
+	}
+	if got := summarizeCredits(credits, &models.CreditsParams{}); !reflect.DeepEqual(got, want) {
+		t.Errorf("summarizeCredits() = %v, want %v", got, want)
+	}
+}
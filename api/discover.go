@@ -0,0 +1,171 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BarTar213/movies-service/models"
+	"github.com/BarTar213/movies-service/provider"
+	"github.com/BarTar213/movies-service/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	nowPlayingPath = "now-playing"
+	upcomingPath   = "upcoming"
+	popularPath    = "popular"
+	discoverPath   = "discover"
+
+	minDiscoverYear = 1874
+	maxDiscoverYear = 2100
+)
+
+var discoverSortFields = map[string]bool{
+	"popularity":     true,
+	"release_date":   true,
+	"revenue":        true,
+	"vote_average":   true,
+	"vote_count":     true,
+	"original_title": true,
+}
+
+type tmdbMoviesFetcher func(ctx context.Context, meta *models.PageMeta) ([]models.TmdbMovie, int, error)
+
+// GetMovieOrList serves movie lists under /movies/:movieId,
+// gin doesn't allow static routes next to wildcard on the same segment
+func (h *MovieHandlers) GetMovieOrList(c *gin.Context) {
+	switch c.Param(movieIdKey) {
+	case nowPlayingPath:
+		h.GetNowPlayingMovies(c)
+	case upcomingPath:
+		h.GetUpcomingMovies(c)
+	case popularPath:
+		h.GetPopularMovies(c)
+	case discoverPath:
+		h.DiscoverMovies(c)
+	default:
+		h.GetMovie(c)
+	}
+}
+
+func (h *MovieHandlers) GetNowPlayingMovies(c *gin.Context) {
+	params, ok := bindTmdbListParams(c)
+	if !ok {
+		return
+	}
+
+	h.listTmdbMovies(c, func(ctx context.Context, meta *models.PageMeta) ([]models.TmdbMovie, int, error) {
+		return h.providers.ListMovies(ctx, provider.ListNowPlaying, params, meta)
+	})
+}
+
+func (h *MovieHandlers) GetUpcomingMovies(c *gin.Context) {
+	params, ok := bindTmdbListParams(c)
+	if !ok {
+		return
+	}
+
+	h.listTmdbMovies(c, func(ctx context.Context, meta *models.PageMeta) ([]models.TmdbMovie, int, error) {
+		return h.providers.ListMovies(ctx, provider.ListUpcoming, params, meta)
+	})
+}
+
+func (h *MovieHandlers) GetPopularMovies(c *gin.Context) {
+	params, ok := bindTmdbListParams(c)
+	if !ok {
+		return
+	}
+
+	h.listTmdbMovies(c, func(ctx context.Context, meta *models.PageMeta) ([]models.TmdbMovie, int, error) {
+		return h.providers.ListMovies(ctx, provider.ListPopular, params, meta)
+	})
+}
+
+func (h *MovieHandlers) DiscoverMovies(c *gin.Context) {
+	params := &models.DiscoverParams{}
+	err := c.ShouldBindQuery(params)
+	if err != nil || !validTmdbListParams(&params.TmdbListParams) || !validDiscoverParams(params) {
+		utils.RespondBindingError(c, models.CodeInvalidQuery, invalidDiscoverQueryParams, err)
+		return
+	}
+	params.Genres = normalizeGenres(params.Genres)
+
+	h.listTmdbMovies(c, func(ctx context.Context, meta *models.PageMeta) ([]models.TmdbMovie, int, error) {
+		return h.providers.DiscoverMovies(ctx, params, meta)
+	})
+}
+
+// responds with movies listed by providers and stores them in background
+func (h *MovieHandlers) listTmdbMovies(c *gin.Context, fetch tmdbMoviesFetcher) {
+	meta := &models.PageMeta{}
+	movies, status, err := fetch(c.Request.Context(), meta)
+	if err != nil || status != http.StatusOK {
+		handleTMDBError(c, h.logger, status, err, moviesResource)
+		return
+	}
+
+	translated := make([]models.TmdbMovie, len(movies))
+	copy(translated, movies)
+	h.tasks.Go(c.Request.Context(), "add movies", func(ctx context.Context) {
+		h.AddMovies(ctx, movies)
+	})
+
+	h.translateTmdbMovies(c.Request.Context(), translated, getLanguage(c))
+	c.JSON(http.StatusOK, models.Response{Data: translated, Meta: meta})
+}
+
+// sort_by has to be one of TMDB sort fields with asc or desc direction,
+// genres are ids separated with comma (all of them) or pipe (any of them)
+func validDiscoverParams(params *models.DiscoverParams) bool {
+	if len(params.SortBy) > 0 {
+		i := strings.LastIndex(params.SortBy, ".")
+		if i < 0 || !discoverSortFields[params.SortBy[:i]] {
+			return false
+		}
+		if direction := params.SortBy[i+1:]; direction != "asc" && direction != "desc" {
+			return false
+		}
+	}
+
+	if len(params.Genres) > 0 {
+		for _, id := range strings.FieldsFunc(params.Genres, func(r rune) bool { return r == ',' || r == '|' }) {
+			if _, err := strconv.Atoi(id); err != nil {
+				return false
+			}
+		}
+		if strings.Contains(params.Genres, ",") && strings.Contains(params.Genres, "|") {
+			return false
+		}
+	}
+
+	return params.Year == 0 || (params.Year >= minDiscoverYear && params.Year <= maxDiscoverYear)
+}
+
+// sorts and dedupes valid genre ids, so the same genres are listed and cached once no matter of their order
+func normalizeGenres(genres string) string {
+	separator := ","
+	if strings.Contains(genres, "|") {
+		separator = "|"
+	}
+
+	ids := make([]int, 0)
+	seen := make(map[int]bool)
+	for _, field := range strings.Split(genres, separator) {
+		id, err := strconv.Atoi(field)
+		if err != nil || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	normalized := make([]string, 0, len(ids))
+	for _, id := range ids {
+		normalized = append(normalized, strconv.Itoa(id))
+	}
+	return strings.Join(normalized, separator)
+}
@@ -0,0 +1,235 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BarTar213/movies-service/config"
+	"github.com/BarTar213/movies-service/mock"
+	"github.com/BarTar213/movies-service/models"
+	"github.com/BarTar213/movies-service/storage"
+	"github.com/BarTar213/movies-service/tmdb"
+	"github.com/gin-gonic/gin"
+)
+
+func TestMovieHandlers_GetMovieOrList(t *testing.T) {
+	type fields struct {
+		storage    storage.Storage
+		conf       *config.Config
+		tmdbClient tmdb.Client
+		logger     *slog.Logger
+	}
+	tests := []struct {
+		name       string
+		fields     fields
+		path       string
+		wantStatus int
+	}{
+		{
+			name: "positive_get_now_playing_movies",
+			fields: fields{
+				storage:    &mock.Storage{},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			path:       "/movies/now-playing?region=pl",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "positive_get_upcoming_movies",
+			fields: fields{
+				storage:    &mock.Storage{},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			path:       "/movies/upcoming?page=2",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "positive_get_popular_movies",
+			fields: fields{
+				storage:    &mock.Storage{},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			path:       "/movies/popular",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "positive_discover_movies",
+			fields: fields{
+				storage:    &mock.Storage{},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			path:       "/movies/discover?sort_by=vote_average.desc&genres=18,35&year=2019&region=US",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "positive_get_movie",
+			fields: fields{
+				storage:    &mock.Storage{},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			path:       "/movies/" + validId,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "negative_get_now_playing_movies_invalid_region",
+			fields: fields{
+				storage:    &mock.Storage{},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			path:       "/movies/now-playing?region=POL",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "negative_get_popular_movies_tmdb_unavailable",
+			fields: fields{
+				storage: &mock.Storage{},
+				conf:    &config.Config{},
+				tmdbClient: &mock.Tmdb{
+					GetPopularMoviesErr:    true,
+					GetPopularMoviesStatus: http.StatusServiceUnavailable,
+				},
+				logger: logger,
+			},
+			path:       "/movies/popular",
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name: "negative_discover_movies_invalid_sort",
+			fields: fields{
+				storage:    &mock.Storage{},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			path:       "/movies/discover?sort_by=title.desc",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "negative_get_movie_invalid_id",
+			fields: fields{
+				storage:    &mock.Storage{},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			path:       "/movies/" + invalidId,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			a := NewApi(
+				WithConfig(tt.fields.conf),
+				WithLogger(tt.fields.logger),
+				WithStorage(tt.fields.storage),
+				WithTmdbClient(tt.fields.tmdbClient),
+			)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func Test_validDiscoverParams(t *testing.T) {
+	tests := []struct {
+		name   string
+		params *models.DiscoverParams
+		want   bool
+	}{
+		{
+			name:   "positive_empty",
+			params: &models.DiscoverParams{},
+			want:   true,
+		},
+		{
+			name:   "positive_all_params",
+			params: &models.DiscoverParams{SortBy: "release_date.asc", Genres: "18|35", Year: 1999},
+			want:   true,
+		},
+		{
+			name:   "negative_sort_without_direction",
+			params: &models.DiscoverParams{SortBy: "popularity"},
+			want:   false,
+		},
+		{
+			name:   "negative_invalid_direction",
+			params: &models.DiscoverParams{SortBy: "popularity.up"},
+			want:   false,
+		},
+		{
+			name:   "negative_invalid_genre",
+			params: &models.DiscoverParams{Genres: "18,drama"},
+			want:   false,
+		},
+		{
+			name:   "negative_mixed_genre_separators",
+			params: &models.DiscoverParams{Genres: "18,35|10"},
+			want:   false,
+		},
+		{
+			name:   "negative_year_out_of_range",
+			params: &models.DiscoverParams{Year: 1500},
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validDiscoverParams(tt.params); got != tt.want {
+				t.Errorf("validDiscoverParams() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_normalizeGenres(t *testing.T) {
+	tests := []struct {
+		name   string
+		genres string
+		want   string
+	}{
+		{
+			name: "positive_empty",
+		},
+		{
+			name:   "positive_all_genres_sorted",
+			genres: "35,18",
+			want:   "18,35",
+		},
+		{
+			name:   "positive_any_genre_deduped",
+			genres: "35|18|35",
+			want:   "18|35",
+		},
+		{
+			name:   "positive_empty_ids_skipped",
+			genres: "18,,35,",
+			want:   "18,35",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeGenres(tt.genres); got != tt.want {
+				t.Errorf("normalizeGenres() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/BarTar213/movies-service/health"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	storageCheck     = "storage"
+	tmdbCheck        = "tmdb"
+	notificatorCheck = "notificator"
+)
+
+type HealthHandlers struct {
+	checker *health.Checker
+}
+
+func NewHealthHandlers(checker *health.Checker) *HealthHandlers {
+	return &HealthHandlers{
+		checker: checker,
+	}
+}
+
+// storage is checked on every request as service can't work without it, TMDB and notificator are checked
+// in background and reported without failing readiness, movies are served from storage when TMDB is down
+func newHealthChecker(a *Api) *health.Checker {
+	checker := health.NewChecker(a.Config.Health.Timeout, a.Config.Health.Interval)
+	checker.Add(storageCheck, true, a.Storage.Ping)
+	if a.TmdbClient != nil {
+		checker.AddCached(tmdbCheck, false, a.TmdbClient.Ping)
+	}
+	if len(a.Config.Notificator.Address) > 0 {
+		checker.AddCached(notificatorCheck, false, health.Dial(a.Config.Notificator.Address))
+	}
+
+	return checker
+}
+
+// Liveness reports that process serves requests, dependencies are not checked
+func (h *HealthHandlers) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, health.Report{Status: health.StatusUp})
+}
+
+// Readiness reports status of every dependency, service isn't ready when critical one is down
+func (h *HealthHandlers) Readiness(c *gin.Context) {
+	report := h.checker.Check(c.Request.Context())
+
+	status := http.StatusOK
+	if report.Status == health.StatusDown {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
+
+// RunHealthChecks refreshes cached health checks until ctx is done
+func (a *Api) RunHealthChecks(ctx context.Context) {
+	a.Health.Run(ctx)
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BarTar213/movies-service/config"
+	"github.com/BarTar213/movies-service/health"
+	"github.com/BarTar213/movies-service/mock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestHealthHandlers(t *testing.T) {
+	tests := []struct {
+		name         string
+		url          string
+		storage      *mock.Storage
+		wantStatus   int
+		wantHealth   string
+		wantStorage  string
+		wantTmdbSeen bool
+	}{
+		{
+			name:       "positive_liveness",
+			url:        "/healthz",
+			storage:    &mock.Storage{PingErr: true},
+			wantStatus: http.StatusOK,
+			wantHealth: health.StatusUp,
+		},
+		{
+			name:         "positive_readiness_tmdb_not_checked_yet",
+			url:          "/readyz",
+			storage:      &mock.Storage{},
+			wantStatus:   http.StatusOK,
+			wantHealth:   health.StatusDegraded,
+			wantStorage:  health.StatusUp,
+			wantTmdbSeen: true,
+		},
+		{
+			name:         "negative_readiness_storage_down",
+			url:          "/readyz",
+			storage:      &mock.Storage{PingErr: true},
+			wantStatus:   http.StatusServiceUnavailable,
+			wantHealth:   health.StatusDown,
+			wantStorage:  health.StatusDown,
+			wantTmdbSeen: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			a := NewApi(
+				WithConfig(&config.Config{}),
+				WithLogger(logger),
+				WithStorage(tt.storage),
+				WithTmdbClient(&mock.Tmdb{}),
+			)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, tt.url, nil)
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+
+			report := health.Report{}
+			if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+				t.Fatalf("invalid response body %s: %s", w.Body.String(), err)
+			}
+			if report.Status != tt.wantHealth {
+				t.Errorf("status = %s, want %s", report.Status, tt.wantHealth)
+			}
+			if got := report.Checks[storageCheck].Status; got != tt.wantStorage {
+				t.Errorf("storage status = %s, want %s", got, tt.wantStorage)
+			}
+			if _, ok := report.Checks[tmdbCheck]; ok != tt.wantTmdbSeen {
+				t.Errorf("tmdb reported = %v, want %v", ok, tt.wantTmdbSeen)
+			}
+		})
+	}
+}
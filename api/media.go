@@ -0,0 +1,256 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BarTar213/movies-service/logging"
+	"github.com/BarTar213/movies-service/models"
+	"github.com/BarTar213/movies-service/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	//matches images and videos without language
+	noLanguage = "null"
+
+	youtubeSite = "YouTube"
+	vimeoSite   = "Vimeo"
+)
+
+// key of movie media remembered as empty on TMDB
+type mediaKey struct {
+	movieId  int
+	resource string
+}
+
+func (h *MovieHandlers) GetImages(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param(movieIdKey))
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, models.CodeInvalidParam, invalidMovieIdParamErr)
+		return
+	}
+
+	params := &models.MediaParams{}
+	err = c.ShouldBindQuery(params)
+	if err != nil {
+		utils.RespondBindingError(c, models.CodeInvalidQuery, invalidMediaQueryParams, err)
+		return
+	}
+
+	images, err := h.storage.ListImages(c.Request.Context(), id)
+	if err != nil {
+		handleStorageError(c, h.logger, err, imagesResource)
+		return
+	}
+
+	key := mediaKey{movieId: id, resource: imagesResource}
+	if len(images) == 0 && !h.knownEmpty(key) {
+		tmdbImages := &models.Images{}
+		status, err := h.tmdb.GetImages(c.Request.Context(), id, tmdbImages)
+		if err != nil || status != http.StatusOK {
+			handleTMDBError(c, h.logger, status, err, imagesResource)
+			return
+		}
+		images = flattenImages(id, tmdbImages)
+		if len(images) == 0 {
+			h.rememberEmpty(key)
+		} else {
+			h.tasks.Go(c.Request.Context(), "add images", func(ctx context.Context) {
+				h.AddImages(ctx, images)
+			})
+		}
+	}
+
+	c.JSON(http.StatusOK, h.groupImages(id, images, languageFilter(params.Language)))
+}
+
+func (h *MovieHandlers) AddImages(ctx context.Context, images []*models.Image) {
+	err := h.storage.AddImages(ctx, images)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "add images", logging.Err(err))
+	}
+}
+
+func (h *MovieHandlers) GetVideos(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param(movieIdKey))
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, models.CodeInvalidParam, invalidMovieIdParamErr)
+		return
+	}
+
+	params := &models.MediaParams{}
+	err = c.ShouldBindQuery(params)
+	if err != nil {
+		utils.RespondBindingError(c, models.CodeInvalidQuery, invalidMediaQueryParams, err)
+		return
+	}
+
+	videos, err := h.storage.ListVideos(c.Request.Context(), id)
+	if err != nil {
+		handleStorageError(c, h.logger, err, videosResource)
+		return
+	}
+
+	key := mediaKey{movieId: id, resource: videosResource}
+	if len(videos) == 0 && !h.knownEmpty(key) {
+		tmdbVideos := &models.Videos{}
+		status, err := h.tmdb.GetVideos(c.Request.Context(), id, tmdbVideos)
+		if err != nil || status != http.StatusOK {
+			handleTMDBError(c, h.logger, status, err, videosResource)
+			return
+		}
+		for _, video := range tmdbVideos.Results {
+			video.MovieId = id
+		}
+		videos = tmdbVideos.Results
+		if len(videos) == 0 {
+			h.rememberEmpty(key)
+		} else {
+			h.tasks.Go(c.Request.Context(), "add videos", func(ctx context.Context) {
+				h.AddVideos(ctx, videos)
+			})
+		}
+	}
+
+	filter := languageFilter(params.Language)
+	result := &models.Videos{Id: id, Results: make([]*models.Video, 0, len(videos))}
+	for _, video := range videos {
+		if !filter(video.Language) {
+			continue
+		}
+		resolved := *video
+		resolved.Url = videoUrl(video)
+		result.Results = append(result.Results, &resolved)
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *MovieHandlers) AddVideos(ctx context.Context, videos []*models.Video) {
+	err := h.storage.AddVideos(ctx, videos)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "add videos", logging.Err(err))
+	}
+}
+
+// knownEmpty reports if TMDB had no media of movie recently, expired entries are forgotten
+func (h *MovieHandlers) knownEmpty(key mediaKey) bool {
+	h.emptyMu.Lock()
+	defer h.emptyMu.Unlock()
+
+	expiry, ok := h.emptyMedia[key]
+	if !ok {
+		return false
+	}
+	if time.Now().Before(expiry) {
+		return true
+	}
+	delete(h.emptyMedia, key)
+	return false
+}
+
+// rememberEmpty remembers that TMDB has no media of movie, when limit is reached expired entries are pruned
+// and if none expired the one expiring soonest is dropped
+func (h *MovieHandlers) rememberEmpty(key mediaKey) {
+	h.emptyMu.Lock()
+	defer h.emptyMu.Unlock()
+
+	now := time.Now()
+	if _, ok := h.emptyMedia[key]; !ok && len(h.emptyMedia) >= emptyMediaLimit {
+		var oldest mediaKey
+		var oldestExpiry time.Time
+		for k, expiry := range h.emptyMedia {
+			if !now.Before(expiry) {
+				delete(h.emptyMedia, k)
+				continue
+			}
+			if oldestExpiry.IsZero() || expiry.Before(oldestExpiry) {
+				oldest, oldestExpiry = k, expiry
+			}
+		}
+		if len(h.emptyMedia) >= emptyMediaLimit {
+			delete(h.emptyMedia, oldest)
+		}
+	}
+	h.emptyMedia[key] = now.Add(emptyMediaTTL)
+}
+
+func flattenImages(movieId int, images *models.Images) []*models.Image {
+	flat := make([]*models.Image, 0, len(images.Backdrops)+len(images.Posters)+len(images.Logos))
+	add := func(imageType string, list []*models.Image) {
+		for _, image := range list {
+			image.MovieId = movieId
+			image.Type = imageType
+			flat = append(flat, image)
+		}
+	}
+	add(models.BackdropImage, images.Backdrops)
+	add(models.PosterImage, images.Posters)
+	add(models.LogoImage, images.Logos)
+
+	return flat
+}
+
+// returns copies with resolved urls so the originals can be safely stored in background
+func (h *MovieHandlers) groupImages(movieId int, images []*models.Image, filter func(string) bool) *models.Images {
+	grouped := &models.Images{
+		Id:        movieId,
+		Backdrops: make([]*models.Image, 0),
+		Posters:   make([]*models.Image, 0),
+		Logos:     make([]*models.Image, 0),
+	}
+
+	for _, image := range images {
+		if !filter(image.Language) {
+			continue
+		}
+		resolved := *image
+		resolved.Url = fmt.Sprintf("%s/%s%s", h.conf.Tmdb.ImageUrl, h.conf.Tmdb.ImageSize, image.FilePath)
+
+		switch image.Type {
+		case models.BackdropImage:
+			grouped.Backdrops = append(grouped.Backdrops, &resolved)
+		case models.PosterImage:
+			grouped.Posters = append(grouped.Posters, &resolved)
+		case models.LogoImage:
+			grouped.Logos = append(grouped.Logos, &resolved)
+		}
+	}
+
+	return grouped
+}
+
+// languages are comma separated ISO 639-1 codes, "null" matches media without language
+func languageFilter(languages string) func(string) bool {
+	if len(languages) == 0 {
+		return func(string) bool { return true }
+	}
+
+	allowed := make(map[string]bool)
+	for _, language := range strings.Split(languages, ",") {
+		language = strings.TrimSpace(language)
+		if language == noLanguage {
+			language = ""
+		}
+		allowed[language] = true
+	}
+
+	return func(language string) bool {
+		return allowed[language]
+	}
+}
+
+func videoUrl(video *models.Video) string {
+	switch video.Site {
+	case youtubeSite:
+		return fmt.Sprintf("https://www.youtube.com/watch?v=%s", video.Key)
+	case vimeoSite:
+		return fmt.Sprintf("https://vimeo.com/%s", video.Key)
+	}
+	return ""
+}
@@ -0,0 +1,331 @@
+package api
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/BarTar213/movies-service/config"
+	"github.com/BarTar213/movies-service/mock"
+	"github.com/BarTar213/movies-service/models"
+	"github.com/BarTar213/movies-service/storage"
+	"github.com/BarTar213/movies-service/tmdb"
+	"github.com/gin-gonic/gin"
+)
+
+func TestMovieHandlers_GetImages(t *testing.T) {
+	type fields struct {
+		storage    storage.Storage
+		conf       *config.Config
+		tmdbClient tmdb.Client
+		logger     *slog.Logger
+	}
+	tests := []struct {
+		name       string
+		fields     fields
+		movieId    string
+		query      string
+		wantStatus int
+	}{
+		{
+			name: "positive_get_images",
+			fields: fields{
+				storage:    &mock.Storage{},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			movieId:    validId,
+			query:      "?language=en,null",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "positive_get_images_from_tmdb",
+			fields: fields{
+				storage: &mock.Storage{
+					ListImagesEmpty: true,
+				},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			movieId:    validId,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "negative_get_images_invalid_movie_id",
+			fields: fields{
+				storage:    &mock.Storage{},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			movieId:    invalidId,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "negative_get_images_storage_error",
+			fields: fields{
+				storage: &mock.Storage{
+					ListImagesErr: true,
+				},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			movieId:    validId,
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "negative_get_images_tmdb_error",
+			fields: fields{
+				storage: &mock.Storage{
+					ListImagesEmpty: true,
+				},
+				conf: &config.Config{},
+				tmdbClient: &mock.Tmdb{
+					GetImagesErr:    true,
+					GetImagesStatus: http.StatusInternalServerError,
+				},
+				logger: logger,
+			},
+			movieId:    validId,
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			a := NewApi(
+				WithConfig(tt.fields.conf),
+				WithLogger(tt.fields.logger),
+				WithStorage(tt.fields.storage),
+				WithTmdbClient(tt.fields.tmdbClient),
+			)
+
+			w := httptest.NewRecorder()
+			reqUrl := fmt.Sprintf("/movies/%s/images%s", tt.movieId, tt.query)
+			req, _ := http.NewRequest(http.MethodGet, reqUrl, nil)
+
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestMovieHandlers_GetVideos(t *testing.T) {
+	type fields struct {
+		storage    storage.Storage
+		conf       *config.Config
+		tmdbClient tmdb.Client
+		logger     *slog.Logger
+	}
+	tests := []struct {
+		name       string
+		fields     fields
+		movieId    string
+		query      string
+		wantStatus int
+	}{
+		{
+			name: "positive_get_videos",
+			fields: fields{
+				storage:    &mock.Storage{},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			movieId:    validId,
+			query:      "?language=en",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "positive_get_videos_from_tmdb",
+			fields: fields{
+				storage: &mock.Storage{
+					ListVideosEmpty: true,
+				},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			movieId:    validId,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "negative_get_videos_invalid_movie_id",
+			fields: fields{
+				storage:    &mock.Storage{},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			movieId:    invalidId,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "negative_get_videos_storage_error",
+			fields: fields{
+				storage: &mock.Storage{
+					ListVideosErr: true,
+				},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			movieId:    validId,
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "negative_get_videos_tmdb_error",
+			fields: fields{
+				storage: &mock.Storage{
+					ListVideosEmpty: true,
+				},
+				conf: &config.Config{},
+				tmdbClient: &mock.Tmdb{
+					GetVideosErr:    true,
+					GetVideosStatus: http.StatusInternalServerError,
+				},
+				logger: logger,
+			},
+			movieId:    validId,
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			a := NewApi(
+				WithConfig(tt.fields.conf),
+				WithLogger(tt.fields.logger),
+				WithStorage(tt.fields.storage),
+				WithTmdbClient(tt.fields.tmdbClient),
+			)
+
+			w := httptest.NewRecorder()
+			reqUrl := fmt.Sprintf("/movies/%s/videos%s", tt.movieId, tt.query)
+			req, _ := http.NewRequest(http.MethodGet, reqUrl, nil)
+
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestMovieHandlers_emptyMediaCached(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource string
+		failTmdb func(client *mock.Tmdb)
+	}{
+		{
+			name:     "positive_empty_images_not_fetched_again",
+			resource: "images",
+			failTmdb: func(client *mock.Tmdb) {
+				client.GetImagesErr = true
+				client.GetImagesStatus = http.StatusInternalServerError
+			},
+		},
+		{
+			name:     "positive_empty_videos_not_fetched_again",
+			resource: "videos",
+			failTmdb: func(client *mock.Tmdb) {
+				client.GetVideosErr = true
+				client.GetVideosStatus = http.StatusInternalServerError
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			tmdbClient := &mock.Tmdb{}
+			a := NewApi(
+				WithConfig(&config.Config{}),
+				WithLogger(logger),
+				WithStorage(&mock.Storage{ListImagesEmpty: true, ListVideosEmpty: true}),
+				WithTmdbClient(tmdbClient),
+			)
+			reqUrl := fmt.Sprintf("/movies/%s/%s", validId, tt.resource)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, reqUrl, nil)
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, http.StatusOK, w.Code)
+
+			//TMDB had nothing, so its failure can't affect following request
+			tt.failTmdb(tmdbClient)
+			w = httptest.NewRecorder()
+			req, _ = http.NewRequest(http.MethodGet, reqUrl, nil)
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, http.StatusOK, w.Code)
+		})
+	}
+}
+
+func TestMovieHandlers_rememberEmpty(t *testing.T) {
+	tests := []struct {
+		name    string
+		expiry  time.Duration
+		wantLen int
+	}{
+		{
+			name:    "positive_expired_entries_pruned_at_limit",
+			expiry:  -time.Hour,
+			wantLen: 1,
+		},
+		{
+			name:    "positive_soonest_expiring_dropped_at_limit",
+			expiry:  time.Hour,
+			wantLen: emptyMediaLimit,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewMovieHandlers(&config.Config{}, &mock.Storage{}, nil, nil, nil, nil, logger)
+			now := time.Now()
+			for i := 0; i < emptyMediaLimit; i++ {
+				h.emptyMedia[mediaKey{movieId: i, resource: imagesResource}] = now.Add(tt.expiry + time.Duration(i)*time.Millisecond)
+			}
+			key := mediaKey{movieId: 1, resource: videosResource}
+
+			h.rememberEmpty(key)
+			if len(h.emptyMedia) != tt.wantLen {
+				t.Errorf("rememberEmpty() remembered %d entries, want %d", len(h.emptyMedia), tt.wantLen)
+			}
+			if _, ok := h.emptyMedia[mediaKey{movieId: 0, resource: imagesResource}]; ok {
+				t.Errorf("rememberEmpty() kept entry expiring soonest")
+			}
+			if !h.knownEmpty(key) {
+				t.Errorf("rememberEmpty() didn't remember %v", key)
+			}
+		})
+	}
+}
+
+func TestMovieHandlers_groupImages(t *testing.T) {
+	h := &MovieHandlers{
+		conf: &config.Config{
+			Tmdb: config.Tmdb{ImageUrl: "https://image.tmdb.org/t/p", ImageSize: "w500"},
+		},
+	}
+	images := []*models.Image{
+		{FilePath: "/poster_en.jpg", Type: models.PosterImage, Language: "en"},
+		{FilePath: "/poster_pl.jpg", Type: models.PosterImage, Language: "pl"},
+		{FilePath: "/backdrop.jpg", Type: models.BackdropImage},
+	}
+
+	got := h.groupImages(1, images, languageFilter("en,null"))
+	if len(got.Posters) != 1 || got.Posters[0].Url != "https://image.tmdb.org/t/p/w500/poster_en.jpg" {
+		t.Errorf("groupImages() posters = %v", got.Posters)
+	}
+	if len(got.Backdrops) != 1 || got.Backdrops[0].Url != "https://image.tmdb.org/t/p/w500/backdrop.jpg" {
+		t.Errorf("groupImages() backdrops = %v", got.Backdrops)
+	}
+	if len(images[0].Url) != 0 {
+		t.Errorf("groupImages() modified stored image")
+	}
+}
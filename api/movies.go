@@ -1,53 +1,96 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/BarTar213/movies-service/config"
+	"github.com/BarTar213/movies-service/logging"
+	"github.com/BarTar213/movies-service/metrics"
 	"github.com/BarTar213/movies-service/models"
+	"github.com/BarTar213/movies-service/provider"
 	"github.com/BarTar213/movies-service/storage"
 	"github.com/BarTar213/movies-service/tmdb"
 	"github.com/BarTar213/movies-service/utils"
 	"github.com/gin-gonic/gin"
-	"github.com/go-pg/pg/v10"
 )
 
 const (
 	movieIdKey = "movieId"
+
+	popularityOrder   = "popularity DESC"
+	voteAverageOrder  = "vote_average DESC"
+	storedMoviesLimit = 20
+
+	staleResponseWarning = `110 - "Response is Stale"`
+
+	dayWindow  = "day"
+	weekWindow = "week"
+	//TMDB doesn't serve pages above this one
+	maxTmdbPage = 500
 )
 
 type MovieHandlers struct {
-	storage storage.Storage
-	tmdb    tmdb.Client
-	logger  *log.Logger
+	conf        *config.Config
+	storage     storage.Storage
+	tmdb        tmdb.Client
+	providers   provider.Provider
+	metrics     *metrics.Metrics
+	tasks       *Tasks
+	logger      *slog.Logger
+	refreshing  sync.Map
+	translating sync.Map
+	emptyMu     sync.Mutex
+	emptyMedia  map[mediaKey]time.Time
 }
 
-func NewMovieHandlers(postgres storage.Storage, tmdb tmdb.Client, logger *log.Logger) *MovieHandlers {
+func NewMovieHandlers(conf *config.Config, postgres storage.Storage, tmdb tmdb.Client, providers provider.Provider, metrics *metrics.Metrics, tasks *Tasks, logger *slog.Logger) *MovieHandlers {
 	return &MovieHandlers{
-		storage: postgres,
-		tmdb:    tmdb,
-		logger:  logger,
+		conf:       conf,
+		storage:    postgres,
+		tmdb:       tmdb,
+		providers:  providers,
+		metrics:    metrics,
+		tasks:      tasks,
+		logger:     logger,
+		emptyMedia: make(map[mediaKey]time.Time),
 	}
 }
 
 func (h *MovieHandlers) GetMovie(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param(movieIdKey))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.Response{Error: err.Error()})
+		utils.RespondError(c, http.StatusBadRequest, models.CodeInvalidParam, invalidMovieIdParamErr)
 		return
 	}
 
 	movie := &models.Movie{Id: id}
-	err = h.storage.GetMovie(movie)
+	err = h.storage.GetMovie(c.Request.Context(), movie)
+	if errors.Is(err, storage.ErrNotFound) {
+		if !h.fetchMovie(c, id) {
+			return
+		}
+		err = h.storage.GetMovie(c.Request.Context(), movie)
+	}
 	if err != nil {
-		handlePostgresError(c, h.logger, err, movieResource)
+		handleStorageError(c, h.logger, err, movieResource)
 		return
 	}
-	go h.AddRecentViewedMovie(c.Copy(), id)
+	account := models.AccountInfo{}
+	if c.ShouldBindHeader(&account) == nil {
+		h.tasks.Go(c.Request.Context(), "add recent viewed movie", func(ctx context.Context) {
+			h.AddRecentViewedMovie(ctx, account.ID, id)
+		})
+	}
 
+	h.translateMovie(c.Request.Context(), movie, getLanguage(c))
 	c.JSON(http.StatusOK, movie)
 }
 
@@ -55,25 +98,33 @@ func (h *MovieHandlers) ListMovies(c *gin.Context) {
 	params := models.PaginationParams{OrderBy: "revenue DESC"}
 	err := c.ShouldBindQuery(&params)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.Response{Error: invalidPaginationQueryParams})
+		utils.RespondBindingError(c, models.CodeInvalidQuery, invalidPaginationQueryParams, err)
 		return
 	}
 
-	title := fmt.Sprintf("%%%s%%", c.Query("title"))
+	filters := models.MovieFilters{}
+	err = c.ShouldBindQuery(&filters)
+	if err != nil {
+		utils.RespondBindingError(c, models.CodeInvalidQuery, invalidMovieFiltersErr, err)
+		return
+	}
+	filters.Title = fmt.Sprintf("%%%s%%", filters.Title)
+	filters.Language = getLanguage(c)
 
-	movies, err := h.storage.ListMovies(title, &params)
+	movies, err := h.storage.ListMovies(c.Request.Context(), &filters, &params)
 	if err != nil {
-		handlePostgresError(c, h.logger, err, movieResource)
+		handleStorageError(c, h.logger, err, movieResource)
 		return
 	}
 
+	h.translatePreviews(c.Request.Context(), movies, filters.Language)
 	c.JSON(http.StatusOK, movies)
 }
 
 func (h *MovieHandlers) LikeMovie(c *gin.Context) {
 	movieId, err := strconv.Atoi(c.Param(movieIdKey))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.Response{Error: err.Error()})
+		utils.RespondError(c, http.StatusBadRequest, models.CodeInvalidParam, invalidMovieIdParamErr)
 		return
 	}
 
@@ -81,33 +132,30 @@ func (h *MovieHandlers) LikeMovie(c *gin.Context) {
 
 	liked, err := strconv.ParseBool(c.Query(likedParam))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.Response{Error: invalidLikedParamErr})
+		utils.RespondError(c, http.StatusBadRequest, models.CodeInvalidParam, invalidLikedParamErr)
 		return
 	}
 
+	action := metrics.LikeAction
 	if liked {
-		err = h.storage.DeleteMovieLike(account.ID, movieId)
+		action = metrics.UnlikeAction
+		err = h.storage.DeleteMovieLike(c.Request.Context(), account.ID, movieId)
 	} else {
-		err = h.storage.LikeMovie(account.ID, movieId)
+		err = h.storage.LikeMovie(c.Request.Context(), account.ID, movieId)
 	}
 	if err != nil {
-		handlePostgresError(c, h.logger, err, movieCommentResource)
+		handleStorageError(c, h.logger, err, movieCommentResource)
 		return
 	}
+	h.metrics.Likes.WithLabelValues(metrics.MovieResource, action).Inc()
 
 	c.JSON(http.StatusOK, models.Response{})
 }
 
-func (h *MovieHandlers) AddRecentViewedMovie(c *gin.Context, movieId int) {
-	account := models.AccountInfo{}
-	err := c.ShouldBindHeader(&account)
+func (h *MovieHandlers) AddRecentViewedMovie(ctx context.Context, accountId int, movieId int) {
+	err := h.storage.AddRecentViewedMovie(ctx, accountId, movieId)
 	if err != nil {
-		return
-	}
-
-	err = h.storage.AddRecentViewedMovie(account.ID, movieId)
-	if err != nil {
-		h.logger.Printf("addRecentViewedMovie: %s", err)
+		h.logger.ErrorContext(ctx, "add recent viewed movie", slog.Int("account_id", accountId), slog.Int("movie_id", movieId), logging.Err(err))
 	}
 }
 
@@ -115,15 +163,15 @@ func (h *MovieHandlers) ListLikedMovies(c *gin.Context) {
 	params := &models.PaginationParams{OrderBy: "revenue DESC"}
 	err := c.ShouldBindQuery(params)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.Response{Error: invalidPaginationQueryParams})
+		utils.RespondBindingError(c, models.CodeInvalidQuery, invalidPaginationQueryParams, err)
 		return
 	}
 
 	account := utils.GetAccount(c)
 
-	movies, err := h.storage.ListLikedMovies(account.ID, params)
+	movies, err := h.storage.ListLikedMovies(c.Request.Context(), account.ID, params)
 	if err != nil {
-		handlePostgresError(c, h.logger, err, movieCommentResource)
+		handleStorageError(c, h.logger, err, movieCommentResource)
 		return
 	}
 
@@ -133,7 +181,7 @@ func (h *MovieHandlers) ListLikedMovies(c *gin.Context) {
 func (h *MovieHandlers) CheckLiked(c *gin.Context) {
 	movieId, err := strconv.Atoi(c.Param(movieIdKey))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.Response{Error: err.Error()})
+		utils.RespondError(c, http.StatusBadRequest, models.CodeInvalidParam, invalidMovieIdParamErr)
 		return
 	}
 
@@ -143,9 +191,9 @@ func (h *MovieHandlers) CheckLiked(c *gin.Context) {
 		MovieId: movieId,
 		UserId:  account.ID,
 	}
-	liked, err := h.storage.CheckLiked(likedMovie)
+	liked, err := h.storage.CheckLiked(c.Request.Context(), likedMovie)
 	if err != nil {
-		handlePostgresError(c, h.logger, err, movieCommentResource)
+		handleStorageError(c, h.logger, err, movieCommentResource)
 		return
 	}
 
@@ -155,7 +203,7 @@ func (h *MovieHandlers) CheckLiked(c *gin.Context) {
 func (h *MovieHandlers) RateMovie(c *gin.Context) {
 	movieId, err := strconv.Atoi(c.Param(movieIdKey))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.Response{Error: err.Error()})
+		utils.RespondError(c, http.StatusBadRequest, models.CodeInvalidParam, invalidMovieIdParamErr)
 		return
 	}
 
@@ -164,26 +212,27 @@ func (h *MovieHandlers) RateMovie(c *gin.Context) {
 	rating := &models.Rating{}
 	err = c.ShouldBindJSON(rating)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.Response{Error: invalidRequestBodyErr})
+		utils.RespondBindingError(c, models.CodeInvalidBody, invalidRequestBodyErr, err)
 		return
 	}
 	rating.UserId = account.ID
 	rating.MovieId = movieId
 	rating.CreateDate = time.Now()
 
-	err = h.storage.AddRating(rating)
+	change, err := h.storage.AddRating(c.Request.Context(), rating)
 	if err != nil {
-		handlePostgresError(c, h.logger, err, ratingResource)
+		handleStorageError(c, h.logger, err, ratingResource)
 		return
 	}
+	h.observeRating(change)
 
 	c.JSON(http.StatusCreated, rating)
 }
 
-func (h MovieHandlers) DeleteRating(c *gin.Context) {
+func (h *MovieHandlers) DeleteRating(c *gin.Context) {
 	movieId, err := strconv.Atoi(c.Param(movieIdKey))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.Response{Error: err.Error()})
+		utils.RespondError(c, http.StatusBadRequest, models.CodeInvalidParam, invalidMovieIdParamErr)
 		return
 	}
 
@@ -193,28 +242,41 @@ func (h MovieHandlers) DeleteRating(c *gin.Context) {
 		UserId:  account.ID,
 		MovieId: movieId,
 	}
-	err = h.storage.DeleteRating(rating)
+	deleted, err := h.storage.DeleteRating(c.Request.Context(), rating)
 	if err != nil {
-		handlePostgresError(c, h.logger, err, ratingResource)
+		handleStorageError(c, h.logger, err, ratingResource)
 		return
 	}
+	if deleted {
+		h.metrics.Ratings.WithLabelValues(metrics.RemovedAction).Inc()
+	}
 
 	c.JSON(http.StatusOK, &models.Response{})
 }
 
+// ratings with unchanged value are not counted, storage doesn't write them
+func (h *MovieHandlers) observeRating(change storage.RatingChange) {
+	switch change {
+	case storage.RatingAdded:
+		h.metrics.Ratings.WithLabelValues(metrics.AddedAction).Inc()
+	case storage.RatingChanged:
+		h.metrics.Ratings.WithLabelValues(metrics.ChangedAction).Inc()
+	}
+}
+
 func (h *MovieHandlers) ListRatedMovies(c *gin.Context) {
 	params := &models.PaginationParams{OrderBy: "create_date DESC"}
 	err := c.ShouldBindQuery(params)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.Response{Error: invalidPaginationQueryParams})
+		utils.RespondBindingError(c, models.CodeInvalidQuery, invalidPaginationQueryParams, err)
 		return
 	}
 
 	account := utils.GetAccount(c)
 
-	ratings, err := h.storage.ListRatedMovies(account.ID, params)
+	ratings, err := h.storage.ListRatedMovies(c.Request.Context(), account.ID, params)
 	if err != nil {
-		handlePostgresError(c, h.logger, err, ratingResource)
+		handleStorageError(c, h.logger, err, ratingResource)
 		return
 	}
 
@@ -224,7 +286,7 @@ func (h *MovieHandlers) ListRatedMovies(c *gin.Context) {
 func (h *MovieHandlers) GetRating(c *gin.Context) {
 	movieId, err := strconv.Atoi(c.Param(movieIdKey))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.Response{Error: err.Error()})
+		utils.RespondError(c, http.StatusBadRequest, models.CodeInvalidParam, invalidMovieIdParamErr)
 		return
 	}
 
@@ -235,9 +297,9 @@ func (h *MovieHandlers) GetRating(c *gin.Context) {
 		MovieId: movieId,
 		Rating:  intPointer(0),
 	}
-	err = h.storage.GetRating(rating)
-	if err != nil && err != pg.ErrNoRows {
-		handlePostgresError(c, h.logger, err, ratingResource)
+	err = h.storage.GetRating(c.Request.Context(), rating)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		handleStorageError(c, h.logger, err, ratingResource)
 		return
 	}
 
@@ -245,37 +307,212 @@ func (h *MovieHandlers) GetRating(c *gin.Context) {
 }
 
 func (h *MovieHandlers) GetTrendingMovies(c *gin.Context) {
-	movies, status, err := h.tmdb.GetTrendingMovies()
+	params, ok := bindTmdbListParams(c)
+	if !ok {
+		return
+	}
+	//window is used only by trending, other lists ignore it
+	if params.Window != dayWindow && params.Window != weekWindow {
+		utils.RespondError(c, http.StatusBadRequest, models.CodeInvalidQuery, invalidListQueryParams)
+		return
+	}
+
+	meta := &models.PageMeta{}
+	movies, status, err := h.providers.ListMovies(c.Request.Context(), provider.ListTrending, params, meta)
 	if err != nil || status != http.StatusOK {
-		handleTMDBError(c, h.logger, status, err, creditsResource)
+		if stored, ok := h.listStoredMovies(c, status, popularityOrder, params.Page); ok {
+			respondStale(c, tmdbMoviesFromPreviews(stored), params.Page)
+			return
+		}
+		handleTMDBError(c, h.logger, status, err, moviesResource)
 		return
 	}
-	c.JSON(http.StatusOK, movies)
-	go h.AddMovies(movies)
+
+	translated := make([]models.TmdbMovie, len(movies))
+	copy(translated, movies)
+	h.tasks.Go(c.Request.Context(), "add movies", func(ctx context.Context) {
+		h.AddMovies(ctx, movies)
+	})
+
+	h.translateTmdbMovies(c.Request.Context(), translated, getLanguage(c))
+	c.JSON(http.StatusOK, models.Response{Data: translated, Meta: meta})
+}
+
+// fetches movie unknown to storage from providers and stores it, returns false when response was written
+func (h *MovieHandlers) fetchMovie(c *gin.Context, id int) bool {
+	movie := &models.TmdbMovie{Id: id}
+	status, err := h.providers.GetMovie(c.Request.Context(), movie)
+	if err != nil || status != http.StatusOK {
+		handleTMDBError(c, h.logger, status, err, movieResource)
+		return false
+	}
+
+	movie.VoteCount = 0
+	err = h.storage.AddMovie(c.Request.Context(), movie)
+	if err != nil {
+		handleStorageError(c, h.logger, err, movieResource)
+		return false
+	}
+	return true
 }
 
-func (h *MovieHandlers) AddMovies(movies []models.TmdbMovie) {
+// stores movies fetched from TMDB lists, provider of record is kept when movies came from other provider
+func (h *MovieHandlers) AddMovies(ctx context.Context, movies []models.TmdbMovie) {
 	for i := range movies {
 		movies[i].VoteCount = 0
-		err := h.storage.AddMovie(&movies[i])
-		if err != nil {
-			h.logger.Printf("Storage add movie: %s", err)
+		if len(movies[i].Provider) == 0 {
+			movies[i].Provider = provider.TmdbName
 		}
 	}
+
+	err := h.storage.AddMovies(ctx, movies)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "add movies", slog.Int("count", len(movies)), logging.Err(err))
+	}
 }
 
 func (h *MovieHandlers) GetTopRatedMovies(c *gin.Context) {
-	ids, status, err := h.tmdb.GetTopRatedMovies()
+	switch c.Query(sourceParam) {
+	case "", tmdbSource:
+	case communitySource:
+		h.GetCommunityRanking(c)
+		return
+	default:
+		utils.RespondError(c, http.StatusBadRequest, models.CodeInvalidParam, invalidSourceParam)
+		return
+	}
+
+	params, ok := bindTmdbListParams(c)
+	if !ok {
+		return
+	}
+
+	meta := &models.PageMeta{}
+	ranked, status, err := h.providers.ListMovies(c.Request.Context(), provider.ListTopRated, params, meta)
 	if err != nil || status != http.StatusOK {
-		handleTMDBError(c, h.logger, status, err, creditsResource)
+		if stored, ok := h.listStoredMovies(c, status, voteAverageOrder, params.Page); ok {
+			respondStale(c, stored, params.Page)
+			return
+		}
+		handleTMDBError(c, h.logger, status, err, moviesResource)
 		return
 	}
 
-	movies, err := h.storage.ListMoviesFromIDs(ids)
+	ids := make([]int, 0, len(ranked))
+	for i := range ranked {
+		ids = append(ids, ranked[i].Id)
+	}
+	stored, err := h.storage.ListMoviesFromIDs(c.Request.Context(), ids)
 	if err != nil {
-		handlePostgresError(c, h.logger, err, ratingResource)
+		handleStorageError(c, h.logger, err, moviesResource)
 		return
 	}
+	movies := rankedPreviews(ranked, stored)
 
-	c.JSON(http.StatusOK, movies)
+	h.translatePreviews(c.Request.Context(), movies, getLanguage(c))
+	c.JSON(http.StatusOK, models.Response{Data: movies, Meta: meta})
+}
+
+// lists movies from storage to serve when TMDB failed on its side, returns false when they can't be served
+func (h *MovieHandlers) listStoredMovies(c *gin.Context, status int, orderBy string, page int) ([]models.MoviePreview, bool) {
+	if status == http.StatusNotFound || c.Request.Context().Err() != nil {
+		return nil, false
+	}
+
+	filters := &models.MovieFilters{Title: "%%"}
+	params := &models.PaginationParams{
+		OrderBy: orderBy,
+		Offset:  (page - 1) * storedMoviesLimit,
+		Limit:   storedMoviesLimit,
+	}
+	movies, err := h.storage.ListMovies(c.Request.Context(), filters, params)
+	if err != nil {
+		h.logger.ErrorContext(c.Request.Context(), "serve stored movies", logging.Err(err))
+		return nil, false
+	}
+
+	h.translatePreviews(c.Request.Context(), movies, getLanguage(c))
+	return movies, true
+}
+
+// keeps order of ranked movies, movies missing in storage are previewed from provider details
+// and skipped when provider listed only their ids
+func rankedPreviews(ranked []models.TmdbMovie, stored []models.MoviePreview) []models.MoviePreview {
+	byId := make(map[int]models.MoviePreview, len(stored))
+	for _, preview := range stored {
+		byId[preview.Id] = preview
+	}
+
+	previews := make([]models.MoviePreview, 0, len(ranked))
+	for _, movie := range ranked {
+		if preview, ok := byId[movie.Id]; ok {
+			previews = append(previews, preview)
+			continue
+		}
+		if len(movie.Title) == 0 {
+			continue
+		}
+		previews = append(previews, models.MoviePreview{
+			Id:          movie.Id,
+			Title:       movie.Title,
+			PosterPath:  movie.PosterPath,
+			ReleaseDate: movie.ReleaseDate.Time,
+			VoteAverage: movie.VoteAverage,
+		})
+	}
+	return previews
+}
+
+func respondStale(c *gin.Context, movies interface{}, page int) {
+	c.Header("Warning", staleResponseWarning)
+	c.JSON(http.StatusOK, models.Response{Data: movies, Meta: &models.PageMeta{Page: page}})
+}
+
+// stale trending keeps shape of TMDB movies, fields not stored in previews are left empty
+func tmdbMoviesFromPreviews(previews []models.MoviePreview) []models.TmdbMovie {
+	movies := make([]models.TmdbMovie, 0, len(previews))
+	for _, preview := range previews {
+		movies = append(movies, models.TmdbMovie{
+			Id:          preview.Id,
+			Title:       preview.Title,
+			PosterPath:  preview.PosterPath,
+			ReleaseDate: models.Time{Time: preview.ReleaseDate},
+			VoteAverage: preview.VoteAverage,
+		})
+	}
+	return movies
+}
+
+// binds and validates TMDB list query params, writes bad request response when they are invalid
+func bindTmdbListParams(c *gin.Context) (*models.TmdbListParams, bool) {
+	params := &models.TmdbListParams{}
+	err := c.ShouldBindQuery(params)
+	if err != nil || !validTmdbListParams(params) {
+		utils.RespondBindingError(c, models.CodeInvalidQuery, invalidListQueryParams, err)
+		return nil, false
+	}
+
+	return params, true
+}
+
+// region is upper cased as TMDB expects ISO 3166-1 code
+func validTmdbListParams(params *models.TmdbListParams) bool {
+	params.Region = strings.ToUpper(params.Region)
+
+	return validRegion(params.Region) && params.Page >= 1 && params.Page <= maxTmdbPage
+}
+
+func validRegion(region string) bool {
+	if len(region) == 0 {
+		return true
+	}
+	if len(region) != 2 {
+		return false
+	}
+	for _, r := range region {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
 }
@@ -2,16 +2,19 @@ package api
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/BarTar213/movies-service/config"
+	"github.com/BarTar213/movies-service/metrics"
 	"github.com/BarTar213/movies-service/mock"
 	"github.com/BarTar213/movies-service/models"
+	"github.com/BarTar213/movies-service/provider"
 	"github.com/BarTar213/movies-service/storage"
 	"github.com/BarTar213/movies-service/tmdb"
 	"github.com/gin-gonic/gin"
@@ -28,13 +31,17 @@ const (
 	accountRole  = "role"
 )
 
-var logger = log.New(os.Stdout, "", log.LstdFlags)
+var logger = slog.New(slog.NewTextHandler(os.Stdout, nil))
 
 func TestNewMovieHandlers(t *testing.T) {
 	type args struct {
-		postgres storage.Storage
-		tmdb     tmdb.Client
-		logger   *log.Logger
+		conf      *config.Config
+		postgres  storage.Storage
+		tmdb      tmdb.Client
+		providers provider.Provider
+		metrics   *metrics.Metrics
+		tasks     *Tasks
+		logger    *slog.Logger
 	}
 	tests := []struct {
 		name string
@@ -44,18 +51,21 @@ func TestNewMovieHandlers(t *testing.T) {
 		{
 			name: "positiveNewMovieHandlers",
 			args: args{
+				conf:     &config.Config{},
 				postgres: &mock.Storage{},
-				logger:   &log.Logger{},
+				logger:   &slog.Logger{},
 			},
 			want: &MovieHandlers{
-				storage: &mock.Storage{},
-				logger:  &log.Logger{},
+				conf:       &config.Config{},
+				storage:    &mock.Storage{},
+				logger:     &slog.Logger{},
+				emptyMedia: make(map[mediaKey]time.Time),
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := NewMovieHandlers(tt.args.postgres, tt.args.tmdb, tt.args.logger); !reflect.DeepEqual(got, tt.want) {
+			if got := NewMovieHandlers(tt.args.conf, tt.args.postgres, tt.args.tmdb, tt.args.providers, tt.args.metrics, tt.args.tasks, tt.args.logger); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("NewMovieHandlers() = %v, want %v", got, tt.want)
 			}
 		})
@@ -66,7 +76,7 @@ func TestMovieHandlers_AddRecentViewedMovie(t *testing.T) {
 	type fields struct {
 		storage storage.Storage
 		conf    *config.Config
-		logger  *log.Logger
+		logger  *slog.Logger
 	}
 	tests := []struct {
 		name    string
@@ -93,9 +103,10 @@ func TestMovieHandlers_AddRecentViewedMovie(t *testing.T) {
 
 func TestMovieHandlers_GetMovie(t *testing.T) {
 	type fields struct {
-		storage storage.Storage
-		conf    *config.Config
-		logger  *log.Logger
+		storage    storage.Storage
+		conf       *config.Config
+		tmdbClient tmdb.Client
+		logger     *slog.Logger
 	}
 	tests := []struct {
 		name       string
@@ -178,6 +189,49 @@ func TestMovieHandlers_GetMovie(t *testing.T) {
 			movieId:    validId,
 			wantStatus: http.StatusInternalServerError,
 		},
+		{
+			name: "positive_get_movie_fetched_from_provider",
+			fields: fields{
+				storage: &mock.Storage{
+					GetMovieNotFoundErr: true,
+				},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			movieId:    validId,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "negative_get_movie_not_found_in_provider",
+			fields: fields{
+				storage: &mock.Storage{
+					GetMovieNotFoundErr: true,
+				},
+				conf: &config.Config{},
+				tmdbClient: &mock.Tmdb{
+					GetMovieDetailsErr:    true,
+					GetMovieDetailsStatus: http.StatusServiceUnavailable,
+				},
+				logger: logger,
+			},
+			movieId:    validId,
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name: "negative_get_movie_add_movie_error",
+			fields: fields{
+				storage: &mock.Storage{
+					GetMovieNotFoundErr: true,
+					AddMovieErr:         true,
+				},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			movieId:    validId,
+			wantStatus: http.StatusInternalServerError,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -186,6 +240,7 @@ func TestMovieHandlers_GetMovie(t *testing.T) {
 				WithConfig(tt.fields.conf),
 				WithLogger(tt.fields.logger),
 				WithStorage(tt.fields.storage),
+				WithTmdbClient(tt.fields.tmdbClient),
 			)
 
 			w := httptest.NewRecorder()
@@ -203,7 +258,7 @@ func TestMovieHandlers_LikeMovie(t *testing.T) {
 	type fields struct {
 		storage storage.Storage
 		conf    *config.Config
-		logger  *log.Logger
+		logger  *slog.Logger
 	}
 	tests := []struct {
 		name       string
@@ -338,7 +393,7 @@ func TestMovieHandlers_ListMovies(t *testing.T) {
 	type fields struct {
 		storage storage.Storage
 		conf    *config.Config
-		logger  *log.Logger
+		logger  *slog.Logger
 	}
 	tests := []struct {
 		name       string
@@ -385,6 +440,26 @@ func TestMovieHandlers_ListMovies(t *testing.T) {
 			query:      "?offset=10&title=mad&order_by=popularity",
 			wantStatus: http.StatusOK,
 		},
+		{
+			name: "positive_list_movies_cast_and_crew_filters",
+			fields: fields{
+				storage: &mock.Storage{},
+				conf:    &config.Config{},
+				logger:  logger,
+			},
+			query:      "?cast=2&crew=3",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "negative_list_movies_invalid_cast_filter",
+			fields: fields{
+				storage: &mock.Storage{},
+				conf:    &config.Config{},
+				logger:  logger,
+			},
+			query:      "?cast=invalidCast",
+			wantStatus: http.StatusBadRequest,
+		},
 		{
 			name: "negative_list_movies_storage_error",
 			fields: fields{
@@ -437,6 +512,326 @@ func TestMovieHandlers_ListMovies(t *testing.T) {
 	}
 }
 
+func TestMovieHandlers_GetTrendingMovies(t *testing.T) {
+	type fields struct {
+		storage    storage.Storage
+		conf       *config.Config
+		tmdbClient tmdb.Client
+		logger     *slog.Logger
+	}
+	tests := []struct {
+		name        string
+		fields      fields
+		query       string
+		wantStatus  int
+		wantWarning bool
+	}{
+		{
+			name: "positive_get_trending_movies",
+			fields: fields{
+				storage:    &mock.Storage{},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "positive_get_trending_movies_stored_when_tmdb_unavailable",
+			fields: fields{
+				storage: &mock.Storage{},
+				conf:    &config.Config{},
+				tmdbClient: &mock.Tmdb{
+					GetTrendingMoviesErr:    true,
+					GetTrendingMoviesStatus: http.StatusServiceUnavailable,
+				},
+				logger: logger,
+			},
+			wantStatus:  http.StatusOK,
+			wantWarning: true,
+		},
+		{
+			name: "negative_get_trending_movies_tmdb_and_storage_error",
+			fields: fields{
+				storage: &mock.Storage{
+					ListMoviesErr: true,
+				},
+				conf: &config.Config{},
+				tmdbClient: &mock.Tmdb{
+					GetTrendingMoviesErr:    true,
+					GetTrendingMoviesStatus: http.StatusServiceUnavailable,
+				},
+				logger: logger,
+			},
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name: "positive_get_trending_movies_week_window",
+			fields: fields{
+				storage:    &mock.Storage{},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			query:      "?window=week&page=2",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "negative_get_trending_movies_invalid_window",
+			fields: fields{
+				storage:    &mock.Storage{},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			query:      "?window=month",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "negative_get_trending_movies_invalid_page",
+			fields: fields{
+				storage:    &mock.Storage{},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			query:      "?page=0",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			a := NewApi(
+				WithConfig(tt.fields.conf),
+				WithLogger(tt.fields.logger),
+				WithStorage(tt.fields.storage),
+				WithTmdbClient(tt.fields.tmdbClient),
+			)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/trending"+tt.query, nil)
+
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+			if gotWarning := len(w.Header().Get("Warning")) > 0; gotWarning != tt.wantWarning {
+				t.Errorf("Expected warning header: %v, got: %v", tt.wantWarning, gotWarning)
+			}
+		})
+	}
+}
+
+func Test_tmdbMoviesFromPreviews(t *testing.T) {
+	released := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	previews := []models.MoviePreview{
+		{Id: 1, Title: "title", PosterPath: "/poster.jpg", ReleaseDate: released, VoteAverage: 7.5, Rating: 3},
+	}
+	want := []models.TmdbMovie{
+		{Id: 1, Title: "title", PosterPath: "/poster.jpg", ReleaseDate: models.Time{Time: released}, VoteAverage: 7.5},
+	}
+
+	if got := tmdbMoviesFromPreviews(previews); !reflect.DeepEqual(got, want) {
+		t.Errorf("tmdbMoviesFromPreviews() = %v, want %v", got, want)
+	}
+}
+
+func Test_rankedPreviews(t *testing.T) {
+	released := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	ranked := []models.TmdbMovie{
+		{Id: 3},
+		{Id: 1, Title: "catalogue", ReleaseDate: models.Time{Time: released}, VoteAverage: 8},
+		{Id: 2},
+	}
+	stored := []models.MoviePreview{
+		{Id: 2, Title: "second"},
+		{Id: 1, Title: "stored"},
+	}
+	want := []models.MoviePreview{
+		{Id: 1, Title: "stored"},
+		{Id: 2, Title: "second"},
+	}
+
+	if got := rankedPreviews(ranked, stored); !reflect.DeepEqual(got, want) {
+		t.Errorf("rankedPreviews() = %v, want %v", got, want)
+	}
+
+	want = []models.MoviePreview{
+		{Id: 1, Title: "catalogue", ReleaseDate: released, VoteAverage: 8},
+	}
+	if got := rankedPreviews(ranked, nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("rankedPreviews() without stored movies = %v, want %v", got, want)
+	}
+}
+
+func TestMovieHandlers_GetTopRatedMovies(t *testing.T) {
+	type fields struct {
+		storage    storage.Storage
+		conf       *config.Config
+		tmdbClient tmdb.Client
+		logger     *slog.Logger
+	}
+	tests := []struct {
+		name       string
+		fields     fields
+		query      string
+		wantStatus int
+	}{
+		{
+			name: "positive_get_top_rated_movies",
+			fields: fields{
+				storage:    &mock.Storage{},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "positive_get_top_rated_movies_stored_when_tmdb_unavailable",
+			fields: fields{
+				storage: &mock.Storage{},
+				conf:    &config.Config{},
+				tmdbClient: &mock.Tmdb{
+					GetTopRatedMoviesErr:    true,
+					GetTopRatedMoviesStatus: http.StatusInternalServerError,
+				},
+				logger: logger,
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "negative_get_top_rated_movies_storage_error",
+			fields: fields{
+				storage: &mock.Storage{
+					ListMoviesFromIDsErr: true,
+				},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "positive_get_top_rated_movies_region",
+			fields: fields{
+				storage:    &mock.Storage{},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			query:      "?page=3&region=pl",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "negative_get_top_rated_movies_invalid_region",
+			fields: fields{
+				storage:    &mock.Storage{},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			query:      "?region=POL",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "negative_get_top_rated_movies_region_not_letters",
+			fields: fields{
+				storage:    &mock.Storage{},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			query:      "?region=P%26",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "positive_get_top_rated_movies_window_ignored",
+			fields: fields{
+				storage:    &mock.Storage{},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			query:      "?window=month",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "negative_get_top_rated_movies_page_out_of_range",
+			fields: fields{
+				storage:    &mock.Storage{},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			query:      "?page=501",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "positive_get_community_ranking",
+			fields: fields{
+				storage:    &mock.Storage{},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			query:      "?source=community&period=last_30_days&genre=18&page=2",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "negative_get_community_ranking_invalid_period",
+			fields: fields{
+				storage:    &mock.Storage{},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			query:      "?source=community&period=decade",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "negative_get_community_ranking_storage_error",
+			fields: fields{
+				storage: &mock.Storage{
+					ListCommunityRankingErr: true,
+				},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			query:      "?source=community",
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "negative_get_ranking_invalid_source",
+			fields: fields{
+				storage:    &mock.Storage{},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			query:      "?source=imdb",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			a := NewApi(
+				WithConfig(tt.fields.conf),
+				WithLogger(tt.fields.logger),
+				WithStorage(tt.fields.storage),
+				WithTmdbClient(tt.fields.tmdbClient),
+			)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/ranking"+tt.query, nil)
+
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
 func setAccountHeaders(r *http.Request, account *models.AccountInfo) {
 	if account == nil {
 		return
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/BarTar213/movies-service/logging"
+	"github.com/BarTar213/movies-service/models"
+	"github.com/BarTar213/movies-service/storage"
+	"github.com/BarTar213/movies-service/tmdb"
+	"github.com/BarTar213/movies-service/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	personIdKey = "personId"
+)
+
+type PersonHandlers struct {
+	storage storage.Storage
+	tmdb    tmdb.Client
+	tasks   *Tasks
+	logger  *slog.Logger
+}
+
+func NewPersonHandlers(storage storage.Storage, tmdb tmdb.Client, tasks *Tasks, logger *slog.Logger) *PersonHandlers {
+	return &PersonHandlers{
+		storage: storage,
+		tmdb:    tmdb,
+		tasks:   tasks,
+		logger:  logger,
+	}
+}
+
+func (h *PersonHandlers) GetPerson(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param(personIdKey))
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, models.CodeInvalidParam, invalidPersonIdParamErr)
+		return
+	}
+
+	person := &models.Person{Id: id}
+	err = h.storage.GetPerson(c.Request.Context(), person)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		handleStorageError(c, h.logger, err, personResource)
+		return
+	}
+
+	if errors.Is(err, storage.ErrNotFound) {
+		status, err := h.tmdb.GetPerson(c.Request.Context(), id, person)
+		if err != nil || status != http.StatusOK {
+			handleTMDBError(c, h.logger, status, err, personResource)
+			return
+		}
+		h.tasks.Go(c.Request.Context(), "add person", func(ctx context.Context) {
+			h.AddPerson(ctx, person)
+		})
+	}
+
+	c.JSON(http.StatusOK, person)
+}
+
+func (h *PersonHandlers) AddPerson(ctx context.Context, person *models.Person) {
+	err := h.storage.AddPerson(ctx, person)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "add person", slog.Int("person_id", person.Id), logging.Err(err))
+	}
+}
+
+func (h *PersonHandlers) ListPersonMovies(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param(personIdKey))
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, models.CodeInvalidParam, invalidPersonIdParamErr)
+		return
+	}
+
+	cast, err := h.storage.ListPersonCast(c.Request.Context(), id)
+	if err != nil {
+		handleStorageError(c, h.logger, err, personResource)
+		return
+	}
+
+	crew, err := h.storage.ListPersonCrew(c.Request.Context(), id)
+	if err != nil {
+		handleStorageError(c, h.logger, err, personResource)
+		return
+	}
+
+	c.JSON(http.StatusOK, newFilmography(cast, crew))
+}
+
+func newFilmography(cast []models.CastCredit, crew []models.CrewCredit) *models.Filmography {
+	filmography := &models.Filmography{
+		Cast: cast,
+		Crew: make(map[string]map[string][]models.CrewCredit),
+	}
+
+	for _, credit := range crew {
+		jobs, ok := filmography.Crew[credit.Department]
+		if !ok {
+			jobs = make(map[string][]models.CrewCredit)
+			filmography.Crew[credit.Department] = jobs
+		}
+		jobs[credit.Job] = append(jobs[credit.Job], credit)
+	}
+
+	return filmography
+}
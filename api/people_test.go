@@ -0,0 +1,208 @@
+package api
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/BarTar213/movies-service/config"
+	"github.com/BarTar213/movies-service/mock"
+	"github.com/BarTar213/movies-service/models"
+	"github.com/BarTar213/movies-service/storage"
+	"github.com/BarTar213/movies-service/tmdb"
+	"github.com/gin-gonic/gin"
+)
+
+func TestPersonHandlers_GetPerson(t *testing.T) {
+	type fields struct {
+		storage    storage.Storage
+		conf       *config.Config
+		tmdbClient tmdb.Client
+		logger     *slog.Logger
+	}
+	tests := []struct {
+		name       string
+		fields     fields
+		personId   string
+		wantStatus int
+	}{
+		{
+			name: "positive_get_person",
+			fields: fields{
+				storage:    &mock.Storage{},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			personId:   validId,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "positive_get_person_from_tmdb",
+			fields: fields{
+				storage: &mock.Storage{
+					GetPersonNotFoundErr: true,
+				},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			personId:   validId,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "negative_get_person_invalid_person_id",
+			fields: fields{
+				storage:    &mock.Storage{},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			personId:   invalidId,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "negative_get_person_storage_error",
+			fields: fields{
+				storage: &mock.Storage{
+					GetPersonErr: true,
+				},
+				conf:       &config.Config{},
+				tmdbClient: &mock.Tmdb{},
+				logger:     logger,
+			},
+			personId:   validId,
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "negative_get_person_tmdb_error",
+			fields: fields{
+				storage: &mock.Storage{
+					GetPersonNotFoundErr: true,
+				},
+				conf: &config.Config{},
+				tmdbClient: &mock.Tmdb{
+					GetPersonErr:    true,
+					GetPersonStatus: http.StatusNotFound,
+				},
+				logger: logger,
+			},
+			personId:   validId,
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			a := NewApi(
+				WithConfig(tt.fields.conf),
+				WithLogger(tt.fields.logger),
+				WithStorage(tt.fields.storage),
+				WithTmdbClient(tt.fields.tmdbClient),
+			)
+
+			w := httptest.NewRecorder()
+			reqUrl := fmt.Sprintf("/people/%s", tt.personId)
+			req, _ := http.NewRequest(http.MethodGet, reqUrl, nil)
+
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestPersonHandlers_ListPersonMovies(t *testing.T) {
+	type fields struct {
+		storage storage.Storage
+		conf    *config.Config
+		logger  *slog.Logger
+	}
+	tests := []struct {
+		name       string
+		fields     fields
+		personId   string
+		wantStatus int
+	}{
+		{
+			name: "positive_list_person_movies",
+			fields: fields{
+				storage: &mock.Storage{},
+				conf:    &config.Config{},
+				logger:  logger,
+			},
+			personId:   validId,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "negative_list_person_movies_invalid_person_id",
+			fields: fields{
+				storage: &mock.Storage{},
+				conf:    &config.Config{},
+				logger:  logger,
+			},
+			personId:   invalidId,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "negative_list_person_movies_cast_storage_error",
+			fields: fields{
+				storage: &mock.Storage{
+					ListPersonCastErr: true,
+				},
+				conf:   &config.Config{},
+				logger: logger,
+			},
+			personId:   validId,
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "negative_list_person_movies_crew_storage_error",
+			fields: fields{
+				storage: &mock.Storage{
+					ListPersonCrewErr: true,
+				},
+				conf:   &config.Config{},
+				logger: logger,
+			},
+			personId:   validId,
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			a := NewApi(
+				WithConfig(tt.fields.conf),
+				WithLogger(tt.fields.logger),
+				WithStorage(tt.fields.storage),
+			)
+
+			w := httptest.NewRecorder()
+			reqUrl := fmt.Sprintf("/people/%s/movies", tt.personId)
+			req, _ := http.NewRequest(http.MethodGet, reqUrl, nil)
+
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func Test_newFilmography(t *testing.T) {
+	crew := []models.CrewCredit{
+		{MovieId: 1, Department: "Directing", Job: "Director"},
+		{MovieId: 2, Department: "Writing", Job: "Screenplay"},
+		{MovieId: 3, Department: "Directing", Job: "Director"},
+	}
+
+	got := newFilmography([]models.CastCredit{}, crew)
+	want := map[string]map[string][]models.CrewCredit{
+		"Directing": {"Director": {crew[0], crew[2]}},
+		"Writing":   {"Screenplay": {crew[1]}},
+	}
+	if !reflect.DeepEqual(got.Crew, want) {
+		t.Errorf("newFilmography() crew = %v, want %v", got.Crew, want)
+	}
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/BarTar213/movies-service/logging"
+	"github.com/BarTar213/movies-service/models"
+	"github.com/BarTar213/movies-service/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	sourceParam     = "source"
+	tmdbSource      = "tmdb"
+	communitySource = "community"
+
+	rankingPageSize = 20
+
+	defaultRankingMinVotes        = 5
+	defaultRankingRefreshInterval = 15 * time.Minute
+)
+
+// GetCommunityRanking serves ranking materialised from ratings and likes of our users
+func (h *MovieHandlers) GetCommunityRanking(c *gin.Context) {
+	params := &models.CommunityRankingParams{}
+	err := c.ShouldBindQuery(params)
+	if err != nil || !validRankingPeriod(params.Period) || params.Page < 1 || params.GenreId < 0 {
+		utils.RespondBindingError(c, models.CodeInvalidQuery, invalidRankingQueryParams, err)
+		return
+	}
+
+	pagination := &models.PaginationParams{
+		Offset: (params.Page - 1) * rankingPageSize,
+		Limit:  rankingPageSize,
+	}
+	movies, count, err := h.storage.ListCommunityRanking(c.Request.Context(), params, pagination)
+	if err != nil {
+		handleStorageError(c, h.logger, err, rankingResource)
+		return
+	}
+
+	h.translateRankedMovies(c.Request.Context(), movies, getLanguage(c))
+	meta := &models.PageMeta{
+		Page:         params.Page,
+		TotalPages:   (count + rankingPageSize - 1) / rankingPageSize,
+		TotalResults: count,
+	}
+	c.JSON(http.StatusOK, models.Response{Data: movies, Meta: meta})
+}
+
+func (h *MovieHandlers) translateRankedMovies(ctx context.Context, movies []models.RankedMovie, language string) {
+	previews := make([]models.MoviePreview, len(movies))
+	for i := range movies {
+		previews[i] = movies[i].MoviePreview
+	}
+
+	h.translatePreviews(ctx, previews, language)
+	for i := range movies {
+		movies[i].Title = previews[i].Title
+	}
+}
+
+// RefreshRankings materialises community rankings right away and then periodically until ctx is done
+func (a *Api) RefreshRankings(ctx context.Context) {
+	minVotes := a.Config.Ranking.MinVotes
+	if minVotes <= 0 {
+		minVotes = defaultRankingMinVotes
+	}
+	interval := a.Config.Ranking.RefreshInterval
+	if interval <= 0 {
+		interval = defaultRankingRefreshInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		err := a.Storage.RefreshCommunityRankings(ctx, minVotes)
+		if err != nil {
+			a.Logger.ErrorContext(ctx, "refresh community rankings", logging.Err(err))
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func validRankingPeriod(period string) bool {
+	for _, p := range models.RankingPeriods {
+		if p == period {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Tasks runs work detached from requests, like storing data fetched from TMDB or sending notifications,
+// so it can be drained on shutdown instead of being abandoned
+type Tasks struct {
+	timeout time.Duration
+	logger  *slog.Logger
+
+	mu      sync.Mutex
+	closed  bool
+	wg      sync.WaitGroup
+	running int64
+
+	//cancelled when draining didn't finish before its deadline
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewTasks(timeout time.Duration, logger *slog.Logger) *Tasks {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Tasks{
+		timeout: timeout,
+		logger:  logger,
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Go runs fn in background with context keeping values of ctx, e.g. trace and log attributes of request,
+// but not its deadline, tasks submitted after Drain was called are dropped
+func (t *Tasks) Go(ctx context.Context, name string, fn func(ctx context.Context)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		t.logger.WarnContext(ctx, "background task dropped on shutdown", slog.String("task", name))
+		return
+	}
+
+	t.wg.Add(1)
+	atomic.AddInt64(&t.running, 1)
+	go func() {
+		defer t.wg.Done()
+		defer atomic.AddInt64(&t.running, -1)
+
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), t.timeout)
+		defer cancel()
+		stop := context.AfterFunc(t.ctx, cancel)
+		defer stop()
+
+		fn(ctx)
+	}()
+}
+
+// Drain stops accepting tasks and waits for running ones, when ctx is done first
+// contexts of running tasks are cancelled, they are waited for to return and ctx error is returned
+func (t *Tasks) Drain(ctx context.Context) error {
+	t.mu.Lock()
+	t.closed = true
+	t.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		t.logger.Warn("background tasks cancelled on shutdown", slog.Int64("running", atomic.LoadInt64(&t.running)))
+		t.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type ctxKey struct{}
+
+func TestTasks_Drain(t *testing.T) {
+	tests := []struct {
+		name        string
+		task        func(ctx context.Context) error
+		deadline    time.Duration
+		wantErr     error
+		wantTaskErr error
+	}{
+		{
+			name: "positive_waits_for_running_task",
+			task: func(ctx context.Context) error {
+				time.Sleep(20 * time.Millisecond)
+				return ctx.Err()
+			},
+			deadline: time.Second,
+		},
+		{
+			name: "positive_task_keeps_request_values",
+			task: func(ctx context.Context) error {
+				if ctx.Value(ctxKey{}) != "value" {
+					return errors.New("missing request value")
+				}
+				return nil
+			},
+			deadline: time.Second,
+		},
+		{
+			name: "negative_deadline_cancels_running_task",
+			task: func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+			deadline:    20 * time.Millisecond,
+			wantErr:     context.DeadlineExceeded,
+			wantTaskErr: context.Canceled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tasks := NewTasks(time.Minute, logger)
+
+			//request context is cancelled right after submitting like when handler returns
+			reqCtx, cancelReq := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "value"))
+			done := make(chan error, 1)
+			tasks.Go(reqCtx, "test", func(ctx context.Context) {
+				done <- tt.task(ctx)
+			})
+			cancelReq()
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.deadline)
+			defer cancel()
+			if err := tasks.Drain(ctx); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Drain() error = %v, want %v", err, tt.wantErr)
+			}
+
+			//task has returned by the time Drain does, even when it was cancelled
+			select {
+			case err := <-done:
+				if !errors.Is(err, tt.wantTaskErr) {
+					t.Errorf("task error = %v, want %v", err, tt.wantTaskErr)
+				}
+			default:
+				t.Errorf("Drain() returned before task completed")
+			}
+		})
+	}
+}
+
+func TestTasks_Go_afterDrain(t *testing.T) {
+	tasks := NewTasks(time.Minute, logger)
+	if err := tasks.Drain(context.Background()); err != nil {
+		t.Fatalf("Drain() error = %v", err)
+	}
+
+	var called int32
+	tasks.Go(context.Background(), "test", func(ctx context.Context) {
+		atomic.StoreInt32(&called, 1)
+	})
+	if err := tasks.Drain(context.Background()); err != nil {
+		t.Errorf("Drain() error = %v", err)
+	}
+	if atomic.LoadInt32(&called) != 0 {
+		t.Errorf("Go() ran task submitted after Drain")
+	}
+}
@@ -0,0 +1,209 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/BarTar213/movies-service/logging"
+	"github.com/BarTar213/movies-service/models"
+	"github.com/BarTar213/movies-service/storage"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	languageParam        = "language"
+	acceptLanguageHeader = "Accept-Language"
+
+	//language of metadata stored in movies table
+	defaultLanguage = "en"
+
+	//limits TMDB calls made in background for a single list response
+	maxTranslationFetches = 20
+)
+
+// key of translation fetched in background
+type translationKey struct {
+	movieId  int
+	language string
+}
+
+// returns ISO 639-1 code from language query param or Accept-Language header,
+// empty string means that stored metadata should be returned untranslated
+func getLanguage(c *gin.Context) string {
+	language := c.Query(languageParam)
+	if len(language) == 0 {
+		language = c.GetHeader(acceptLanguageHeader)
+		if i := strings.IndexAny(language, ",;"); i >= 0 {
+			language = language[:i]
+		}
+	}
+	language = strings.TrimSpace(language)
+	if i := strings.IndexAny(language, "-_"); i >= 0 {
+		language = language[:i]
+	}
+	language = strings.ToLower(language)
+
+	if len(language) != 2 || language == defaultLanguage {
+		return ""
+	}
+	return language
+}
+
+func (h *MovieHandlers) translateMovie(ctx context.Context, movie *models.Movie, language string) {
+	if len(language) == 0 {
+		return
+	}
+
+	translation := &models.Translation{MovieId: movie.Id, Language: language}
+	err := h.storage.GetTranslation(ctx, translation)
+	if errors.Is(err, storage.ErrNotFound) {
+		var translations []models.Translation
+		translation, translations, err = h.fetchTranslation(ctx, movie.Id, language)
+		if err == nil {
+			h.tasks.Go(ctx, "add translations", func(ctx context.Context) {
+				h.AddTranslations(ctx, translations)
+			})
+		}
+	}
+	if err != nil {
+		h.logger.WarnContext(ctx, "translate movie", slog.Int("movie_id", movie.Id), slog.String("language", language), logging.Err(err))
+		return
+	}
+
+	translate(&movie.Title, translation.Title)
+	translate(&movie.Overview, translation.Overview)
+	translate(&movie.Tagline, translation.Tagline)
+}
+
+func (h *MovieHandlers) translatePreviews(ctx context.Context, movies []models.MoviePreview, language string) {
+	if len(language) == 0 || len(movies) == 0 {
+		return
+	}
+
+	ids := make([]int, 0, len(movies))
+	for i := range movies {
+		ids = append(ids, movies[i].Id)
+	}
+
+	translations := h.listTranslations(ctx, ids, language)
+	for i := range movies {
+		if translation, ok := translations[movies[i].Id]; ok {
+			translate(&movies[i].Title, translation.Title)
+		}
+	}
+}
+
+func (h *MovieHandlers) translateTmdbMovies(ctx context.Context, movies []models.TmdbMovie, language string) {
+	if len(language) == 0 || len(movies) == 0 {
+		return
+	}
+
+	ids := make([]int, 0, len(movies))
+	for i := range movies {
+		ids = append(ids, movies[i].Id)
+	}
+
+	translations := h.listTranslations(ctx, ids, language)
+	for i := range movies {
+		if translation, ok := translations[movies[i].Id]; ok {
+			translate(&movies[i].Title, translation.Title)
+			translate(&movies[i].Overview, translation.Overview)
+			translate(&movies[i].Tagline, translation.Tagline)
+		}
+	}
+}
+
+// returns stored translations and fetches missing ones in background,
+// movies without stored translation fall back to the original metadata
+func (h *MovieHandlers) listTranslations(ctx context.Context, ids []int, language string) map[int]models.Translation {
+	translations := make(map[int]models.Translation, len(ids))
+
+	stored, err := h.storage.ListTranslations(ctx, ids, language)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "list translations", logging.Err(err))
+		return translations
+	}
+	for _, translation := range stored {
+		translations[translation.MovieId] = translation
+	}
+
+	//translations already fetched for another request are skipped
+	missing := make([]int, 0)
+	for _, id := range ids {
+		if len(missing) == maxTranslationFetches {
+			break
+		}
+		if _, ok := translations[id]; ok {
+			continue
+		}
+		if _, fetching := h.translating.LoadOrStore(translationKey{movieId: id, language: language}, struct{}{}); !fetching {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 {
+		h.tasks.Go(ctx, "fetch translations", func(ctx context.Context) {
+			h.fetchTranslations(ctx, missing, language)
+		})
+	}
+
+	return translations
+}
+
+// runs as background task already, so translations are stored right away instead of in another task
+// which would be dropped once tasks are drained
+func (h *MovieHandlers) fetchTranslations(ctx context.Context, ids []int, language string) {
+	for _, id := range ids {
+		_, translations, err := h.fetchTranslation(ctx, id, language)
+		if err != nil {
+			h.logger.WarnContext(ctx, "fetch translation", slog.Int("movie_id", id), slog.String("language", language), logging.Err(err))
+		} else {
+			h.AddTranslations(ctx, translations)
+		}
+		h.translating.Delete(translationKey{movieId: id, language: language})
+	}
+}
+
+// fetches translation of movie in language together with all translations of movie to be stored,
+// empty translation is added when requested language is missing so TMDB is not asked again
+func (h *MovieHandlers) fetchTranslation(ctx context.Context, movieId int, language string) (*models.Translation, []models.Translation, error) {
+	translations, status, err := h.tmdb.GetTranslations(ctx, movieId)
+	if err != nil {
+		return nil, nil, err
+	}
+	if status != http.StatusOK {
+		return nil, nil, fmt.Errorf("unexpected TMDB status: %d", status)
+	}
+
+	var found *models.Translation
+	for i := range translations {
+		if translations[i].Language == language {
+			found = &translations[i]
+			break
+		}
+	}
+	if found == nil {
+		translations = append(translations, models.Translation{MovieId: movieId, Language: language})
+		found = &translations[len(translations)-1]
+	}
+
+	result := *found
+
+	return &result, translations, nil
+}
+
+func (h *MovieHandlers) AddTranslations(ctx context.Context, translations []models.Translation) {
+	err := h.storage.AddTranslations(ctx, translations)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "add translations", logging.Err(err))
+	}
+}
+
+func translate(field *string, value string) {
+	if len(value) > 0 {
+		*field = value
+	}
+}
@@ -0,0 +1,233 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BarTar213/movies-service/mock"
+	"github.com/BarTar213/movies-service/models"
+	"github.com/BarTar213/movies-service/storage"
+	"github.com/BarTar213/movies-service/tmdb"
+	"github.com/gin-gonic/gin"
+)
+
+func Test_getLanguage(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		acceptLanguage string
+		want           string
+	}{
+		{
+			name: "no_language",
+			want: "",
+		},
+		{
+			name:  "language_param",
+			query: "?language=pl",
+			want:  "pl",
+		},
+		{
+			name:           "language_param_before_header",
+			query:          "?language=de-DE",
+			acceptLanguage: "pl-PL,pl;q=0.9",
+			want:           "de",
+		},
+		{
+			name:           "accept_language_header",
+			acceptLanguage: "pl-PL,pl;q=0.9,en-US;q=0.8",
+			want:           "pl",
+		},
+		{
+			name:           "default_language",
+			acceptLanguage: "en-US,en;q=0.9",
+			want:           "",
+		},
+		{
+			name:           "wildcard_language",
+			acceptLanguage: "*",
+			want:           "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request, _ = http.NewRequest(http.MethodGet, "/movies"+tt.query, nil)
+			if len(tt.acceptLanguage) > 0 {
+				c.Request.Header.Set(acceptLanguageHeader, tt.acceptLanguage)
+			}
+
+			if got := getLanguage(c); got != tt.want {
+				t.Errorf("getLanguage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMovieHandlers_translateMovie(t *testing.T) {
+	type fields struct {
+		storage storage.Storage
+		tmdb    tmdb.Client
+		logger  *slog.Logger
+	}
+	tests := []struct {
+		name      string
+		fields    fields
+		language  string
+		wantTitle string
+	}{
+		{
+			name: "positive_translate_movie_stored_translation",
+			fields: fields{
+				storage: &mock.Storage{},
+				tmdb:    &mock.Tmdb{},
+				logger:  logger,
+			},
+			language:  "pl",
+			wantTitle: "title",
+		},
+		{
+			name: "positive_translate_movie_fetched_translation",
+			fields: fields{
+				storage: &mock.Storage{
+					GetTranslationNotFoundErr: true,
+				},
+				tmdb:   &mock.Tmdb{},
+				logger: logger,
+			},
+			language:  "pl",
+			wantTitle: "title",
+		},
+		{
+			name: "negative_translate_movie_tmdb_error_falls_back",
+			fields: fields{
+				storage: &mock.Storage{
+					GetTranslationNotFoundErr: true,
+				},
+				tmdb: &mock.Tmdb{
+					GetTranslationsErr:    true,
+					GetTranslationsStatus: http.StatusInternalServerError,
+				},
+				logger: logger,
+			},
+			language:  "pl",
+			wantTitle: "title",
+		},
+		{
+			name: "negative_translate_movie_storage_error_falls_back",
+			fields: fields{
+				storage: &mock.Storage{
+					GetTranslationErr: true,
+				},
+				tmdb:   &mock.Tmdb{},
+				logger: logger,
+			},
+			language:  "pl",
+			wantTitle: "title",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &MovieHandlers{
+				storage: tt.fields.storage,
+				tmdb:    tt.fields.tmdb,
+				tasks:   NewTasks(time.Second, tt.fields.logger),
+				logger:  tt.fields.logger,
+			}
+			movie := &models.Movie{Id: 1, Title: "title"}
+			h.translateMovie(context.Background(), movie, tt.language)
+
+			if movie.Title != tt.wantTitle {
+				t.Errorf("translateMovie() title = %v, want %v", movie.Title, tt.wantTitle)
+			}
+		})
+	}
+}
+
+func TestMovieHandlers_listTranslations(t *testing.T) {
+	tests := []struct {
+		name      string
+		ids       []int
+		fetching  []int
+		wantCalls int
+	}{
+		{
+			name:      "positive_fetch_missing_translations",
+			ids:       []int{1, 2},
+			wantCalls: 2,
+		},
+		{
+			name:      "positive_skip_translations_fetched_for_other_request",
+			ids:       []int{1, 2},
+			fetching:  []int{2},
+			wantCalls: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmdbClient := &mock.Tmdb{}
+			store := &translationsStorage{}
+			h := &MovieHandlers{
+				storage: store,
+				tmdb:    tmdbClient,
+				tasks:   NewTasks(time.Second, logger),
+				logger:  logger,
+			}
+			for _, id := range tt.fetching {
+				h.translating.Store(translationKey{movieId: id, language: "pl"}, struct{}{})
+			}
+
+			//fetched translations are stored even though tasks are drained right away
+			h.listTranslations(context.Background(), tt.ids, "pl")
+			if err := h.tasks.Drain(context.Background()); err != nil {
+				t.Fatalf("Drain() error = %v", err)
+			}
+			if got := tmdbClient.GetTranslationsCalls(); got != tt.wantCalls {
+				t.Errorf("GetTranslations() calls = %d, want %d", got, tt.wantCalls)
+			}
+			if got := store.addCalls(); got != tt.wantCalls {
+				t.Errorf("AddTranslations() calls = %d, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+// translationsStorage counts stored batches of translations
+type translationsStorage struct {
+	mock.Storage
+	mu    sync.Mutex
+	calls int
+}
+
+func (s *translationsStorage) AddTranslations(ctx context.Context, translations []models.Translation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	return nil
+}
+
+func (s *translationsStorage) addCalls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+func Test_translate(t *testing.T) {
+	field := "original"
+
+	translate(&field, "")
+	if field != "original" {
+		t.Errorf("translate() with empty value = %v, want original", field)
+	}
+
+	translate(&field, "translated")
+	if field != "translated" {
+		t.Errorf("translate() = %v, want translated", field)
+	}
+}
@@ -1,70 +1,117 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
+	"time"
 
+	"github.com/BarTar213/movies-service/logging"
 	"github.com/BarTar213/movies-service/models"
+	"github.com/BarTar213/movies-service/storage"
+	"github.com/BarTar213/movies-service/tmdb"
+	"github.com/BarTar213/movies-service/utils"
 	"github.com/gin-gonic/gin"
-	"github.com/go-pg/pg/v10"
 )
 
 const (
 	invalidRequestBodyErr        = "invalid request body"
 	invalidMovieIdParamErr       = "invalid param - movieId"
 	invalidCommentIdParamErr     = "invalid param - commentId"
+	invalidPersonIdParamErr      = "invalid param - personId"
 	invalidLikedParamErr         = "invalid param - liked"
 	invalidPaginationQueryParams = "invalid pagination query params"
+	invalidMovieFiltersErr       = "invalid movie filters"
+	invalidCreditsQueryParams    = "invalid credits query params"
+	invalidMediaQueryParams      = "invalid media query params"
+	invalidListQueryParams       = "invalid list query params"
+	invalidRankingQueryParams    = "invalid ranking query params"
+	invalidSourceParam           = "invalid param - source"
+	invalidDiscoverQueryParams   = "invalid discover query params"
+
+	adminRole = "admin"
+
+	//timeout of work done in background after response was sent
+	backgroundTimeout = 30 * time.Second
+	//how long movie without images or videos on TMDB isn't looked up again
+	emptyMediaTTL = 24 * time.Hour
+	//max number of movies remembered without images or videos
+	emptyMediaLimit = 10000
 
 	likedParam           = "liked"
 	movieIdQuery         = "movie_id"
 	movieResource        = "movie"
+	moviesResource       = "movies"
 	movieCommentResource = "movie comment"
 	commentResource      = "comment"
 	creditsResource      = "credits"
 	ratingResource       = "rating"
+	personResource       = "person"
+	imagesResource       = "images"
+	videosResource       = "videos"
+	rankingResource      = "ranking"
 )
 
-func handlePostgresError(c *gin.Context, l *log.Logger, err error, resource string) {
-	if err == pg.ErrNoRows {
-		c.JSON(http.StatusBadRequest, models.Response{Error: fmt.Sprintf("%s with given identification doesn't exist", resource)})
+// handleStorageError maps kinds of storage errors to http statuses, missing resources are reported with 404
+// no matter if they were looked up directly or referenced by created one
+func handleStorageError(c *gin.Context, l *slog.Logger, err error, resource string) {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		utils.RespondError(c, http.StatusNotFound, models.CodeNotFound, fmt.Sprintf("%s with given identification doesn't exist", resource))
+		return
+	case errors.Is(err, storage.ErrForeignKeyViolation):
+		utils.RespondError(c, http.StatusNotFound, models.CodeNotFound, fmt.Sprintf("%s with given information doesn't exist", resource))
+		return
+	case errors.Is(err, storage.ErrAlreadyExists):
+		utils.RespondError(c, http.StatusConflict, models.CodeAlreadyExists, fmt.Sprintf("%s with given information already exists", resource))
 		return
 	}
-	l.Println(err)
-
-	msg := ""
-	pgErr, ok := err.(pg.Error)
-	if ok {
-		switch pgErr.Field('C') {
-		case "23503":
-			msg = fmt.Sprintf("%s with given information doesn't exists", resource)
-		case "23505":
-			msg = fmt.Sprintf("%s with given information already exists", resource)
-		}
-		if len(msg) > 0 {
-			c.JSON(http.StatusBadRequest, models.Response{Error: msg})
-			return
-		}
+	//cancelled request isn't failure of storage, client is gone already
+	if !errors.Is(err, context.Canceled) {
+		l.ErrorContext(c.Request.Context(), "storage error", slog.String("resource", resource), logging.Err(err))
 	}
 
-	c.JSON(http.StatusInternalServerError, models.Response{Error: "storage error"})
+	switch {
+	case errors.Is(err, storage.ErrConflict):
+		utils.RespondError(c, http.StatusConflict, models.CodeConflict, fmt.Sprintf("%s was modified concurrently, try again", resource))
+	case errors.Is(err, storage.ErrUnavailable):
+		utils.RespondError(c, http.StatusServiceUnavailable, models.CodeStorageUnavailable, "storage unavailable")
+	default:
+		utils.RespondError(c, http.StatusInternalServerError, models.CodeStorageError, "storage error")
+	}
 }
 
-func handleTMDBError(c *gin.Context, l *log.Logger, status int, err error, resource string) {
+func handleTMDBError(c *gin.Context, l *slog.Logger, status int, err error, resource string) {
+	if tmdbUnavailable(status, err) && !errors.Is(err, context.Canceled) {
+		l.WarnContext(c.Request.Context(), "TMDB unavailable", slog.String("resource", resource), slog.Int("status", status), logging.Err(err))
+		utils.RespondError(c, http.StatusServiceUnavailable, models.CodeApiUnavailable, "api unavailable")
+		return
+	}
+
 	if err != nil {
-		l.Println(err)
-		c.JSON(http.StatusInternalServerError, models.Response{Error: "api error"})
+		l.ErrorContext(c.Request.Context(), "TMDB error", slog.String("resource", resource), logging.Err(err))
+		utils.RespondError(c, http.StatusInternalServerError, models.CodeApiError, "api error")
 		return
 	}
 
 	switch status {
 	case http.StatusNotFound:
-		c.JSON(http.StatusNotFound, models.Response{Error: fmt.Sprintf("%s with given information already exists", resource)})
+		utils.RespondError(c, http.StatusNotFound, models.CodeNotFound, fmt.Sprintf("%s with given information doesn't exist", resource))
 		return
 	}
 
-	c.JSON(http.StatusInternalServerError, models.Response{Error: "api error"})
+	utils.RespondError(c, http.StatusInternalServerError, models.CodeApiError, "api error")
+}
+
+// reports whether TMDB failed on its side so the stored data can be served instead
+func tmdbUnavailable(status int, err error) bool {
+	return errors.Is(err, tmdb.ErrCircuitOpen) ||
+		status == http.StatusTooManyRequests ||
+		status == http.StatusServiceUnavailable ||
+		status == http.StatusBadGateway ||
+		status == http.StatusGatewayTimeout
 }
 
 func intPointer(i int) *int {
@@ -1,24 +1,35 @@
 package api
 
 import (
+	"encoding/json"
 	"errors"
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/BarTar213/movies-service/config"
+	"github.com/BarTar213/movies-service/metrics"
+	"github.com/BarTar213/movies-service/middleware"
 	"github.com/BarTar213/movies-service/mock"
+	"github.com/BarTar213/movies-service/models"
+	"github.com/BarTar213/movies-service/storage"
+	"github.com/BarTar213/movies-service/tmdb"
 	"github.com/gin-gonic/gin"
-	"github.com/go-pg/pg/v10"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 const (
 	resourceExample = "resource"
 )
 
-func Test_handlePostgresError(t *testing.T) {
+func Test_handleStorageError(t *testing.T) {
 	type args struct {
-		logger   *log.Logger
+		logger   *slog.Logger
 		err      error
 		resource string
 	}
@@ -28,37 +39,55 @@ func Test_handlePostgresError(t *testing.T) {
 		wantStatus int
 	}{
 		{
-			name: "no_rows_error",
+			name: "not_found_error",
 			args: args{
 				logger:   logger,
-				err:      pg.ErrNoRows,
+				err:      &storage.Error{Kind: storage.ErrNotFound, Resource: resourceExample},
 				resource: resourceExample,
 			},
-			wantStatus: http.StatusBadRequest,
+			wantStatus: http.StatusNotFound,
 		},
 		{
-			name: "23503_error",
+			name: "foreign_key_violation_error",
 			args: args{
 				logger:   logger,
-				err:      &mock.PgError{FieldCode: "23503"},
+				err:      &storage.Error{Kind: storage.ErrForeignKeyViolation, Resource: resourceExample},
 				resource: resourceExample,
 			},
-			wantStatus: http.StatusBadRequest,
+			wantStatus: http.StatusNotFound,
 		},
 		{
-			name: "23505_error",
+			name: "already_exists_error",
 			args: args{
 				logger:   logger,
-				err:      &mock.PgError{FieldCode: "23505"},
+				err:      &storage.Error{Kind: storage.ErrAlreadyExists, Resource: resourceExample},
 				resource: resourceExample,
 			},
-			wantStatus: http.StatusBadRequest,
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name: "conflict_error",
+			args: args{
+				logger:   logger,
+				err:      &storage.Error{Kind: storage.ErrConflict, Resource: resourceExample},
+				resource: resourceExample,
+			},
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name: "unavailable_error",
+			args: args{
+				logger:   logger,
+				err:      fmt.Errorf("list movies: %w", &storage.Error{Kind: storage.ErrUnavailable, Resource: resourceExample}),
+				resource: resourceExample,
+			},
+			wantStatus: http.StatusServiceUnavailable,
 		},
 		{
-			name: "not_handled_postgres_error",
+			name: "not_handled_storage_error",
 			args: args{
 				logger:   logger,
-				err:      &mock.PgError{FieldCode: "23111"},
+				err:      errors.New("storage error"),
 				resource: resourceExample,
 			},
 			wantStatus: http.StatusInternalServerError,
@@ -68,8 +97,9 @@ func Test_handlePostgresError(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			w := httptest.NewRecorder()
 			context, _ := gin.CreateTestContext(w)
+			context.Request = httptest.NewRequest(http.MethodGet, "/", nil)
 
-			handlePostgresError(context, tt.args.logger, tt.args.err, tt.args.resource)
+			handleStorageError(context, tt.args.logger, tt.args.err, tt.args.resource)
 
 			checkResponseStatusCode(t, tt.wantStatus, w.Code)
 		})
@@ -78,7 +108,7 @@ func Test_handlePostgresError(t *testing.T) {
 
 func Test_handleTMDBError(t *testing.T) {
 	type args struct {
-		logger   *log.Logger
+		logger   *slog.Logger
 		status   int
 		err      error
 		resource string
@@ -108,6 +138,26 @@ func Test_handleTMDBError(t *testing.T) {
 			},
 			wantStatus: http.StatusNotFound,
 		},
+		{
+			name: "negative_handle_tmdb_circuit_open",
+			args: args{
+				logger:   logger,
+				status:   http.StatusServiceUnavailable,
+				err:      tmdb.ErrCircuitOpen,
+				resource: creditsResource,
+			},
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name: "negative_handle_tmdb_too_many_requests",
+			args: args{
+				logger:   logger,
+				status:   http.StatusTooManyRequests,
+				err:      nil,
+				resource: creditsResource,
+			},
+			wantStatus: http.StatusServiceUnavailable,
+		},
 		{
 			name: "negative_handle_tmdb_internal_server_error",
 			args: args{
@@ -123,9 +173,280 @@ func Test_handleTMDBError(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			w := httptest.NewRecorder()
 			context, _ := gin.CreateTestContext(w)
+			context.Request = httptest.NewRequest(http.MethodGet, "/", nil)
 
 			handleTMDBError(context, tt.args.logger, tt.args.status, tt.args.err, tt.args.resource)
 			checkResponseStatusCode(t, tt.wantStatus, w.Code)
 		})
 	}
 }
+
+func TestApi_ErrorResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		url        string
+		body       string
+		wantStatus int
+		want       models.ErrorDetails
+	}{
+		{
+			name:       "negative_error_response_invalid_param",
+			method:     http.MethodGet,
+			url:        "/movies/1/credits?max_order=invalid",
+			wantStatus: http.StatusBadRequest,
+			want: models.ErrorDetails{
+				Code:    models.CodeInvalidQuery,
+				Message: invalidCreditsQueryParams,
+			},
+		},
+		{
+			name:       "negative_error_response_missing_field",
+			method:     http.MethodPost,
+			url:        "/movies/1/rating",
+			body:       `{}`,
+			wantStatus: http.StatusBadRequest,
+			want: models.ErrorDetails{
+				Code:    models.CodeInvalidBody,
+				Message: invalidRequestBodyErr,
+				Fields:  []models.FieldError{{Field: "rating", Reason: "required"}},
+			},
+		},
+		{
+			name:       "negative_error_response_invalid_field_type",
+			method:     http.MethodPost,
+			url:        "/movies/1/rating",
+			body:       `{"rating": "high"}`,
+			wantStatus: http.StatusBadRequest,
+			want: models.ErrorDetails{
+				Code:    models.CodeInvalidBody,
+				Message: invalidRequestBodyErr,
+				Fields:  []models.FieldError{{Field: "rating", Reason: "type=int"}},
+			},
+		},
+		{
+			name:       "negative_error_response_unknown_route",
+			method:     http.MethodGet,
+			url:        "/unknown",
+			wantStatus: http.StatusNotFound,
+			want: models.ErrorDetails{
+				Code:    models.CodeRouteNotFound,
+				Message: "route not found",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			a := NewApi(
+				WithConfig(&config.Config{}),
+				WithLogger(logger),
+				WithStorage(&mock.Storage{}),
+				WithTmdbClient(&mock.Tmdb{}),
+			)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			req.Header.Set(middleware.RequestIdHeader, "request-1")
+			setAccountHeaders(req, &models.AccountInfo{ID: 1, Login: accountLogin, Role: accountRole})
+
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+
+			response := models.Response{}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("invalid response body %s: %s", w.Body.String(), err)
+			}
+			tt.want.RequestId = "request-1"
+			if !reflect.DeepEqual(response.ErrorDetails, &tt.want) {
+				t.Errorf("error details = %+v, want %+v", response.ErrorDetails, tt.want)
+			}
+			if response.Error != tt.want.Message {
+				t.Errorf("error = %s, want %s", response.Error, tt.want.Message)
+			}
+		})
+	}
+}
+
+func TestApi_BusinessMetrics(t *testing.T) {
+	tests := []struct {
+		name      string
+		storage   *mock.Storage
+		method    string
+		url       string
+		body      string
+		counter   func(m *metrics.Metrics) prometheus.Collector
+		wantCount float64
+	}{
+		{
+			name:    "positive_movie_like",
+			storage: &mock.Storage{},
+			method:  http.MethodPost,
+			url:     "/movies/1/like?liked=false",
+			counter: func(m *metrics.Metrics) prometheus.Collector {
+				return m.Likes.WithLabelValues(metrics.MovieResource, metrics.LikeAction)
+			},
+			wantCount: 1,
+		},
+		{
+			name:    "positive_comment_unlike",
+			storage: &mock.Storage{},
+			method:  http.MethodPost,
+			url:     "/comments/1/like?liked=true",
+			counter: func(m *metrics.Metrics) prometheus.Collector {
+				return m.Likes.WithLabelValues(metrics.CommentResource, metrics.UnlikeAction)
+			},
+			wantCount: 1,
+		},
+		{
+			name:    "positive_comment_created",
+			storage: &mock.Storage{},
+			method:  http.MethodPost,
+			url:     "/comments?movie_id=1",
+			body:    `{"content": "content"}`,
+			counter: func(m *metrics.Metrics) prometheus.Collector {
+				return m.Comments.WithLabelValues(metrics.CreatedAction)
+			},
+			wantCount: 1,
+		},
+		{
+			name:    "positive_rating_added",
+			storage: &mock.Storage{RatingChange: storage.RatingAdded},
+			method:  http.MethodPost,
+			url:     "/movies/1/rating",
+			body:    `{"rating": 5}`,
+			counter: func(m *metrics.Metrics) prometheus.Collector {
+				return m.Ratings.WithLabelValues(metrics.AddedAction)
+			},
+			wantCount: 1,
+		},
+		{
+			name:    "positive_rating_changed",
+			storage: &mock.Storage{RatingChange: storage.RatingChanged},
+			method:  http.MethodPost,
+			url:     "/movies/1/rating",
+			body:    `{"rating": 5}`,
+			counter: func(m *metrics.Metrics) prometheus.Collector {
+				return m.Ratings.WithLabelValues(metrics.ChangedAction)
+			},
+			wantCount: 1,
+		},
+		{
+			name:    "positive_rating_unchanged_not_counted",
+			storage: &mock.Storage{RatingChange: storage.RatingUnchanged},
+			method:  http.MethodPost,
+			url:     "/movies/1/rating",
+			body:    `{"rating": 5}`,
+			counter: func(m *metrics.Metrics) prometheus.Collector {
+				return m.Ratings.WithLabelValues(metrics.ChangedAction)
+			},
+			wantCount: 0,
+		},
+		{
+			name:    "negative_rating_added_storage_error",
+			storage: &mock.Storage{AddRatingErr: true, RatingChange: storage.RatingAdded},
+			method:  http.MethodPost,
+			url:     "/movies/1/rating",
+			body:    `{"rating": 5}`,
+			counter: func(m *metrics.Metrics) prometheus.Collector {
+				return m.Ratings.WithLabelValues(metrics.AddedAction)
+			},
+			wantCount: 0,
+		},
+		{
+			name:    "positive_rating_removed",
+			storage: &mock.Storage{},
+			method:  http.MethodDelete,
+			url:     "/movies/1/rating",
+			counter: func(m *metrics.Metrics) prometheus.Collector {
+				return m.Ratings.WithLabelValues(metrics.RemovedAction)
+			},
+			wantCount: 1,
+		},
+		{
+			name:    "negative_rating_removed_not_matched",
+			storage: &mock.Storage{DeleteRatingNotFound: true},
+			method:  http.MethodDelete,
+			url:     "/movies/1/rating",
+			counter: func(m *metrics.Metrics) prometheus.Collector {
+				return m.Ratings.WithLabelValues(metrics.RemovedAction)
+			},
+			wantCount: 0,
+		},
+		{
+			name:    "positive_comment_edited",
+			storage: &mock.Storage{},
+			method:  http.MethodPut,
+			url:     "/comments/1",
+			body:    `{"content": "content"}`,
+			counter: func(m *metrics.Metrics) prometheus.Collector {
+				return m.Comments.WithLabelValues(metrics.EditedAction)
+			},
+			wantCount: 1,
+		},
+		{
+			name:    "negative_comment_edited_not_matched",
+			storage: &mock.Storage{UpdateCommentNotFoundErr: true},
+			method:  http.MethodPut,
+			url:     "/comments/1",
+			body:    `{"content": "content"}`,
+			counter: func(m *metrics.Metrics) prometheus.Collector {
+				return m.Comments.WithLabelValues(metrics.EditedAction)
+			},
+			wantCount: 0,
+		},
+		{
+			name:    "positive_comment_deleted",
+			storage: &mock.Storage{},
+			method:  http.MethodDelete,
+			url:     "/comments/1",
+			counter: func(m *metrics.Metrics) prometheus.Collector {
+				return m.Comments.WithLabelValues(metrics.DeletedAction)
+			},
+			wantCount: 1,
+		},
+		{
+			name:    "negative_comment_deleted_not_matched",
+			storage: &mock.Storage{DeleteCommentNotFound: true},
+			method:  http.MethodDelete,
+			url:     "/comments/1",
+			counter: func(m *metrics.Metrics) prometheus.Collector {
+				return m.Comments.WithLabelValues(metrics.DeletedAction)
+			},
+			wantCount: 0,
+		},
+		{
+			name:    "negative_comment_deleted_storage_error",
+			storage: &mock.Storage{DeleteCommentErr: true},
+			method:  http.MethodDelete,
+			url:     "/comments/1",
+			counter: func(m *metrics.Metrics) prometheus.Collector {
+				return m.Comments.WithLabelValues(metrics.DeletedAction)
+			},
+			wantCount: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			m := metrics.New()
+			a := NewApi(
+				WithConfig(&config.Config{}),
+				WithLogger(logger),
+				WithStorage(tt.storage),
+				WithNotificator(&mock.Notificator{}),
+				WithMetrics(m),
+			)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			setAccountHeaders(req, &models.AccountInfo{ID: 1, Login: accountLogin, Role: accountRole})
+
+			a.Router.ServeHTTP(w, req)
+
+			if got := testutil.ToFloat64(tt.counter(m)); got != tt.wantCount {
+				t.Errorf("counter = %v, want %v", got, tt.wantCount)
+			}
+		})
+	}
+}
@@ -1,60 +1,151 @@
 package main
 
 import (
+	"context"
+	"io"
 	"log"
+	"log/slog"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/BarTar213/movies-service/api"
 	"github.com/BarTar213/movies-service/config"
+	"github.com/BarTar213/movies-service/logging"
 	"github.com/BarTar213/movies-service/metrics"
+	"github.com/BarTar213/movies-service/provider"
 	"github.com/BarTar213/movies-service/storage"
 	"github.com/BarTar213/movies-service/tmdb"
+	"github.com/BarTar213/movies-service/tracing"
 	notificator "github.com/BarTar213/notificator/client"
 	"github.com/gin-gonic/gin"
 )
 
+const defaultShutdownTimeout = 20 * time.Second
+
 func main() {
 	conf := config.NewConfig("movies-service.yml")
-	logger := log.New(os.Stdout, "", log.LstdFlags)
+	var output io.Writer = os.Stdout
+	if len(os.Args) > 1 {
+		//standard output is reserved for exported records
+		output = os.Stderr
+	}
+	logger, err := logging.New(conf.Logging, output)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	slog.SetDefault(logger)
 
-	logger.Printf("%+v\n", conf)
+	//secrets are redacted by config
+	logger.Info("loaded config", slog.Any("config", conf))
 
 	if conf.Api.Release {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
-	logger.Println("Connecting to postgresql")
-	postgres, err := storage.NewPostgres(&conf.Postgres)
+	metricsCli := metrics.New()
+
+	logger.Info("connecting to storage", slog.String("storage", conf.Storage))
+	store, err := storage.New(conf, metricsCli)
 	if err != nil {
-		logger.Fatalln(err)
+		fatal(logger, "connect to storage", err)
 	}
 
-	logger.Println("Connecting to TMDB client")
-	tmdbClient := tmdb.NewClient(5*time.Second, conf)
+	if len(os.Args) > 1 {
+		code := runCommand(os.Args[1], os.Args[2:], store, logger)
+		//os.Exit skips deferred calls, so storage is closed before it
+		if err := store.Close(); err != nil {
+			logger.Error("close storage", logging.Err(err))
+		}
+		os.Exit(code)
+	}
 
-	logger.Println("Connecting to notificator")
-	notificatorCli := notificator.New(conf.Notificator.Address, conf.Api.Timeout)
+	shutdownTracing, err := tracing.Init(conf.Tracing)
+	if err != nil {
+		fatal(logger, "init tracing", err)
+	}
 
-	metricsCli := metrics.New()
+	logger.Info("connecting to TMDB client")
+	var snapshots tmdb.SnapshotStore
+	if conf.Tmdb.CacheSnapshots {
+		snapshots = store
+	}
+	tmdbClient := tmdb.NewCachedClient(tmdb.NewClient(5*time.Second, conf, metricsCli), conf, snapshots, metricsCli, logger)
+
+	providers, err := provider.NewProviders(conf, tmdbClient)
+	if err != nil {
+		fatal(logger, "create providers", err)
+	}
+
+	logger.Info("connecting to notificator")
+	notificatorCli := tracing.NewNotificator(notificator.New(conf.Notificator.Address, conf.Api.Timeout))
 
 	a := api.NewApi(
 		api.WithConfig(conf),
 		api.WithLogger(logger),
-		api.WithStorage(postgres),
+		api.WithStorage(store),
 		api.WithTmdbClient(tmdbClient),
+		api.WithProviders(providers),
 		api.WithNotificator(notificatorCli),
 		api.WithMetrics(metricsCli),
 	)
 
-	go a.Run()
-	logger.Print("started app")
+	//background loops stop as soon as shutdown starts
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+
+	go func() {
+		if err := a.Run(); err != nil {
+			fatal(logger, "run server", err)
+		}
+	}()
+	loops := sync.WaitGroup{}
+	loops.Add(2)
+	go func() {
+		defer loops.Done()
+		a.RefreshRankings(ctx)
+	}()
+	go func() {
+		defer loops.Done()
+		a.RunHealthChecks(ctx)
+	}()
+	logger.Info("started app", slog.String("port", conf.Api.Port))
 
-	shutDownSignal := make(chan os.Signal)
+	shutDownSignal := make(chan os.Signal, 1)
 	signal.Notify(shutDownSignal, syscall.SIGINT, syscall.SIGTERM)
 
+	//missing timeout would cancel requests and background tasks right away
+	shutdownTimeout := conf.Api.ShutdownTimeout
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = defaultShutdownTimeout
+	}
+
 	<-shutDownSignal
-	logger.Print("exited from app")
+	logger.Info("shutting down app", slog.Duration("timeout", shutdownTimeout))
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	//storage is closed last as requests and background tasks may still use it
+	if err := a.Shutdown(shutdownCtx); err != nil {
+		logger.Error("shut down api", logging.Err(err))
+	}
+	if err := tmdbClient.Close(shutdownCtx); err != nil {
+		logger.Error("stop TMDB cache", logging.Err(err))
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Error("flush traces", logging.Err(err))
+	}
+	loops.Wait()
+	if err := store.Close(); err != nil {
+		logger.Error("close storage", logging.Err(err))
+	}
+	logger.Info("exited from app")
+}
+
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, logging.Err(err))
+	os.Exit(1)
 }
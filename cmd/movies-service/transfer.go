@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/BarTar213/movies-service/logging"
+	"github.com/BarTar213/movies-service/storage"
+	"github.com/BarTar213/movies-service/transfer"
+)
+
+const (
+	importCommand = "import"
+	exportCommand = "export"
+
+	stdStream = "-"
+)
+
+// runCommand runs import or export subcommand and returns exit code
+func runCommand(name string, args []string, storage storage.Storage, logger *slog.Logger) int {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	file := flags.String("file", stdStream, "JSON Lines file, - for standard input or output")
+	types := flags.String("types", strings.Join(transfer.RecordTypes, ","), "comma separated record types to export")
+	batch := flags.Int("batch", 500, "number of records read from storage at once")
+	err := flags.Parse(args)
+	if err != nil {
+		return 2
+	}
+
+	switch name {
+	case importCommand:
+		err = importFile(*file, storage, logger)
+	case exportCommand:
+		err = exportFile(*file, strings.Split(*types, ","), *batch, storage, logger)
+	default:
+		err = fmt.Errorf("unknown command %s, expected %s or %s", name, importCommand, exportCommand)
+	}
+	if err != nil {
+		logger.Error("run command", slog.String("command", name), logging.Err(err))
+		return 1
+	}
+
+	return 0
+}
+
+func importFile(path string, storage storage.Storage, logger *slog.Logger) error {
+	var r io.Reader = os.Stdin
+	if path != stdStream {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	importer := transfer.NewImporter(storage, func(err *transfer.RecordError) {
+		logger.Warn("import record", logging.Err(err))
+	})
+	summary, err := importer.Import(context.Background(), r)
+	logger.Info("imported", slog.String("summary", summary.String()))
+	if err != nil {
+		return err
+	}
+	if summary.Failed > 0 {
+		return fmt.Errorf("%d records failed to import", summary.Failed)
+	}
+
+	return nil
+}
+
+func exportFile(path string, types []string, batch int, storage storage.Storage, logger *slog.Logger) error {
+	var w io.Writer = os.Stdout
+	if path != stdStream {
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	summary, err := transfer.NewExporter(storage, batch).Export(context.Background(), w, types)
+	logger.Info("exported", slog.String("summary", summary.String()))
+
+	return err
+}
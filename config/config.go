@@ -2,6 +2,7 @@ package config
 
 import (
 	"log"
+	"log/slog"
 	"time"
 
 	"github.com/spf13/viper"
@@ -9,15 +10,42 @@ import (
 
 type Config struct {
 	Api         Api
+	Storage     string
 	Postgres    Postgres
 	Tmdb        Tmdb
+	Ranking     Ranking
+	Providers   Providers
 	Notificator Notificator
+	Tracing     Tracing
+	Logging     Logging
+	Health      Health
 }
 
+const redacted = "[REDACTED]"
+
+// LogValue hides secrets when config is logged
+func (c Config) LogValue() slog.Value {
+	if len(c.Postgres.Password) > 0 {
+		c.Postgres.Password = redacted
+	}
+	if len(c.Tmdb.Key) > 0 {
+		c.Tmdb.Key = redacted
+	}
+	if len(c.Providers.Omdb.Key) > 0 {
+		c.Providers.Omdb.Key = redacted
+	}
+	return slog.AnyValue(loggedConfig(c))
+}
+
+// loggedConfig doesn't implement slog.LogValuer, so redacted copy is logged as it is
+type loggedConfig Config
+
 type Api struct {
 	Port    string
 	Timeout time.Duration
 	Release bool
+	//time given to in-flight requests and background tasks on shutdown
+	ShutdownTimeout time.Duration
 }
 
 type Postgres struct {
@@ -28,6 +56,37 @@ type Postgres struct {
 }
 
 type Tmdb struct {
+	Url         string
+	Key         string
+	CreditsTTL  time.Duration
+	ImageUrl    string
+	ImageSize   string
+	Concurrency int
+
+	RateLimit        float64
+	Burst            int
+	MaxRetries       int
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+
+	CacheTTL       time.Duration
+	CacheSize      int
+	CacheSnapshots bool
+}
+
+type Ranking struct {
+	MinVotes        int
+	RefreshInterval time.Duration
+}
+
+type Providers struct {
+	Order     []string
+	Merge     map[string]string
+	Omdb      Omdb
+	Catalogue string
+}
+
+type Omdb struct {
 	Url string
 	Key string
 }
@@ -36,6 +95,23 @@ type Notificator struct {
 	Address string
 }
 
+type Health struct {
+	Timeout  time.Duration
+	Interval time.Duration
+}
+
+type Logging struct {
+	Level  string
+	Format string
+}
+
+type Tracing struct {
+	Exporter    string
+	Endpoint    string
+	Insecure    bool
+	SampleRatio float64
+}
+
 func NewConfig(fileName string) *Config {
 	viper.SetConfigFile(fileName)
 	viper.SetConfigType("yaml")
@@ -0,0 +1,185 @@
+package health
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusDegraded = "degraded"
+	StatusUnknown  = "unknown"
+
+	defaultTimeout  = 2 * time.Second
+	defaultInterval = 30 * time.Second
+)
+
+// Check returns error when dependency can't be used
+type Check func(ctx context.Context) error
+
+// Status of single dependency, cached statuses are as old as their CheckedAt
+type Status struct {
+	Status    string     `json:"status"`
+	Critical  bool       `json:"critical"`
+	LatencyMs float64    `json:"latency_ms"`
+	Error     string     `json:"error,omitempty"`
+	CheckedAt *time.Time `json:"checked_at,omitempty"`
+}
+
+// Report is down when any critical dependency is down and degraded when only other dependencies are down
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Status `json:"checks"`
+}
+
+type check struct {
+	name     string
+	critical bool
+	cached   bool
+	fn       Check
+
+	mu   sync.Mutex
+	last Status
+}
+
+type Checker struct {
+	timeout  time.Duration
+	interval time.Duration
+	checks   []*check
+}
+
+// zero timeout and interval are replaced with defaults
+func NewChecker(timeout time.Duration, interval time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	return &Checker{
+		timeout:  timeout,
+		interval: interval,
+	}
+}
+
+// Add registers check run on every Check call
+func (c *Checker) Add(name string, critical bool, fn Check) {
+	c.checks = append(c.checks, &check{name: name, critical: critical, fn: fn})
+}
+
+// AddCached registers check run in background by Run, Check reports its last result
+// so slow dependency doesn't block callers
+func (c *Checker) AddCached(name string, critical bool, fn Check) {
+	c.checks = append(c.checks, &check{
+		name:     name,
+		critical: critical,
+		cached:   true,
+		fn:       fn,
+		last:     Status{Status: StatusUnknown, Critical: critical},
+	})
+}
+
+// Run refreshes cached checks right away and then periodically until ctx is done
+func (c *Checker) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		c.refresh(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Checker) refresh(ctx context.Context) {
+	wg := sync.WaitGroup{}
+	for _, ch := range c.checks {
+		if !ch.cached {
+			continue
+		}
+		wg.Add(1)
+		go func(ch *check) {
+			defer wg.Done()
+			status := c.run(ctx, ch)
+			ch.mu.Lock()
+			ch.last = status
+			ch.mu.Unlock()
+		}(ch)
+	}
+	wg.Wait()
+}
+
+// Check runs not cached checks concurrently and reports them together with last results of cached checks
+func (c *Checker) Check(ctx context.Context) Report {
+	statuses := make([]Status, len(c.checks))
+	wg := sync.WaitGroup{}
+	for i, ch := range c.checks {
+		if ch.cached {
+			ch.mu.Lock()
+			statuses[i] = ch.last
+			ch.mu.Unlock()
+			continue
+		}
+		wg.Add(1)
+		go func(i int, ch *check) {
+			defer wg.Done()
+			statuses[i] = c.run(ctx, ch)
+		}(i, ch)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp, Checks: make(map[string]Status, len(c.checks))}
+	for i, ch := range c.checks {
+		status := statuses[i]
+		report.Checks[ch.name] = status
+		if status.Status == StatusUp {
+			continue
+		}
+		if ch.critical {
+			report.Status = StatusDown
+		} else if report.Status == StatusUp {
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, ch *check) Status {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := ch.fn(ctx)
+	latency := time.Since(start)
+
+	status := Status{
+		Status:    StatusUp,
+		Critical:  ch.critical,
+		LatencyMs: float64(latency.Microseconds()) / 1000,
+		CheckedAt: &start,
+	}
+	if err != nil {
+		status.Status = StatusDown
+		status.Error = err.Error()
+	}
+	return status
+}
+
+// Dial checks that tcp connection to address can be opened, it's used for services without health endpoint
+func Dial(address string) Check {
+	return func(ctx context.Context) error {
+		conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", address)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
@@ -0,0 +1,98 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errDown = errors.New("down")
+
+func up(ctx context.Context) error {
+	return nil
+}
+
+func down(ctx context.Context) error {
+	return errDown
+}
+
+func TestChecker_Check(t *testing.T) {
+	tests := []struct {
+		name       string
+		register   func(c *Checker)
+		refresh    bool
+		wantStatus string
+		wantChecks map[string]string
+	}{
+		{
+			name: "positive_all_up",
+			register: func(c *Checker) {
+				c.Add("storage", true, up)
+				c.AddCached("tmdb", false, up)
+			},
+			refresh:    true,
+			wantStatus: StatusUp,
+			wantChecks: map[string]string{"storage": StatusUp, "tmdb": StatusUp},
+		},
+		{
+			name: "positive_cached_check_not_run_yet",
+			register: func(c *Checker) {
+				c.Add("storage", true, up)
+				c.AddCached("tmdb", false, up)
+			},
+			wantStatus: StatusDegraded,
+			wantChecks: map[string]string{"storage": StatusUp, "tmdb": StatusUnknown},
+		},
+		{
+			name: "negative_not_critical_down",
+			register: func(c *Checker) {
+				c.Add("storage", true, up)
+				c.AddCached("notificator", false, down)
+			},
+			refresh:    true,
+			wantStatus: StatusDegraded,
+			wantChecks: map[string]string{"storage": StatusUp, "notificator": StatusDown},
+		},
+		{
+			name: "negative_critical_down",
+			register: func(c *Checker) {
+				c.Add("storage", true, down)
+				c.AddCached("tmdb", false, down)
+			},
+			refresh:    true,
+			wantStatus: StatusDown,
+			wantChecks: map[string]string{"storage": StatusDown, "tmdb": StatusDown},
+		},
+		{
+			name: "negative_check_timeout",
+			register: func(c *Checker) {
+				c.Add("storage", true, func(ctx context.Context) error {
+					<-ctx.Done()
+					return ctx.Err()
+				})
+			},
+			wantStatus: StatusDown,
+			wantChecks: map[string]string{"storage": StatusDown},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewChecker(10*time.Millisecond, time.Minute)
+			tt.register(c)
+			if tt.refresh {
+				c.refresh(context.Background())
+			}
+
+			report := c.Check(context.Background())
+			if report.Status != tt.wantStatus {
+				t.Errorf("Check() status = %s, want %s", report.Status, tt.wantStatus)
+			}
+			for name, want := range tt.wantChecks {
+				if got := report.Checks[name].Status; got != want {
+					t.Errorf("Check() %s status = %s, want %s", name, got, want)
+				}
+			}
+		})
+	}
+}
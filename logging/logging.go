@@ -0,0 +1,124 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/BarTar213/movies-service/config"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	JsonFormat = "json"
+	TextFormat = "text"
+
+	Redacted = "[REDACTED]"
+)
+
+// attributes with these keys never reach the output, keys are compared case insensitively
+var secretKeys = map[string]bool{
+	"password":      true,
+	"api_key":       true,
+	"apikey":        true,
+	"secret":        true,
+	"token":         true,
+	"authorization": true,
+}
+
+// secrets sent in query of urls, e.g. TMDB key in urls of errors returned by http client
+var secretParams = regexp.MustCompile(`(?i)((?:api_?key|token)=)[^&\s"]+`)
+
+// New returns logger writing records of configured level and format to w, records carry attributes
+// stored in their context by WithAttrs and ids of trace and span
+func New(conf config.Logging, w io.Writer) (*slog.Logger, error) {
+	level := slog.LevelInfo
+	if len(conf.Level) > 0 {
+		err := level.UnmarshalText([]byte(conf.Level))
+		if err != nil {
+			return nil, fmt.Errorf("invalid log level %q", conf.Level)
+		}
+	}
+
+	options := &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redact,
+	}
+
+	var handler slog.Handler
+	switch conf.Format {
+	case "", JsonFormat:
+		handler = slog.NewJSONHandler(w, options)
+	case TextFormat:
+		handler = slog.NewTextHandler(w, options)
+	default:
+		return nil, fmt.Errorf("unknown log format %q", conf.Format)
+	}
+
+	return slog.New(&contextHandler{Handler: handler}), nil
+}
+
+// Err is attribute of logged error
+func Err(err error) slog.Attr {
+	return slog.Any("error", err)
+}
+
+type attrsKey struct{}
+
+// WithAttrs returns context whose records are logged with attrs added to attrs already stored in ctx
+func WithAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	stored, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	merged := make([]slog.Attr, 0, len(stored)+len(attrs))
+	merged = append(merged, stored...)
+	merged = append(merged, attrs...)
+	return context.WithValue(ctx, attrsKey{}, merged)
+}
+
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if attrs, ok := ctx.Value(attrsKey{}).([]slog.Attr); ok {
+		record.AddAttrs(attrs...)
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		record.AddAttrs(
+			slog.String("trace_id", span.TraceID().String()),
+			slog.String("span_id", span.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+// redact hides values of secret keys and secret params of urls in strings and errors
+func redact(groups []string, attr slog.Attr) slog.Attr {
+	if secretKeys[strings.ToLower(attr.Key)] {
+		return slog.String(attr.Key, Redacted)
+	}
+
+	switch attr.Value.Kind() {
+	case slog.KindString:
+		attr.Value = slog.StringValue(RedactString(attr.Value.String()))
+	case slog.KindAny:
+		if err, ok := attr.Value.Any().(error); ok {
+			attr.Value = slog.StringValue(RedactString(err.Error()))
+		}
+	}
+	return attr
+}
+
+func RedactString(s string) string {
+	return secretParams.ReplaceAllString(s, "${1}"+Redacted)
+}
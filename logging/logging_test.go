@@ -0,0 +1,93 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/BarTar213/movies-service/config"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    config.Logging
+		log     func(logger *slog.Logger)
+		want    []string
+		wantNot []string
+		wantErr bool
+	}{
+		{
+			name: "positive_context_attrs",
+			conf: config.Logging{Level: "info"},
+			log: func(logger *slog.Logger) {
+				ctx := WithAttrs(context.Background(), slog.String("request_id", "request-1"))
+				ctx = WithAttrs(ctx, slog.Int("account_id", 7))
+				logger.InfoContext(ctx, "handled")
+			},
+			want: []string{`"msg":"handled"`, `"request_id":"request-1"`, `"account_id":7`},
+		},
+		{
+			name: "positive_level_filters_records",
+			conf: config.Logging{Level: "warn"},
+			log: func(logger *slog.Logger) {
+				logger.Info("hidden")
+				logger.Warn("shown")
+			},
+			want:    []string{`"msg":"shown"`},
+			wantNot: []string{"hidden"},
+		},
+		{
+			name: "positive_redact_secrets",
+			conf: config.Logging{Format: TextFormat},
+			log: func(logger *slog.Logger) {
+				err := errors.New(`Get "https://api.themoviedb.org/3/movie/1?api_key=secret1&language=pl": timeout`)
+				logger.Error("tmdb", Err(err), slog.String("password", "secret2"))
+				conf := config.Config{
+					Postgres: config.Postgres{User: "postgres", Password: "secret3"},
+					Tmdb:     config.Tmdb{Key: "secret4"},
+				}
+				logger.Info("loaded config", slog.Any("config", conf))
+			},
+			want:    []string{"api_key=" + Redacted + "&language=pl", "password=" + Redacted, "User:postgres"},
+			wantNot: []string{"secret1", "secret2", "secret3", "secret4"},
+		},
+		{
+			name:    "negative_invalid_level",
+			conf:    config.Logging{Level: "verbose"},
+			wantErr: true,
+		},
+		{
+			name:    "negative_unknown_format",
+			conf:    config.Logging{Format: "xml"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			logger, err := New(tt.conf, out)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			tt.log(logger)
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("output %s doesn't contain %s", out.String(), want)
+				}
+			}
+			for _, wantNot := range tt.wantNot {
+				if strings.Contains(out.String(), wantNot) {
+					t.Errorf("output %s contains %s", out.String(), wantNot)
+				}
+			}
+		})
+	}
+}
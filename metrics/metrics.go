@@ -1,34 +1,193 @@
 package metrics
 
 import (
+	"net/http"
+
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 const (
 	namespace = "movies_service"
 
-	apiSubsystem = "api"
+	apiSubsystem     = "api"
+	tmdbSubsystem    = "tmdb"
+	storageSubsystem = "storage"
+
+	engagementSubsystem  = "engagement"
+	notificatorSubsystem = "notificator"
+)
+
+// label values of business metrics, ids of users and resources are never used as labels
+const (
+	MovieResource   = "movie"
+	CommentResource = "comment"
+
+	LikeAction   = "like"
+	UnlikeAction = "unlike"
+
+	CreatedAction = "created"
+	EditedAction  = "edited"
+	DeletedAction = "deleted"
+
+	AddedAction   = "added"
+	ChangedAction = "changed"
+	RemovedAction = "removed"
+
+	SentOutcome   = "sent"
+	FailedOutcome = "failed"
+
+	StoredOutcome = "stored"
 )
 
+// Metrics are registered only in registry of the service, so many instances can live in one process
 type Metrics struct {
 	registry *prometheus.Registry
 
-	APIRequestDuration prometheus.Histogram
+	APIRequests         *prometheus.CounterVec
+	APIRequestDuration  *prometheus.HistogramVec
+	APIRequestsInFlight *prometheus.GaugeVec
+
+	TmdbRequests        *prometheus.CounterVec
+	TmdbRequestDuration *prometheus.HistogramVec
+	TmdbCache           *prometheus.CounterVec
+
+	StorageQueryDuration *prometheus.HistogramVec
+
+	IngestedMovies         *prometheus.CounterVec
+	IngestionBatchDuration prometheus.Histogram
+	IngestionBatchSize     prometheus.Histogram
+
+	Likes                 *prometheus.CounterVec
+	Comments              *prometheus.CounterVec
+	Ratings               *prometheus.CounterVec
+	Notifications         *prometheus.CounterVec
+	NotificationsInFlight prometheus.Gauge
 }
 
 func New() *Metrics {
 	metrics := &Metrics{
 		registry: prometheus.NewRegistry(),
 	}
+	metrics.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	factory := promauto.With(metrics.registry)
+
+	metrics.APIRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: apiSubsystem,
+		Name:      "requests_total",
+		Help:      "Number of handled requests by route template, method and status.",
+	}, []string{"route", "method", "status"})
+
+	metrics.APIRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: apiSubsystem,
+		Name:      "request_duration_seconds",
+		Help:      "Duration of handled requests by route template, method and status.",
+	}, []string{"route", "method", "status"})
 
-	metrics.APIRequestDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+	metrics.APIRequestsInFlight = factory.NewGaugeVec(prometheus.GaugeOpts{
 		Namespace: namespace,
 		Subsystem: apiSubsystem,
-		Name:      "request_duration_milliseconds",
+		Name:      "requests_in_flight",
+		Help:      "Number of requests being handled by route template and method.",
+	}, []string{"route", "method"})
+
+	metrics.TmdbRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: tmdbSubsystem,
+		Name:      "requests_total",
+		Help:      "Number of TMDB requests by outcome, retries are counted separately.",
+	}, []string{"outcome"})
+
+	metrics.TmdbRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: tmdbSubsystem,
+		Name:      "request_duration_seconds",
+		Help:      "Duration of TMDB calls including retries by endpoint and outcome.",
+	}, []string{"endpoint", "outcome"})
+
+	metrics.TmdbCache = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: tmdbSubsystem,
+		Name:      "cache_requests_total",
+		Help:      "Number of TMDB list cache lookups by list and result.",
+	}, []string{"list", "result"})
+
+	metrics.StorageQueryDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: storageSubsystem,
+		Name:      "query_duration_seconds",
+		Help:      "Duration of database queries by statement and outcome.",
+		Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 14),
+	}, []string{"statement", "outcome"})
+
+	metrics.IngestedMovies = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: storageSubsystem,
+		Name:      "ingested_movies_total",
+		Help:      "Number of movies upserted by outcome, duplicates within batch are counted once.",
+	}, []string{"outcome"})
+
+	metrics.IngestionBatchDuration = factory.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: storageSubsystem,
+		Name:      "ingestion_batch_duration_seconds",
+		Help:      "Duration of batch movie upserts.",
+	})
+
+	metrics.IngestionBatchSize = factory.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: storageSubsystem,
+		Name:      "ingestion_batch_size",
+		Help:      "Number of unique movies in upserted batches.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 10),
 	})
 
-	metrics.registry.MustRegister(metrics.APIRequestDuration)
+	metrics.Likes = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: engagementSubsystem,
+		Name:      "likes_total",
+		Help:      "Number of likes and unlikes by resource and action.",
+	}, []string{"resource", "action"})
+
+	metrics.Comments = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: engagementSubsystem,
+		Name:      "comments_total",
+		Help:      "Number of created, edited and deleted comments.",
+	}, []string{"action"})
+
+	metrics.Ratings = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: engagementSubsystem,
+		Name:      "ratings_total",
+		Help:      "Number of added, changed and removed ratings.",
+	}, []string{"action"})
+
+	metrics.Notifications = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: notificatorSubsystem,
+		Name:      "notifications_total",
+		Help:      "Number of notifications by template and outcome.",
+	}, []string{"template", "outcome"})
+
+	metrics.NotificationsInFlight = factory.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: notificatorSubsystem,
+		Name:      "notifications_in_flight",
+		Help:      "Number of notifications being sent.",
+	})
 
 	return metrics
 }
+
+// Handler serves metrics of the service registry
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
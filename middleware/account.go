@@ -1,9 +1,12 @@
 package middleware
 
 import (
+	"log/slog"
 	"net/http"
 
+	"github.com/BarTar213/movies-service/logging"
 	"github.com/BarTar213/movies-service/models"
+	"github.com/BarTar213/movies-service/utils"
 	"github.com/gin-gonic/gin"
 )
 
@@ -12,10 +15,24 @@ func CheckAccount() gin.HandlerFunc {
 		account := models.AccountInfo{}
 		err := c.ShouldBindHeader(&account)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusForbidden, models.Response{Error: "invalid account headers"})
+			utils.RespondError(c, http.StatusForbidden, models.CodeInvalidAccount, "invalid account headers", utils.BindingFields(err)...)
 			return
 		}
 		c.Set("account", account)
+		c.Request = c.Request.WithContext(logging.WithAttrs(c.Request.Context(), slog.Int("account_id", account.ID)))
+		c.Next()
+	}
+}
+
+// CheckRole should be used after CheckAccount
+// aborts requests of accounts without given role
+func CheckRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		account, ok := c.Keys["account"].(models.AccountInfo)
+		if !ok || account.Role != role {
+			utils.RespondError(c, http.StatusForbidden, models.CodeForbidden, "insufficient permissions")
+			return
+		}
 		c.Next()
 	}
 }
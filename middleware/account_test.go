@@ -50,6 +50,51 @@ func TestCheckAccount(t *testing.T) {
 	}
 }
 
+func TestCheckRole(t *testing.T) {
+	tests := []struct {
+		name       string
+		account    *models.AccountInfo
+		wantStatus int
+	}{
+		{
+			name: "positive_check_role",
+			account: &models.AccountInfo{
+				ID:    1,
+				Login: "login",
+				Role:  "admin",
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "negative_check_role_other_role",
+			account: &models.AccountInfo{
+				ID:    1,
+				Login: "login",
+				Role:  "role",
+			},
+			wantStatus: http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.Default()
+			router.Use(CheckAccount(), CheckRole("admin"))
+			router.GET("/ping", func(c *gin.Context) {
+				c.String(http.StatusOK, "pong")
+			})
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/ping", nil)
+			setAccountHeaders(req, tt.account)
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("CheckRole() = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
 func setupRouter() *gin.Engine {
 	r := gin.Default()
 	r.Use(CheckAccount())
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/BarTar213/movies-service/logging"
+	"github.com/BarTar213/movies-service/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Logging should be used after RequestId
+// records logged with request context carry request id and route, every request is logged when it's done
+func Logging(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if len(route) == 0 {
+			route = unmatchedRoute
+		}
+
+		ctx := logging.WithAttrs(c.Request.Context(),
+			slog.String("request_id", utils.GetRequestId(c)),
+			slog.String("route", route),
+		)
+		c.Request = c.Request.WithContext(ctx)
+
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+		//account id is added by CheckAccount to context of request replaced during c.Next
+		logger.LogAttrs(c.Request.Context(), level, "request handled",
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+		)
+	}
+}
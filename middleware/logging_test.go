@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BarTar213/movies-service/config"
+	"github.com/BarTar213/movies-service/logging"
+	"github.com/gin-gonic/gin"
+)
+
+func TestLogging(t *testing.T) {
+	tests := []struct {
+		name      string
+		url       string
+		headers   map[string]string
+		wantLevel string
+		want      map[string]interface{}
+	}{
+		{
+			name: "positive_logging_authorized_request",
+			url:  "/movies/12",
+			headers: map[string]string{
+				RequestIdHeader: "request-1",
+				"X-Account-Id":  "7",
+				"X-Account":     "login",
+				"X-Role":        "role",
+			},
+			wantLevel: "INFO",
+			want: map[string]interface{}{
+				"request_id": "request-1",
+				"route":      "/movies/:movieId",
+				"account_id": float64(7),
+				"status":     float64(http.StatusOK),
+			},
+		},
+		{
+			name:      "negative_logging_invalid_account",
+			url:       "/movies/12",
+			wantLevel: "WARN",
+			want: map[string]interface{}{
+				"route":  "/movies/:movieId",
+				"status": float64(http.StatusForbidden),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			out := &bytes.Buffer{}
+			logger, _ := logging.New(config.Logging{}, out)
+
+			router := gin.New()
+			router.Use(RequestId(), Logging(logger))
+			router.GET("/movies/:movieId", CheckAccount(), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, tt.url, nil)
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			router.ServeHTTP(w, req)
+
+			record := map[string]interface{}{}
+			if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+				t.Fatalf("invalid record %s: %s", out.String(), err)
+			}
+			if record["level"] != tt.wantLevel {
+				t.Errorf("Logging() level = %v, want %v", record["level"], tt.wantLevel)
+			}
+			for key, want := range tt.want {
+				if record[key] != want {
+					t.Errorf("Logging() %s = %v, want %v", key, record[key], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/BarTar213/movies-service/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// requests which didn't match any route share one label value, so unknown paths don't create new series
+const unmatchedRoute = "unmatched"
+
+// Metrics records count, duration and number of in flight requests labelled by route template,
+// method and status of response
+func Metrics(m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if len(route) == 0 {
+			route = unmatchedRoute
+		}
+		method := c.Request.Method
+
+		inFlight := m.APIRequestsInFlight.WithLabelValues(route, method)
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		start := time.Now()
+		c.Next()
+
+		status := strconv.Itoa(c.Writer.Status())
+		m.APIRequests.WithLabelValues(route, method, status).Inc()
+		m.APIRequestDuration.WithLabelValues(route, method, status).Observe(time.Since(start).Seconds())
+	}
+}
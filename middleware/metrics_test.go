@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BarTar213/movies-service/metrics"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetrics(t *testing.T) {
+	tests := []struct {
+		name       string
+		url        string
+		wantRoute  string
+		wantStatus string
+	}{
+		{
+			name:       "positive_metrics_route_template",
+			url:        "/movies/12",
+			wantRoute:  "/movies/:movieId",
+			wantStatus: "200",
+		},
+		{
+			name:       "positive_metrics_unmatched_route",
+			url:        "/unknown/12",
+			wantRoute:  unmatchedRoute,
+			wantStatus: "404",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			m := metrics.New()
+			router := gin.New()
+			router.Use(Metrics(m))
+
+			inFlight := 0.0
+			router.GET("/movies/:movieId", func(c *gin.Context) {
+				inFlight = testutil.ToFloat64(m.APIRequestsInFlight.WithLabelValues("/movies/:movieId", http.MethodGet))
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, tt.url, nil)
+			router.ServeHTTP(w, req)
+
+			if got := testutil.ToFloat64(m.APIRequests.WithLabelValues(tt.wantRoute, http.MethodGet, tt.wantStatus)); got != 1 {
+				t.Errorf("Metrics() requests = %v, want %v", got, 1)
+			}
+			if got := testutil.CollectAndCount(m.APIRequestDuration); got != 1 {
+				t.Errorf("Metrics() duration series = %v, want %v", got, 1)
+			}
+			if tt.wantRoute != unmatchedRoute && inFlight != 1 {
+				t.Errorf("Metrics() in flight = %v, want %v", inFlight, 1)
+			}
+			if got := testutil.ToFloat64(m.APIRequestsInFlight.WithLabelValues(tt.wantRoute, http.MethodGet)); got != 0 {
+				t.Errorf("Metrics() in flight after request = %v, want %v", got, 0)
+			}
+		})
+	}
+}
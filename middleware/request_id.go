@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/BarTar213/movies-service/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const RequestIdHeader = "X-Request-Id"
+
+// RequestId keeps id of request received from caller or generates new one,
+// the id is returned in response header and in error responses
+func RequestId() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIdHeader)
+		if len(id) == 0 || len(id) > 128 {
+			id = newRequestId()
+		}
+
+		c.Set(utils.RequestIdKey, id)
+		c.Header(RequestIdHeader, id)
+		c.Next()
+	}
+}
+
+func newRequestId() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BarTar213/movies-service/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestId(t *testing.T) {
+	tests := []struct {
+		name      string
+		requestId string
+		wantKept  bool
+	}{
+		{
+			name:      "positive_request_id_kept",
+			requestId: "request-1",
+			wantKept:  true,
+		},
+		{
+			name:     "positive_request_id_generated",
+			wantKept: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			router := gin.New()
+			router.Use(RequestId())
+
+			got := ""
+			router.GET("/ping", func(c *gin.Context) {
+				got = utils.GetRequestId(c)
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/ping", nil)
+			if len(tt.requestId) > 0 {
+				req.Header.Set(RequestIdHeader, tt.requestId)
+			}
+			router.ServeHTTP(w, req)
+
+			if len(got) == 0 || got != w.Header().Get(RequestIdHeader) {
+				t.Errorf("RequestId() = %q, header %q", got, w.Header().Get(RequestIdHeader))
+			}
+			if (got == tt.requestId) != tt.wantKept {
+				t.Errorf("RequestId() = %q, kept %v", got, tt.wantKept)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTimeout(t *testing.T) {
+	tests := []struct {
+		name         string
+		timeout      time.Duration
+		wantDeadline bool
+	}{
+		{
+			name:         "positive_timeout",
+			timeout:      time.Second,
+			wantDeadline: true,
+		},
+		{
+			name:         "positive_timeout_disabled",
+			timeout:      0,
+			wantDeadline: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.Default()
+			router.Use(Timeout(tt.timeout))
+
+			hasDeadline := false
+			router.GET("/ping", func(c *gin.Context) {
+				_, hasDeadline = c.Request.Context().Deadline()
+				c.String(http.StatusOK, "pong")
+			})
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/ping", nil)
+			router.ServeHTTP(w, req)
+
+			if hasDeadline != tt.wantDeadline {
+				t.Errorf("Timeout() deadline = %v, want %v", hasDeadline, tt.wantDeadline)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	provider, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagator)
+	})
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	tests := []struct {
+		name        string
+		traceparent string
+		status      int
+		wantName    string
+		wantTraceId string
+		wantStatus  codes.Code
+	}{
+		{
+			name:        "positive_tracing_continues_trace",
+			traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			status:      http.StatusOK,
+			wantName:    "GET /movies/:movieId",
+			wantTraceId: "4bf92f3577b34da6a3ce929d0e0e4736",
+			wantStatus:  codes.Unset,
+		},
+		{
+			name:       "negative_tracing_server_error",
+			status:     http.StatusInternalServerError,
+			wantName:   "GET /movies/:movieId",
+			wantStatus: codes.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			router := gin.New()
+			router.Use(Tracing())
+
+			var handlerSpan trace.SpanContext
+			router.GET("/movies/:movieId", func(c *gin.Context) {
+				handlerSpan = trace.SpanContextFromContext(c.Request.Context())
+				c.Status(tt.status)
+			})
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/movies/12", nil)
+			if len(tt.traceparent) > 0 {
+				req.Header.Set("traceparent", tt.traceparent)
+			}
+			router.ServeHTTP(w, req)
+
+			spans := recorder.Ended()
+			span := spans[len(spans)-1]
+			if span.Name() != tt.wantName {
+				t.Errorf("Tracing() span name = %s, want %s", span.Name(), tt.wantName)
+			}
+			if span.SpanContext().SpanID() != handlerSpan.SpanID() {
+				t.Errorf("Tracing() request context doesn't carry server span")
+			}
+			if len(tt.wantTraceId) > 0 && span.SpanContext().TraceID().String() != tt.wantTraceId {
+				t.Errorf("Tracing() trace id = %s, want %s", span.SpanContext().TraceID(), tt.wantTraceId)
+			}
+			if span.Status().Code != tt.wantStatus {
+				t.Errorf("Tracing() status = %v, want %v", span.Status().Code, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -1,18 +1,25 @@
 package mock
 
 import (
+	"context"
 	"errors"
+	"sync/atomic"
 
 	"github.com/BarTar213/movies-service/models"
-	"github.com/go-pg/pg/v10"
+	"github.com/BarTar213/movies-service/storage"
 )
 
 var exampleErr = errors.New("example error")
 
 type Storage struct {
+	PingErr bool
+
 	AddMovieErr             bool
+	AddMoviesErr            bool
 	GetMovieErr             bool
+	GetMovieNotFoundErr     bool
 	ListMoviesErr           bool
+	ListMoviesFromIDsErr    bool
 	LikeMovieErr            bool
 	DeleteMovieLikeErr      bool
 	AddRecentViewedMovieErr bool
@@ -26,163 +33,386 @@ type Storage struct {
 	LikeCommentErr               bool
 	DeleteCommentLikeErr         bool
 	DeleteCommentErr             bool
+	UpsertCommentErr             bool
+	UpdateCommentNotFoundErr     bool
+	// no comment matched, nothing is deleted
+	DeleteCommentNotFound bool
 
 	GetCreditsErr         bool
 	GetCreditsNotFoundErr bool
 	AddCreditsErr         bool
 
+	ListImagesErr   bool
+	ListImagesEmpty bool
+	AddImagesErr    bool
+	ListVideosErr   bool
+	ListVideosEmpty bool
+	AddVideosErr    bool
+
+	GetTranslationErr         bool
+	GetTranslationNotFoundErr bool
+	ListTranslationsErr       bool
+	AddTranslationsErr        bool
+
+	GetPersonErr         bool
+	GetPersonNotFoundErr bool
+	AddPersonErr         bool
+	ListPersonCastErr    bool
+	ListPersonCrewErr    bool
+
+	GetSnapshotErr  bool
+	SaveSnapshotErr bool
+
+	RefreshCommunityRankingsErr bool
+	ListCommunityRankingErr     bool
+
 	GetRatingErr       bool
 	AddRatingErr       bool
 	DeleteRatingErr    bool
 	ListRatedMoviesErr bool
+	// change reported by AddRating
+	RatingChange storage.RatingChange
+	// no rating matched, nothing is deleted
+	DeleteRatingNotFound bool
+
+	ExportMoviesErr   bool
+	ExportCreditsErr  bool
+	ExportCommentsErr bool
+	ExportRatingsErr  bool
+
+	getMovieCalls int32
 }
 
-func (s *Storage) AddMovie(movie *models.TmdbMovie) error {
+func (s *Storage) Ping(ctx context.Context) error {
+	if s.PingErr {
+		return exampleErr
+	}
+	return nil
+}
+
+func (s *Storage) Close() error {
+	return nil
+}
+
+func (s *Storage) AddMovie(ctx context.Context, movie *models.TmdbMovie) error {
 	if s.AddMovieErr {
 		return exampleErr
 	}
 	return nil
 }
 
-func (s *Storage) GetMovie(movie *models.Movie) error {
+func (s *Storage) AddMovies(ctx context.Context, movies []models.TmdbMovie) error {
+	if s.AddMoviesErr {
+		return exampleErr
+	}
+	return nil
+}
+
+// movie is found after the first call with GetMovieNotFoundErr, as if it was fetched and stored in between
+func (s *Storage) GetMovie(ctx context.Context, movie *models.Movie) error {
 	if s.GetMovieErr {
 		return exampleErr
 	}
+	if s.GetMovieNotFoundErr && atomic.AddInt32(&s.getMovieCalls, 1) == 1 {
+		return storage.ErrNotFound
+	}
 	return nil
 }
 
-func (s *Storage) ListMovieComments(movieId int, params *models.PaginationParams) ([]models.Comment, error) {
+func (s *Storage) ListMovieComments(ctx context.Context, movieId int, params *models.PaginationParams) ([]models.Comment, error) {
 	if s.GetMovieCommentsErr {
 		return nil, exampleErr
 	}
 	return []models.Comment{}, nil
 }
 
-func (s *Storage) AddMovieComment(comment *models.Comment) error {
+func (s *Storage) AddMovieComment(ctx context.Context, comment *models.Comment) error {
 	if s.AddMovieCommentErr {
 		return exampleErr
 	}
 	return nil
 }
 
-func (s *Storage) UpdateComment(comment *models.Comment) error {
+func (s *Storage) UpdateComment(ctx context.Context, comment *models.Comment) error {
 	if s.UpdateCommentErr {
 		return exampleErr
 	}
+	if s.UpdateCommentNotFoundErr {
+		return storage.ErrNotFound
+	}
 	return nil
 }
 
-func (s *Storage) DeleteComment(comment *models.Comment) error {
+func (s *Storage) DeleteComment(ctx context.Context, comment *models.Comment) (bool, error) {
 	if s.DeleteCommentErr {
-		return exampleErr
+		return false, exampleErr
 	}
-	return nil
+	return !s.DeleteCommentNotFound, nil
 }
 
-func (s *Storage) ListMovies(title string, params *models.PaginationParams) ([]models.MoviePreview, error) {
+func (s *Storage) ListMovies(ctx context.Context, filters *models.MovieFilters, params *models.PaginationParams) ([]models.MoviePreview, error) {
 	if s.ListMoviesErr {
 		return nil, exampleErr
 	}
 	return []models.MoviePreview{}, nil
 }
 
-func (s *Storage) LikeMovie(userId int, movieId int) error {
+func (s *Storage) ListMoviesFromIDs(ctx context.Context, IDs []int) ([]models.MoviePreview, error) {
+	if s.ListMoviesFromIDsErr {
+		return nil, exampleErr
+	}
+	return []models.MoviePreview{}, nil
+}
+
+func (s *Storage) LikeMovie(ctx context.Context, userId int, movieId int) error {
 	if s.LikeMovieErr {
 		return exampleErr
 	}
 	return nil
 }
 
-func (s *Storage) DeleteMovieLike(userId int, movieId int) error {
+func (s *Storage) DeleteMovieLike(ctx context.Context, userId int, movieId int) error {
 	if s.DeleteMovieLikeErr {
 		return exampleErr
 	}
 	return nil
 }
 
-func (s *Storage) AddRecentViewedMovie(userId int, movieId int) error {
+func (s *Storage) AddRecentViewedMovie(ctx context.Context, userId int, movieId int) error {
 	if s.AddRecentViewedMovieErr {
 		return exampleErr
 	}
 	return nil
 }
 
-func (s *Storage) LikeComment(userId int, commentId int, comment *models.Comment) error {
+func (s *Storage) LikeComment(ctx context.Context, userId int, commentId int, comment *models.Comment) error {
 	if s.LikeCommentErr {
 		return exampleErr
 	}
 	return nil
 }
 
-func (s *Storage) DeleteCommentLike(userId int, commentId int) error {
+func (s *Storage) DeleteCommentLike(ctx context.Context, userId int, commentId int) error {
 	if s.DeleteCommentLikeErr {
 		return exampleErr
 	}
 	return nil
 }
 
-func (s *Storage) GetCredits(movieId int, credit *models.Credit) error {
+func (s *Storage) GetCredits(ctx context.Context, movieId int, credit *models.Credit) error {
 	if s.GetCreditsErr {
 		return exampleErr
 	}
 	if s.GetCreditsNotFoundErr {
-		return pg.ErrNoRows
+		return storage.ErrNotFound
 	}
 	return nil
 }
 
-func (s *Storage) AddCredits(credit *models.Credit) error {
+func (s *Storage) AddCredits(ctx context.Context, credit *models.Credit) error {
 	if s.AddCreditsErr {
 		return exampleErr
 	}
 	return nil
 }
 
-func (s *Storage) ListLikedMovies(userId int, params *models.PaginationParams) ([]models.MoviePreview, error) {
+func (s *Storage) ListLikedMovies(ctx context.Context, userId int, params *models.PaginationParams) ([]models.MoviePreview, error) {
 	if s.ListLikedMoviesErr {
 		return nil, exampleErr
 	}
 	return []models.MoviePreview{}, nil
 }
 
-func (s *Storage) CheckLiked(likedMovie *models.LikedMovie) (bool, error) {
+func (s *Storage) CheckLiked(ctx context.Context, likedMovie *models.LikedMovie) (bool, error) {
 	if s.CheckLikedErr {
 		return false, exampleErr
 	}
 	return true, nil
 }
 
-func (s *Storage) ListLikedCommentsForMovie(movieID, userID int) ([]int, error) {
+func (s *Storage) ListLikedCommentsForMovie(ctx context.Context, movieID, userID int) ([]int, error) {
 	if s.ListLikedCommentsForMovieErr {
 		return nil, exampleErr
 	}
 	return []int{}, nil
 }
 
-func (s *Storage) AddRating(rating *models.Rating) error {
+func (s *Storage) AddRating(ctx context.Context, rating *models.Rating) (storage.RatingChange, error) {
 	if s.AddRatingErr {
-		return exampleErr
+		return storage.RatingUnchanged, exampleErr
 	}
-	return nil
+	return s.RatingChange, nil
 }
 
-func (s *Storage) DeleteRating(rating *models.Rating) error {
+func (s *Storage) DeleteRating(ctx context.Context, rating *models.Rating) (bool, error) {
 	if s.DeleteRatingErr {
-		return exampleErr
+		return false, exampleErr
 	}
-	return nil
+	return !s.DeleteRatingNotFound, nil
 }
 
-func (s *Storage) ListRatedMovies(userID int, params *models.PaginationParams) ([]models.MoviePreview, error) {
+func (s *Storage) ListRatedMovies(ctx context.Context, userID int, params *models.PaginationParams) ([]models.MoviePreview, error) {
 	if s.ListRatedMoviesErr {
 		return nil, exampleErr
 	}
 	return []models.MoviePreview{}, nil
 }
 
-func (s *Storage) GetRating(rating *models.Rating) error {
+func (s *Storage) RefreshCommunityRankings(ctx context.Context, minVotes int) error {
+	if s.RefreshCommunityRankingsErr {
+		return exampleErr
+	}
+	return nil
+}
+
+func (s *Storage) ListCommunityRanking(ctx context.Context, params *models.CommunityRankingParams, pagination *models.PaginationParams) ([]models.RankedMovie, int, error) {
+	if s.ListCommunityRankingErr {
+		return nil, 0, exampleErr
+	}
+	return []models.RankedMovie{}, 0, nil
+}
+
+func (s *Storage) GetRating(ctx context.Context, rating *models.Rating) error {
 	if s.GetRatingErr {
 		return exampleErr
 	}
 	return nil
 }
+
+func (s *Storage) GetPerson(ctx context.Context, person *models.Person) error {
+	if s.GetPersonErr {
+		return exampleErr
+	}
+	if s.GetPersonNotFoundErr {
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+func (s *Storage) AddPerson(ctx context.Context, person *models.Person) error {
+	if s.AddPersonErr {
+		return exampleErr
+	}
+	return nil
+}
+
+func (s *Storage) ListPersonCast(ctx context.Context, personId int) ([]models.CastCredit, error) {
+	if s.ListPersonCastErr {
+		return nil, exampleErr
+	}
+	return []models.CastCredit{}, nil
+}
+
+func (s *Storage) ListPersonCrew(ctx context.Context, personId int) ([]models.CrewCredit, error) {
+	if s.ListPersonCrewErr {
+		return nil, exampleErr
+	}
+	return []models.CrewCredit{}, nil
+}
+
+func (s *Storage) ListImages(ctx context.Context, movieId int) ([]*models.Image, error) {
+	if s.ListImagesErr {
+		return nil, exampleErr
+	}
+	if s.ListImagesEmpty {
+		return []*models.Image{}, nil
+	}
+	return []*models.Image{{MovieId: movieId, Type: models.PosterImage}}, nil
+}
+
+func (s *Storage) AddImages(ctx context.Context, images []*models.Image) error {
+	if s.AddImagesErr {
+		return exampleErr
+	}
+	return nil
+}
+
+func (s *Storage) ListVideos(ctx context.Context, movieId int) ([]*models.Video, error) {
+	if s.ListVideosErr {
+		return nil, exampleErr
+	}
+	if s.ListVideosEmpty {
+		return []*models.Video{}, nil
+	}
+	return []*models.Video{{MovieId: movieId}}, nil
+}
+
+func (s *Storage) AddVideos(ctx context.Context, videos []*models.Video) error {
+	if s.AddVideosErr {
+		return exampleErr
+	}
+	return nil
+}
+
+func (s *Storage) GetTranslation(ctx context.Context, translation *models.Translation) error {
+	if s.GetTranslationErr {
+		return exampleErr
+	}
+	if s.GetTranslationNotFoundErr {
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+func (s *Storage) ListTranslations(ctx context.Context, movieIds []int, language string) ([]models.Translation, error) {
+	if s.ListTranslationsErr {
+		return nil, exampleErr
+	}
+	return []models.Translation{}, nil
+}
+
+func (s *Storage) AddTranslations(ctx context.Context, translations []models.Translation) error {
+	if s.AddTranslationsErr {
+		return exampleErr
+	}
+	return nil
+}
+
+func (s *Storage) GetSnapshot(ctx context.Context, snapshot *models.Snapshot) error {
+	if s.GetSnapshotErr {
+		return exampleErr
+	}
+	return storage.ErrNotFound
+}
+
+func (s *Storage) SaveSnapshot(ctx context.Context, snapshot *models.Snapshot) error {
+	if s.SaveSnapshotErr {
+		return exampleErr
+	}
+	return nil
+}
+
+func (s *Storage) UpsertComment(ctx context.Context, comment *models.Comment) error {
+	if s.UpsertCommentErr {
+		return exampleErr
+	}
+	return nil
+}
+
+func (s *Storage) ExportMovies(ctx context.Context, params *models.PaginationParams) ([]models.TmdbMovie, error) {
+	if s.ExportMoviesErr {
+		return nil, exampleErr
+	}
+	return []models.TmdbMovie{}, nil
+}
+
+func (s *Storage) ExportCredits(ctx context.Context, params *models.PaginationParams) ([]models.Credit, error) {
+	if s.ExportCreditsErr {
+		return nil, exampleErr
+	}
+	return []models.Credit{}, nil
+}
+
+func (s *Storage) ExportComments(ctx context.Context, params *models.PaginationParams) ([]models.Comment, error) {
+	if s.ExportCommentsErr {
+		return nil, exampleErr
+	}
+	return []models.Comment{}, nil
+}
+
+func (s *Storage) ExportRatings(ctx context.Context, params *models.PaginationParams) ([]models.Rating, error) {
+	if s.ExportRatingsErr {
+		return nil, exampleErr
+	}
+	return []models.Rating{}, nil
+}
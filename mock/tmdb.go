@@ -1,30 +1,155 @@
 package mock
 
 import (
+	"context"
 	"net/http"
+	"sync/atomic"
 
 	"github.com/BarTar213/movies-service/models"
 )
 
 type Tmdb struct {
+	PingErr bool
+
+	GetMovieDetailsErr    bool
+	GetMovieDetailsStatus int
+
 	GetCreditsErr    bool
 	GetCreditsStatus int
 
 	GetTrendingMoviesErr    bool
 	GetTrendingMoviesStatus int
+
+	GetTopRatedMoviesErr    bool
+	GetTopRatedMoviesStatus int
+
+	GetNowPlayingMoviesErr    bool
+	GetNowPlayingMoviesStatus int
+
+	GetUpcomingMoviesErr    bool
+	GetUpcomingMoviesStatus int
+
+	GetPopularMoviesErr    bool
+	GetPopularMoviesStatus int
+
+	DiscoverMoviesErr    bool
+	DiscoverMoviesStatus int
+
+	GetPersonErr    bool
+	GetPersonStatus int
+
+	GetImagesErr    bool
+	GetImagesStatus int
+
+	GetVideosErr    bool
+	GetVideosStatus int
+
+	GetTranslationsErr    bool
+	GetTranslationsStatus int
+
+	getTranslationsCalls int32
 }
 
-func (t *Tmdb) GetCredits(movieId int, credit *models.Credit) (int, error) {
+func (t *Tmdb) Ping(ctx context.Context) error {
+	if t.PingErr {
+		return exampleErr
+	}
+	return nil
+}
+
+func (t *Tmdb) GetMovieDetails(ctx context.Context, id int, movie *models.TmdbMovie) (int, error) {
+	if t.GetMovieDetailsErr {
+		return t.GetMovieDetailsStatus, exampleErr
+	}
+	movie.Id = id
+	return http.StatusOK, nil
+}
+
+func (t *Tmdb) GetCredits(ctx context.Context, movieId int, credit *models.Credit) (int, error) {
 	if t.GetCreditsErr {
 		return t.GetCreditsStatus, exampleErr
 	}
 	return http.StatusOK, nil
 }
 
-func (t *Tmdb) GetTrendingMovies() ([]models.TmdbMovie, int, error) {
+func (t *Tmdb) GetTrendingMovies(ctx context.Context, params *models.TmdbListParams, meta *models.PageMeta) ([]models.TmdbMovie, int, error) {
 	if t.GetTrendingMoviesErr {
-		return nil,t.GetTrendingMoviesStatus, exampleErr
+		return nil, t.GetTrendingMoviesStatus, exampleErr
+	}
+	*meta = models.PageMeta{Page: params.Page, TotalPages: 1}
+	return []models.TmdbMovie{}, http.StatusOK, nil
+}
+
+func (t *Tmdb) GetTopRatedMovies(ctx context.Context, params *models.TmdbListParams, meta *models.PageMeta) ([]int, int, error) {
+	if t.GetTopRatedMoviesErr {
+		return nil, t.GetTopRatedMoviesStatus, exampleErr
+	}
+	*meta = models.PageMeta{Page: params.Page, TotalPages: 1}
+	return []int{}, http.StatusOK, nil
+}
+
+func (t *Tmdb) GetNowPlayingMovies(ctx context.Context, params *models.TmdbListParams, meta *models.PageMeta) ([]models.TmdbMovie, int, error) {
+	if t.GetNowPlayingMoviesErr {
+		return nil, t.GetNowPlayingMoviesStatus, exampleErr
+	}
+	*meta = models.PageMeta{Page: params.Page, TotalPages: 1}
+	return []models.TmdbMovie{}, http.StatusOK, nil
+}
+
+func (t *Tmdb) GetUpcomingMovies(ctx context.Context, params *models.TmdbListParams, meta *models.PageMeta) ([]models.TmdbMovie, int, error) {
+	if t.GetUpcomingMoviesErr {
+		return nil, t.GetUpcomingMoviesStatus, exampleErr
+	}
+	*meta = models.PageMeta{Page: params.Page, TotalPages: 1}
+	return []models.TmdbMovie{}, http.StatusOK, nil
+}
+
+func (t *Tmdb) GetPopularMovies(ctx context.Context, params *models.TmdbListParams, meta *models.PageMeta) ([]models.TmdbMovie, int, error) {
+	if t.GetPopularMoviesErr {
+		return nil, t.GetPopularMoviesStatus, exampleErr
 	}
+	*meta = models.PageMeta{Page: params.Page, TotalPages: 1}
 	return []models.TmdbMovie{}, http.StatusOK, nil
 }
 
+func (t *Tmdb) DiscoverMovies(ctx context.Context, params *models.DiscoverParams, meta *models.PageMeta) ([]models.TmdbMovie, int, error) {
+	if t.DiscoverMoviesErr {
+		return nil, t.DiscoverMoviesStatus, exampleErr
+	}
+	*meta = models.PageMeta{Page: params.Page, TotalPages: 1}
+	return []models.TmdbMovie{}, http.StatusOK, nil
+}
+
+func (t *Tmdb) GetPerson(ctx context.Context, personId int, person *models.Person) (int, error) {
+	if t.GetPersonErr {
+		return t.GetPersonStatus, exampleErr
+	}
+	return http.StatusOK, nil
+}
+
+func (t *Tmdb) GetImages(ctx context.Context, movieId int, images *models.Images) (int, error) {
+	if t.GetImagesErr {
+		return t.GetImagesStatus, exampleErr
+	}
+	return http.StatusOK, nil
+}
+
+func (t *Tmdb) GetVideos(ctx context.Context, movieId int, videos *models.Videos) (int, error) {
+	if t.GetVideosErr {
+		return t.GetVideosStatus, exampleErr
+	}
+	return http.StatusOK, nil
+}
+
+func (t *Tmdb) GetTranslations(ctx context.Context, movieId int) ([]models.Translation, int, error) {
+	atomic.AddInt32(&t.getTranslationsCalls, 1)
+	if t.GetTranslationsErr {
+		return nil, t.GetTranslationsStatus, exampleErr
+	}
+	return []models.Translation{}, http.StatusOK, nil
+}
+
+// GetTranslationsCalls returns how many times translations were requested
+func (t *Tmdb) GetTranslationsCalls() int {
+	return int(atomic.LoadInt32(&t.getTranslationsCalls))
+}
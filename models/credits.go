@@ -1,11 +1,14 @@
 package models
 
+import "time"
+
 type Credit struct {
-	_       struct{} `pg:",discard_unknown_columns"`
-	Id      int      `json:"id"`
-	MovieId int      `json:"-"`
-	Cast    []*Cast  `json:"cast" pg:"fk:credit_id"`
-	Crew    []*Crew  `json:"crew" pg:"fk:credit_id"`
+	_         struct{}  `pg:",discard_unknown_columns"`
+	Id        int       `json:"id"`
+	MovieId   int       `json:"-"`
+	FetchedAt time.Time `json:"fetched_at"`
+	Cast      []*Cast   `json:"cast" pg:"fk:credit_id"`
+	Crew      []*Crew   `json:"crew" pg:"fk:credit_id"`
 }
 
 type Cast struct {
@@ -28,3 +31,17 @@ type Crew struct {
 	Name        string `json:"name"`
 	ProfilePath string `json:"profile_path"`
 }
+
+type CreditsParams struct {
+	MaxOrder   *int   `form:"max_order"`
+	Department string `form:"department"`
+	Job        string `form:"job"`
+	Summary    bool   `form:"summary"`
+}
+
+type CreditsSummary struct {
+	Directors []*Crew `json:"directors"`
+	Writers   []*Crew `json:"writers"`
+	Composers []*Crew `json:"composers"`
+	Cast      []*Cast `json:"cast"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+const (
+	BackdropImage = "backdrop"
+	PosterImage   = "poster"
+	LogoImage     = "logo"
+)
+
+type Image struct {
+	tableName   struct{} `pg:"movie_images,discard_unknown_columns"`
+	MovieId     int      `json:"-" pg:",pk"`
+	FilePath    string   `json:"file_path" pg:",pk"`
+	Type        string   `json:"-"`
+	Language    string   `json:"iso_639_1" pg:"iso_639_1,use_zero"`
+	AspectRatio float32  `json:"aspect_ratio" pg:",use_zero"`
+	Height      int      `json:"height" pg:",use_zero"`
+	Width       int      `json:"width" pg:",use_zero"`
+	VoteAverage float32  `json:"vote_average" pg:",use_zero"`
+	VoteCount   int      `json:"vote_count" pg:",use_zero"`
+	Url         string   `json:"url" pg:"-"`
+}
+
+type Images struct {
+	Id        int      `json:"id"`
+	Backdrops []*Image `json:"backdrops"`
+	Posters   []*Image `json:"posters"`
+	Logos     []*Image `json:"logos"`
+}
+
+type Video struct {
+	tableName   struct{}  `pg:"movie_videos,discard_unknown_columns"`
+	Id          string    `json:"id" pg:",pk"`
+	MovieId     int       `json:"-"`
+	Language    string    `json:"iso_639_1" pg:"iso_639_1,use_zero"`
+	Country     string    `json:"iso_3166_1" pg:"iso_3166_1,use_zero"`
+	Key         string    `json:"key"`
+	Name        string    `json:"name" pg:",use_zero"`
+	Site        string    `json:"site"`
+	Size        int       `json:"size" pg:",use_zero"`
+	Type        string    `json:"type" pg:",use_zero"`
+	Official    bool      `json:"official" pg:",use_zero"`
+	PublishedAt time.Time `json:"published_at"`
+	Url         string    `json:"url" pg:"-"`
+}
+
+type Videos struct {
+	Id      int      `json:"id"`
+	Results []*Video `json:"results"`
+}
+
+type MediaParams struct {
+	Language string `form:"language"`
+}
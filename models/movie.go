@@ -28,6 +28,7 @@ type Movie struct {
 	Companies        []*Company  `json:"production_companies" pg:",many2many:movie_companies"`
 	Genres           []*Genre    `json:"genres" pg:",many2many:movie_genres"`
 	Languages        []*Language `json:"spoken_languages" pg:",many2many:movie_languages"`
+	Provider         string      `json:"provider"`
 }
 
 func (m *Movie) Reset() {
@@ -54,6 +55,7 @@ func (m *Movie) Reset() {
 	m.Countries = nil
 	m.Genres = nil
 	m.Languages = nil
+	m.Provider = emptyStr
 }
 
 type Country struct {
@@ -5,3 +5,22 @@ type PaginationParams struct {
 	Offset  int    `form:"offset,default=0"`
 	Limit   int    `form:"limit,default=50"`
 }
+
+type TmdbListParams struct {
+	Window string `form:"window,default=day"`
+	Page   int    `form:"page,default=1"`
+	Region string `form:"region"`
+}
+
+type PageMeta struct {
+	Page         int `json:"page"`
+	TotalPages   int `json:"total_pages"`
+	TotalResults int `json:"total_results"`
+}
+
+type DiscoverParams struct {
+	TmdbListParams
+	SortBy string `form:"sort_by"`
+	Genres string `form:"genres"`
+	Year   int    `form:"year"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+type Person struct {
+	tableName          struct{} `pg:"people,discard_unknown_columns"`
+	Id                 int      `json:"id"`
+	Name               string   `json:"name" pg:",use_zero"`
+	Biography          string   `json:"biography" pg:",use_zero"`
+	Birthday           string   `json:"birthday" pg:",use_zero"`
+	Deathday           string   `json:"deathday" pg:",use_zero"`
+	PlaceOfBirth       string   `json:"place_of_birth" pg:",use_zero"`
+	Gender             int      `json:"gender" pg:",use_zero"`
+	KnownForDepartment string   `json:"known_for_department" pg:",use_zero"`
+	Homepage           string   `json:"homepage" pg:",use_zero"`
+	ImdbId             string   `json:"imdb_id" pg:",use_zero"`
+	Popularity         float32  `json:"popularity" pg:",use_zero"`
+	ProfilePath        string   `json:"profile_path" pg:",use_zero"`
+}
+
+type CastCredit struct {
+	MovieId     int       `json:"movie_id"`
+	Title       string    `json:"title"`
+	PosterPath  string    `json:"poster_path"`
+	ReleaseDate time.Time `json:"release_date"`
+	VoteAverage float32   `json:"vote_average"`
+	Character   string    `json:"character"`
+	Order       int       `json:"order"`
+}
+
+type CrewCredit struct {
+	MovieId     int       `json:"movie_id"`
+	Title       string    `json:"title"`
+	PosterPath  string    `json:"poster_path"`
+	ReleaseDate time.Time `json:"release_date"`
+	VoteAverage float32   `json:"vote_average"`
+	Department  string    `json:"-"`
+	Job         string    `json:"-"`
+}
+
+// Filmography groups crew credits by department and then by job
+type Filmography struct {
+	Cast []CastCredit                       `json:"cast"`
+	Crew map[string]map[string][]CrewCredit `json:"crew"`
+}
+
+type MovieFilters struct {
+	Title    string `form:"title"`
+	CastId   int    `form:"cast"`
+	CrewId   int    `form:"crew"`
+	Language string `form:"-"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+const (
+	PeriodAllTime    = "all_time"
+	PeriodThisYear   = "this_year"
+	PeriodLast30Days = "last_30_days"
+)
+
+// RankingPeriods lists periods for which community rankings are materialised
+var RankingPeriods = []string{PeriodAllTime, PeriodThisYear, PeriodLast30Days}
+
+// PeriodStart returns time from which ratings are taken into account in given period
+func PeriodStart(period string, now time.Time) time.Time {
+	switch period {
+	case PeriodThisYear:
+		return time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, now.Location())
+	case PeriodLast30Days:
+		return now.AddDate(0, 0, -30)
+	}
+	return time.Unix(0, 0)
+}
+
+type CommunityRanking struct {
+	tableName   struct{} `pg:"community_rankings,discard_unknown_columns"`
+	Period      string   `pg:",pk"`
+	MovieId     int      `pg:",pk"`
+	Score       float64  `pg:",use_zero"`
+	VoteCount   int      `pg:",use_zero"`
+	VoteAverage float64  `pg:",use_zero"`
+	Likes       int      `pg:",use_zero"`
+	UpdatedAt   time.Time
+}
+
+type RankedMovie struct {
+	MoviePreview
+	CommunityScore   float64 `json:"community_score"`
+	CommunityVotes   int     `json:"community_votes"`
+	CommunityAverage float64 `json:"community_average"`
+	Likes            int     `json:"likes"`
+}
+
+type CommunityRankingParams struct {
+	Period  string `form:"period,default=all_time"`
+	GenreId int    `form:"genre"`
+	Page    int    `form:"page,default=1"`
+}
@@ -1,7 +1,40 @@
 package models
 
+// codes of errors, they are part of api and shouldn't change
+const (
+	CodeInvalidParam       = "invalid_param"
+	CodeInvalidQuery       = "invalid_query"
+	CodeInvalidBody        = "invalid_body"
+	CodeInvalidAccount     = "invalid_account"
+	CodeForbidden          = "forbidden"
+	CodeNotFound           = "not_found"
+	CodeRouteNotFound      = "route_not_found"
+	CodeAlreadyExists      = "already_exists"
+	CodeConflict           = "conflict"
+	CodeStorageUnavailable = "storage_unavailable"
+	CodeStorageError       = "storage_error"
+	CodeApiUnavailable     = "api_unavailable"
+	CodeApiError           = "api_error"
+)
+
 type Response struct {
-	Data  interface{} `json:"data,omitempty"`
-	Error string      `json:"error,omitempty"`
-	Meta  interface{} `json:"meta,omitempty"`
+	Data interface{} `json:"data,omitempty"`
+	// Deprecated: message of ErrorDetails kept for older clients, it will be removed in the next release
+	Error        string        `json:"error,omitempty"`
+	ErrorDetails *ErrorDetails `json:"error_details,omitempty"`
+	Meta         interface{}   `json:"meta,omitempty"`
+}
+
+// ErrorDetails describes why request failed, code is meant for programs and message for people
+type ErrorDetails struct {
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	Fields    []FieldError `json:"fields,omitempty"`
+	RequestId string       `json:"request_id,omitempty"`
+}
+
+// FieldError tells which field of request was rejected, reason is name of the failed rule with its parameter
+type FieldError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
 }
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+type Snapshot struct {
+	tableName struct{}  `pg:"tmdb_snapshots,discard_unknown_columns"`
+	Key       string    `pg:",pk"`
+	Data      []byte    `pg:"type:jsonb"`
+	UpdatedAt time.Time `pg:",use_zero"`
+}
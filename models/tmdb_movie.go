@@ -27,6 +27,7 @@ type TmdbMovie struct {
 	Companies        []*Company  `json:"production_companies" pg:",many2many:movie_companies,fk:movie_id"`
 	Genres           []*Genre    `json:"genres" pg:",many2many:movie_genres,fk:movie_id"`
 	Languages        []*Language `json:"spoken_languages" pg:",many2many:movie_languages,fk:movie_id"`
+	Provider         string      `json:"-" pg:",use_zero"`
 }
 
 type Time struct {
@@ -44,6 +45,10 @@ func (t *Time) UnmarshalJSON(data []byte) error {
 	// Fractional seconds are handled implicitly by Parse.
 	var err error
 	tt, err := time.Parse(`"`+"2006-01-02"+`"`, string(data))
+	if err != nil {
+		// Time marshalled by this service, e.g. cached TMDB responses
+		tt, err = time.Parse(`"`+time.RFC3339Nano+`"`, string(data))
+	}
 	*t = Time{tt}
 	return err
 }
@@ -0,0 +1,11 @@
+package models
+
+type Translation struct {
+	tableName struct{} `pg:"movie_translations,discard_unknown_columns"`
+	MovieId   int      `json:"movie_id" pg:",pk"`
+	Language  string   `json:"iso_639_1" pg:"iso_639_1,pk"`
+	Country   string   `json:"iso_3166_1" pg:"iso_3166_1,use_zero"`
+	Title     string   `json:"title" pg:",use_zero"`
+	Overview  string   `json:"overview" pg:",use_zero"`
+	Tagline   string   `json:"tagline" pg:",use_zero"`
+}
@@ -0,0 +1,379 @@
+package provider
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BarTar213/movies-service/models"
+)
+
+const (
+	cataloguePageSize = 20
+	//movies released within this period are considered playing now
+	nowPlayingPeriod = 6 * 7 * 24 * time.Hour
+
+	dateLayout = "2006-01-02"
+)
+
+// Catalogue serves movies from local JSON or CSV file for offline environments,
+// JSON file holds array of TMDB movies optionally with credits, CSV file holds movie details only
+type Catalogue struct {
+	movies  []*catalogueEntry
+	byId    map[int]*catalogueEntry
+	byImdb  map[string]*catalogueEntry
+	nowFunc func() time.Time
+}
+
+type catalogueEntry struct {
+	models.TmdbMovie
+	Credits *models.Credit `json:"credits"`
+}
+
+func NewCatalogue(path string) (*Catalogue, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []*catalogueEntry
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.NewDecoder(file).Decode(&entries)
+	case ".csv":
+		entries, err = readCsvCatalogue(file)
+	default:
+		err = fmt.Errorf("unsupported catalogue format %s", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("provider: catalogue %s: %w", path, err)
+	}
+
+	return newCatalogue(entries), nil
+}
+
+func newCatalogue(entries []*catalogueEntry) *Catalogue {
+	c := &Catalogue{
+		movies:  entries,
+		byId:    make(map[int]*catalogueEntry, len(entries)),
+		byImdb:  make(map[string]*catalogueEntry, len(entries)),
+		nowFunc: time.Now,
+	}
+	for _, entry := range entries {
+		if entry.Id > 0 {
+			c.byId[entry.Id] = entry
+		}
+		if len(entry.ImdbId) > 0 {
+			c.byImdb[entry.ImdbId] = entry
+		}
+	}
+
+	return c
+}
+
+func (c *Catalogue) Name() string {
+	return CatalogueName
+}
+
+func (c *Catalogue) GetMovie(ctx context.Context, movie *models.TmdbMovie) (int, error) {
+	entry, ok := c.byId[movie.Id]
+	if !ok {
+		entry, ok = c.byImdb[movie.ImdbId]
+	}
+	if !ok {
+		return http.StatusNotFound, nil
+	}
+
+	*movie = entry.TmdbMovie
+	return http.StatusOK, nil
+}
+
+func (c *Catalogue) GetCredits(ctx context.Context, movieId int, credit *models.Credit) (int, error) {
+	entry, ok := c.byId[movieId]
+	if !ok || entry.Credits == nil {
+		return http.StatusNotFound, nil
+	}
+
+	*credit = *entry.Credits
+	return http.StatusOK, nil
+}
+
+// serves lists computed from catalogue, region and trending window aren't taken into account
+func (c *Catalogue) ListMovies(ctx context.Context, list string, params *models.TmdbListParams, meta *models.PageMeta) ([]models.TmdbMovie, int, error) {
+	now := c.nowFunc()
+	movies := make([]models.TmdbMovie, 0)
+	for _, entry := range c.movies {
+		released := entry.ReleaseDate.Time
+		switch list {
+		case ListUpcoming:
+			if !released.After(now) {
+				continue
+			}
+		case ListNowPlaying:
+			if released.After(now) || now.Sub(released) > nowPlayingPeriod {
+				continue
+			}
+		case ListPopular, ListTopRated, ListTrending:
+		default:
+			return nil, http.StatusNotImplemented, ErrNotSupported
+		}
+		movies = append(movies, entry.TmdbMovie)
+	}
+
+	sort.SliceStable(movies, func(i, j int) bool {
+		switch list {
+		case ListUpcoming:
+			return movies[i].ReleaseDate.Before(movies[j].ReleaseDate.Time)
+		case ListNowPlaying:
+			return movies[i].ReleaseDate.After(movies[j].ReleaseDate.Time)
+		case ListTopRated:
+			return movies[i].VoteAverage > movies[j].VoteAverage
+		}
+		return movies[i].Popularity > movies[j].Popularity
+	})
+
+	return cataloguePage(movies, params.Page, meta), http.StatusOK, nil
+}
+
+// filters catalogue by year and genres and sorts it like TMDB discover, movies are sorted by popularity by default
+func (c *Catalogue) DiscoverMovies(ctx context.Context, params *models.DiscoverParams, meta *models.PageMeta) ([]models.TmdbMovie, int, error) {
+	movies := make([]models.TmdbMovie, 0)
+	for _, entry := range c.movies {
+		if params.Year > 0 && entry.ReleaseDate.Year() != params.Year {
+			continue
+		}
+		if !hasGenres(&entry.TmdbMovie, params.Genres) {
+			continue
+		}
+		movies = append(movies, entry.TmdbMovie)
+	}
+
+	field, direction := "popularity", "desc"
+	if i := strings.LastIndex(params.SortBy, "."); i >= 0 {
+		field, direction = params.SortBy[:i], params.SortBy[i+1:]
+	}
+	sort.SliceStable(movies, func(i, j int) bool {
+		if direction == "asc" {
+			return lessBy(field, &movies[i], &movies[j])
+		}
+		return lessBy(field, &movies[j], &movies[i])
+	})
+
+	return cataloguePage(movies, params.Page, meta), http.StatusOK, nil
+}
+
+// genres are ids separated with comma (all of them) or pipe (any of them)
+func hasGenres(movie *models.TmdbMovie, genres string) bool {
+	if len(genres) == 0 {
+		return true
+	}
+	ids := make(map[string]bool, len(movie.Genres))
+	for _, genre := range movie.Genres {
+		ids[strconv.Itoa(genre.Id)] = true
+	}
+
+	if strings.Contains(genres, "|") {
+		for _, id := range strings.Split(genres, "|") {
+			if ids[id] {
+				return true
+			}
+		}
+		return false
+	}
+	for _, id := range strings.Split(genres, ",") {
+		if !ids[id] {
+			return false
+		}
+	}
+	return true
+}
+
+func lessBy(field string, a, b *models.TmdbMovie) bool {
+	switch field {
+	case "release_date":
+		return a.ReleaseDate.Before(b.ReleaseDate.Time)
+	case "revenue":
+		return a.Revenue < b.Revenue
+	case "vote_average":
+		return a.VoteAverage < b.VoteAverage
+	case "vote_count":
+		return a.VoteCount < b.VoteCount
+	case "original_title":
+		return a.OriginalTitle < b.OriginalTitle
+	}
+	return a.Popularity < b.Popularity
+}
+
+// returns page of sorted movies and fills meta
+func cataloguePage(movies []models.TmdbMovie, page int, meta *models.PageMeta) []models.TmdbMovie {
+	*meta = models.PageMeta{
+		Page:         page,
+		TotalPages:   (len(movies) + cataloguePageSize - 1) / cataloguePageSize,
+		TotalResults: len(movies),
+	}
+	start := (page - 1) * cataloguePageSize
+	if start >= len(movies) {
+		return []models.TmdbMovie{}
+	}
+	end := start + cataloguePageSize
+	if end > len(movies) {
+		end = len(movies)
+	}
+
+	return movies[start:end]
+}
+
+// reads CSV with header row, columns are named after TMDB json fields,
+// genres are listed as id:name pairs separated with pipe, e.g. 18:Drama|35:Comedy
+func readCsvCatalogue(r io.Reader) ([]*catalogueEntry, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.ToLower(name))] = i
+	}
+
+	entries := make([]*catalogueEntry, 0)
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		entry, err := csvEntry(columns, record)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func csvEntry(columns map[string]int, values []string) (*catalogueEntry, error) {
+	record := &csvRecord{columns: columns, values: values}
+
+	entry := &catalogueEntry{}
+	movie := &entry.TmdbMovie
+	movie.Id = int(record.int("id"))
+	movie.ImdbId = record.string("imdb_id")
+	movie.Title = record.string("title")
+	movie.OriginalTitle = record.string("original_title")
+	movie.OriginalLanguage = record.string("original_language")
+	movie.Overview = record.string("overview")
+	movie.Tagline = record.string("tagline")
+	movie.PosterPath = record.string("poster_path")
+	movie.BackdropPath = record.string("backdrop_path")
+	movie.Homepage = record.string("homepage")
+	movie.Status = record.string("status")
+	movie.Runtime = int(record.int("runtime"))
+	movie.Budget = record.int("budget")
+	movie.Revenue = record.int("revenue")
+	movie.Popularity = float32(record.float("popularity"))
+	movie.VoteAverage = float32(record.float("vote_average"))
+	movie.ReleaseDate = models.Time{Time: record.date("release_date")}
+	movie.Genres = record.genres("genres")
+	if record.err != nil {
+		return nil, record.err
+	}
+
+	if movie.Id == 0 && len(movie.ImdbId) == 0 {
+		return nil, fmt.Errorf("id or imdb_id is required")
+	}
+	return entry, nil
+}
+
+// csvRecord reads typed values of named columns, the first parse error is kept in err
+type csvRecord struct {
+	columns map[string]int
+	values  []string
+	err     error
+}
+
+func (r *csvRecord) string(name string) string {
+	i, ok := r.columns[name]
+	if !ok || i >= len(r.values) {
+		return ""
+	}
+	return strings.TrimSpace(r.values[i])
+}
+
+func (r *csvRecord) int(name string) int64 {
+	value := r.string(name)
+	if len(value) == 0 {
+		return 0
+	}
+	i, err := strconv.ParseInt(value, 10, 64)
+	r.fail(name, err)
+	return i
+}
+
+func (r *csvRecord) float(name string) float64 {
+	value := r.string(name)
+	if len(value) == 0 {
+		return 0
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	r.fail(name, err)
+	return f
+}
+
+func (r *csvRecord) date(name string) time.Time {
+	value := r.string(name)
+	if len(value) == 0 {
+		return time.Time{}
+	}
+	t, err := time.Parse(dateLayout, value)
+	r.fail(name, err)
+	return t
+}
+
+func (r *csvRecord) genres(name string) []*models.Genre {
+	value := r.string(name)
+	if len(value) == 0 {
+		return nil
+	}
+	genres, err := parseGenres(value)
+	r.fail(name, err)
+	return genres
+}
+
+func (r *csvRecord) fail(name string, err error) {
+	if err != nil && r.err == nil {
+		r.err = fmt.Errorf("%s: %w", name, err)
+	}
+}
+
+func parseGenres(value string) ([]*models.Genre, error) {
+	genres := make([]*models.Genre, 0)
+	for _, pair := range strings.Split(value, "|") {
+		parts := strings.SplitN(pair, ":", 2)
+		id, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+		if err != nil {
+			return nil, err
+		}
+		genre := &models.Genre{Id: id}
+		if len(parts) == 2 {
+			genre.Name = strings.TrimSpace(parts[1])
+		}
+		genres = append(genres, genre)
+	}
+
+	return genres, nil
+}
@@ -0,0 +1,198 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BarTar213/movies-service/models"
+)
+
+const csvCatalogue = `id,imdb_id,title,release_date,runtime,popularity,vote_average,genres
+1,tt1,Old,2000-01-01,120,10.5,8.1,18:Drama|35:Comedy
+2,tt2,New,2020-06-10,90,50,6.2,
+3,,Upcoming,2020-08-01,,5,,
+`
+
+func TestReadCsvCatalogue(t *testing.T) {
+	entries, err := readCsvCatalogue(strings.NewReader(csvCatalogue))
+	if err != nil {
+		t.Fatalf("readCsvCatalogue() err = %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("readCsvCatalogue() entries = %d, want 3", len(entries))
+	}
+
+	movie := entries[0].TmdbMovie
+	if movie.Id != 1 || movie.ImdbId != "tt1" || movie.Runtime != 120 || movie.VoteAverage != 8.1 {
+		t.Errorf("readCsvCatalogue() movie = %+v", movie)
+	}
+	if len(movie.Genres) != 2 || movie.Genres[1].Id != 35 || movie.Genres[1].Name != "Comedy" {
+		t.Errorf("readCsvCatalogue() genres = %+v", movie.Genres)
+	}
+	if movie.ReleaseDate.Year() != 2000 {
+		t.Errorf("readCsvCatalogue() release date = %s", movie.ReleaseDate)
+	}
+}
+
+func TestReadCsvCatalogue_invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		csv  string
+	}{
+		{
+			name: "negative_invalid_runtime",
+			csv:  "id,runtime\n1,long\n",
+		},
+		{
+			name: "negative_missing_ids",
+			csv:  "id,imdb_id,title\n,,title\n",
+		},
+		{
+			name: "negative_invalid_genres",
+			csv:  "id,genres\n1,Drama\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readCsvCatalogue(strings.NewReader(tt.csv))
+			if err == nil {
+				t.Error("readCsvCatalogue() expected error")
+			}
+		})
+	}
+}
+
+func TestCatalogue_GetMovie(t *testing.T) {
+	entries, _ := readCsvCatalogue(strings.NewReader(csvCatalogue))
+	catalogue := newCatalogue(entries)
+
+	movie := &models.TmdbMovie{ImdbId: "tt2"}
+	status, err := catalogue.GetMovie(context.Background(), movie)
+	if err != nil || status != http.StatusOK || movie.Title != "New" {
+		t.Errorf("GetMovie() status = %d, err = %v, movie = %+v", status, err, movie)
+	}
+
+	status, _ = catalogue.GetMovie(context.Background(), &models.TmdbMovie{Id: 10})
+	if status != http.StatusNotFound {
+		t.Errorf("GetMovie() status = %d, want %d", status, http.StatusNotFound)
+	}
+}
+
+func TestCatalogue_ListMovies(t *testing.T) {
+	entries, _ := readCsvCatalogue(strings.NewReader(csvCatalogue))
+	catalogue := newCatalogue(entries)
+	catalogue.nowFunc = func() time.Time {
+		return time.Date(2020, time.July, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name    string
+		list    string
+		wantIds []int
+	}{
+		{
+			name:    "positive_popular",
+			list:    ListPopular,
+			wantIds: []int{2, 1, 3},
+		},
+		{
+			name:    "positive_top_rated",
+			list:    ListTopRated,
+			wantIds: []int{1, 2, 3},
+		},
+		{
+			name:    "positive_trending",
+			list:    ListTrending,
+			wantIds: []int{2, 1, 3},
+		},
+		{
+			name:    "positive_now_playing",
+			list:    ListNowPlaying,
+			wantIds: []int{2},
+		},
+		{
+			name:    "positive_upcoming",
+			list:    ListUpcoming,
+			wantIds: []int{3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta := &models.PageMeta{}
+			movies, status, err := catalogue.ListMovies(context.Background(), tt.list, &models.TmdbListParams{Page: 1}, meta)
+			if err != nil || status != http.StatusOK {
+				t.Fatalf("ListMovies() status = %d, err = %v", status, err)
+			}
+			if len(movies) != len(tt.wantIds) || meta.TotalResults != len(tt.wantIds) {
+				t.Fatalf("ListMovies() movies = %+v, meta = %+v", movies, meta)
+			}
+			for i, id := range tt.wantIds {
+				if movies[i].Id != id {
+					t.Errorf("ListMovies() movie %d id = %d, want %d", i, movies[i].Id, id)
+				}
+			}
+		})
+	}
+}
+
+func TestCatalogue_DiscoverMovies(t *testing.T) {
+	entries, _ := readCsvCatalogue(strings.NewReader(csvCatalogue))
+	catalogue := newCatalogue(entries)
+
+	tests := []struct {
+		name    string
+		params  models.DiscoverParams
+		wantIds []int
+	}{
+		{
+			name:    "positive_discover_by_popularity",
+			wantIds: []int{2, 1, 3},
+		},
+		{
+			name:    "positive_discover_year",
+			params:  models.DiscoverParams{Year: 2020},
+			wantIds: []int{2, 3},
+		},
+		{
+			name:    "positive_discover_all_genres",
+			params:  models.DiscoverParams{Genres: "18,35"},
+			wantIds: []int{1},
+		},
+		{
+			name:    "positive_discover_any_genre",
+			params:  models.DiscoverParams{Genres: "99|35"},
+			wantIds: []int{1},
+		},
+		{
+			name:    "negative_discover_missing_genre",
+			params:  models.DiscoverParams{Genres: "18,99"},
+			wantIds: []int{},
+		},
+		{
+			name:    "positive_discover_sort_ascending",
+			params:  models.DiscoverParams{SortBy: "vote_average.asc"},
+			wantIds: []int{3, 2, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.params.Page = 1
+			meta := &models.PageMeta{}
+			movies, status, err := catalogue.DiscoverMovies(context.Background(), &tt.params, meta)
+			if err != nil || status != http.StatusOK {
+				t.Fatalf("DiscoverMovies() status = %d, err = %v", status, err)
+			}
+			if len(movies) != len(tt.wantIds) || meta.TotalResults != len(tt.wantIds) {
+				t.Fatalf("DiscoverMovies() movies = %+v, meta = %+v", movies, meta)
+			}
+			for i, id := range tt.wantIds {
+				if movies[i].Id != id {
+					t.Errorf("DiscoverMovies() movie %d id = %d, want %d", i, movies[i].Id, id)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package provider
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/BarTar213/movies-service/models"
+)
+
+// Result is movie returned by single provider
+type Result struct {
+	Provider string
+	Movie    *models.TmdbMovie
+}
+
+// MergePolicy resolves fields which providers disagree on,
+// field is taken from its preferred provider when that provider knows it,
+// otherwise the first provider in chain order with non empty value wins
+type MergePolicy struct {
+	// preferred provider by json name of field, e.g. vote_average: omdb
+	preferred map[string]string
+}
+
+func NewMergePolicy(preferred map[string]string) *MergePolicy {
+	normalized := make(map[string]string, len(preferred))
+	for field, provider := range preferred {
+		normalized[strings.ToLower(field)] = strings.ToLower(provider)
+	}
+
+	return &MergePolicy{preferred: normalized}
+}
+
+// Merge returns movie merged from results given in chain order, provider of record is the first result
+func (m *MergePolicy) Merge(results []Result) *models.TmdbMovie {
+	merged := &models.TmdbMovie{}
+	if len(results) == 0 {
+		return merged
+	}
+
+	target := reflect.ValueOf(merged).Elem()
+	fields := target.Type()
+	for i := 0; i < fields.NumField(); i++ {
+		field := fields.Field(i)
+		name := jsonName(field)
+		if len(field.PkgPath) > 0 || name == "-" {
+			continue
+		}
+
+		value, ok := m.preferredValue(name, i, results)
+		if !ok {
+			value, ok = firstValue(i, results)
+		}
+		if ok {
+			target.Field(i).Set(value)
+		}
+	}
+	merged.Provider = results[0].Provider
+
+	return merged
+}
+
+func (m *MergePolicy) preferredValue(name string, field int, results []Result) (reflect.Value, bool) {
+	provider, ok := m.preferred[name]
+	if !ok {
+		return reflect.Value{}, false
+	}
+
+	for _, result := range results {
+		if result.Provider != provider {
+			continue
+		}
+		value := reflect.ValueOf(result.Movie).Elem().Field(field)
+		if !empty(value) {
+			return value, true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func firstValue(field int, results []Result) (reflect.Value, bool) {
+	for _, result := range results {
+		value := reflect.ValueOf(result.Movie).Elem().Field(field)
+		if !empty(value) {
+			return value, true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func empty(value reflect.Value) bool {
+	if value.Kind() == reflect.Slice {
+		return value.Len() == 0
+	}
+	return value.IsZero()
+}
+
+func jsonName(field reflect.StructField) string {
+	tag := field.Tag.Get("json")
+	if i := strings.Index(tag, ","); i >= 0 {
+		tag = tag[:i]
+	}
+	if len(tag) == 0 {
+		return strings.ToLower(field.Name)
+	}
+	return tag
+}
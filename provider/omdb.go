@@ -0,0 +1,143 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BarTar213/movies-service/config"
+	"github.com/BarTar213/movies-service/models"
+)
+
+const (
+	defaultOmdbUrl = "https://www.omdbapi.com"
+
+	omdbEmpty       = "N/A"
+	omdbDateLayout  = "02 Jan 2006"
+	omdbTrueMessage = "True"
+)
+
+// Omdb serves movie details by imdb id, OMDb doesn't provide credits with ids nor lists
+type Omdb struct {
+	HttpClient *http.Client
+	ApiKey     string
+	BaseUrl    string
+}
+
+type omdbMovie struct {
+	Response   string `json:"Response"`
+	ImdbId     string `json:"imdbID"`
+	Title      string `json:"Title"`
+	Released   string `json:"Released"`
+	Runtime    string `json:"Runtime"`
+	Plot       string `json:"Plot"`
+	ImdbRating string `json:"imdbRating"`
+	BoxOffice  string `json:"BoxOffice"`
+	Website    string `json:"Website"`
+}
+
+func NewOmdb(conf *config.Omdb, timeout time.Duration) *Omdb {
+	baseUrl := conf.Url
+	if len(baseUrl) == 0 {
+		baseUrl = defaultOmdbUrl
+	}
+
+	return &Omdb{
+		HttpClient: &http.Client{Timeout: timeout},
+		ApiKey:     conf.Key,
+		BaseUrl:    baseUrl,
+	}
+}
+
+func (o *Omdb) Name() string {
+	return OmdbName
+}
+
+func (o *Omdb) GetMovie(ctx context.Context, movie *models.TmdbMovie) (int, error) {
+	if len(movie.ImdbId) == 0 {
+		return http.StatusNotFound, nil
+	}
+
+	query := url.Values{}
+	query.Set("i", movie.ImdbId)
+	query.Set("apikey", o.ApiKey)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/?%s", o.BaseUrl, query.Encode()), nil)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	resp, err := o.HttpClient.Do(request)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, nil
+	}
+
+	response := &omdbMovie{}
+	err = json.NewDecoder(resp.Body).Decode(response)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	//OMDb responds with status OK also when movie wasn't found
+	if response.Response != omdbTrueMessage {
+		return http.StatusNotFound, nil
+	}
+
+	response.fill(movie)
+	return http.StatusOK, nil
+}
+
+func (o *Omdb) GetCredits(ctx context.Context, movieId int, credit *models.Credit) (int, error) {
+	return http.StatusNotImplemented, ErrNotSupported
+}
+
+func (o *Omdb) ListMovies(ctx context.Context, list string, params *models.TmdbListParams, meta *models.PageMeta) ([]models.TmdbMovie, int, error) {
+	return nil, http.StatusNotImplemented, ErrNotSupported
+}
+
+func (o *Omdb) DiscoverMovies(ctx context.Context, params *models.DiscoverParams, meta *models.PageMeta) ([]models.TmdbMovie, int, error) {
+	return nil, http.StatusNotImplemented, ErrNotSupported
+}
+
+// fills fields which OMDb shares with TMDB, values OMDb doesn't know are left empty
+func (m *omdbMovie) fill(movie *models.TmdbMovie) {
+	movie.ImdbId = m.ImdbId
+	movie.Title = omdbValue(m.Title)
+	movie.Overview = omdbValue(m.Plot)
+	movie.Homepage = omdbValue(m.Website)
+
+	released, err := time.Parse(omdbDateLayout, omdbValue(m.Released))
+	if err == nil {
+		movie.ReleaseDate = models.Time{Time: released}
+	}
+
+	runtime, err := strconv.Atoi(strings.TrimSuffix(omdbValue(m.Runtime), " min"))
+	if err == nil {
+		movie.Runtime = runtime
+	}
+
+	rating, err := strconv.ParseFloat(omdbValue(m.ImdbRating), 32)
+	if err == nil {
+		movie.VoteAverage = float32(rating)
+	}
+
+	revenue, err := strconv.ParseInt(strings.NewReplacer("$", "", ",", "").Replace(omdbValue(m.BoxOffice)), 10, 64)
+	if err == nil {
+		movie.Revenue = revenue
+	}
+}
+
+func omdbValue(value string) string {
+	if value == omdbEmpty {
+		return ""
+	}
+	return value
+}
@@ -0,0 +1,46 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/BarTar213/movies-service/config"
+	"github.com/BarTar213/movies-service/models"
+)
+
+func TestOmdb_GetMovie(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("i") != "tt0133093" {
+			_, _ = w.Write([]byte(`{"Response":"False","Error":"Movie not found!"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"Response":"True","imdbID":"tt0133093","Title":"The Matrix","Released":"31 Mar 1999",
+			"Runtime":"136 min","Plot":"A computer hacker...","imdbRating":"8.7","BoxOffice":"$172,076,928","Website":"N/A"}`))
+	}))
+	defer server.Close()
+
+	omdb := NewOmdb(&config.Omdb{Url: server.URL, Key: "key"}, time.Second)
+
+	movie := &models.TmdbMovie{Id: 603, ImdbId: "tt0133093"}
+	status, err := omdb.GetMovie(context.Background(), movie)
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("GetMovie() status = %d, err = %v", status, err)
+	}
+	if movie.Title != "The Matrix" || movie.Runtime != 136 || movie.VoteAverage != 8.7 ||
+		movie.Revenue != 172076928 || movie.Homepage != "" || movie.ReleaseDate.Year() != 1999 {
+		t.Errorf("GetMovie() movie = %+v", movie)
+	}
+
+	status, err = omdb.GetMovie(context.Background(), &models.TmdbMovie{ImdbId: "tt0"})
+	if err != nil || status != http.StatusNotFound {
+		t.Errorf("GetMovie() status = %d, err = %v, want not found", status, err)
+	}
+
+	status, _ = omdb.GetMovie(context.Background(), &models.TmdbMovie{Id: 603})
+	if status != http.StatusNotFound {
+		t.Errorf("GetMovie() without imdb id status = %d, want not found", status)
+	}
+}
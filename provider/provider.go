@@ -0,0 +1,173 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/BarTar213/movies-service/config"
+	"github.com/BarTar213/movies-service/models"
+	"github.com/BarTar213/movies-service/tmdb"
+)
+
+const (
+	TmdbName      = "tmdb"
+	OmdbName      = "omdb"
+	CatalogueName = "catalogue"
+
+	ListNowPlaying = "now_playing"
+	ListUpcoming   = "upcoming"
+	ListPopular    = "popular"
+	ListTopRated   = "top_rated"
+	ListTrending   = "trending"
+)
+
+var ErrNotSupported = errors.New("provider: operation not supported")
+
+// Provider is a source of movie metadata, calls follow tmdb.Client convention of returning response status,
+// operations which provider can't serve return ErrNotSupported
+type Provider interface {
+	Name() string
+	// GetMovie fills movie identified by its id or imdb id
+	GetMovie(ctx context.Context, movie *models.TmdbMovie) (int, error)
+	GetCredits(ctx context.Context, movieId int, credit *models.Credit) (int, error)
+	ListMovies(ctx context.Context, list string, params *models.TmdbListParams, meta *models.PageMeta) ([]models.TmdbMovie, int, error)
+	DiscoverMovies(ctx context.Context, params *models.DiscoverParams, meta *models.PageMeta) ([]models.TmdbMovie, int, error)
+}
+
+// Chain asks providers in order of priority, details of movie are merged from all providers which know it,
+// credits and lists are served by the first provider able to serve them
+type Chain struct {
+	providers []Provider
+	policy    *MergePolicy
+}
+
+func NewChain(policy *MergePolicy, providers ...Provider) *Chain {
+	return &Chain{
+		providers: providers,
+		policy:    policy,
+	}
+}
+
+// NewProviders builds chain of providers listed in config, TMDB is the only provider when none is configured
+func NewProviders(conf *config.Config, tmdbClient tmdb.Client) (*Chain, error) {
+	order := conf.Providers.Order
+	if len(order) == 0 {
+		order = []string{TmdbName}
+	}
+
+	providers := make([]Provider, 0, len(order))
+	for _, name := range order {
+		switch name {
+		case TmdbName:
+			providers = append(providers, NewTmdb(tmdbClient))
+		case OmdbName:
+			providers = append(providers, NewOmdb(&conf.Providers.Omdb, conf.Api.Timeout))
+		case CatalogueName:
+			catalogue, err := NewCatalogue(conf.Providers.Catalogue)
+			if err != nil {
+				return nil, err
+			}
+			providers = append(providers, catalogue)
+		default:
+			return nil, fmt.Errorf("provider: unknown provider %s", name)
+		}
+	}
+
+	return NewChain(NewMergePolicy(conf.Providers.Merge), providers...), nil
+}
+
+func (c *Chain) Name() string {
+	if len(c.providers) == 0 {
+		return ""
+	}
+	return c.providers[0].Name()
+}
+
+// GetMovie merges results of all providers, imdb id learned from one provider is passed to the next ones,
+// provider of record is the first provider which returned the movie
+func (c *Chain) GetMovie(ctx context.Context, movie *models.TmdbMovie) (int, error) {
+	results := make([]Result, 0, len(c.providers))
+	failure := &failure{status: http.StatusNotFound}
+
+	for _, p := range c.providers {
+		found := &models.TmdbMovie{Id: movie.Id, ImdbId: movie.ImdbId}
+		status, err := p.GetMovie(ctx, found)
+		if err != nil || status != http.StatusOK {
+			failure.add(status, err)
+			continue
+		}
+
+		results = append(results, Result{Provider: p.Name(), Movie: found})
+		if len(movie.ImdbId) == 0 {
+			movie.ImdbId = found.ImdbId
+		}
+	}
+	if len(results) == 0 {
+		return failure.status, failure.err
+	}
+
+	merged := c.policy.Merge(results)
+	merged.Id = movie.Id
+	*movie = *merged
+
+	return http.StatusOK, nil
+}
+
+func (c *Chain) GetCredits(ctx context.Context, movieId int, credit *models.Credit) (int, error) {
+	failure := &failure{status: http.StatusNotFound}
+	for _, p := range c.providers {
+		status, err := p.GetCredits(ctx, movieId, credit)
+		if err == nil && status == http.StatusOK {
+			return status, nil
+		}
+		failure.add(status, err)
+	}
+
+	return failure.status, failure.err
+}
+
+func (c *Chain) ListMovies(ctx context.Context, list string, params *models.TmdbListParams, meta *models.PageMeta) ([]models.TmdbMovie, int, error) {
+	return c.firstList(func(p Provider) ([]models.TmdbMovie, int, error) {
+		return p.ListMovies(ctx, list, params, meta)
+	})
+}
+
+func (c *Chain) DiscoverMovies(ctx context.Context, params *models.DiscoverParams, meta *models.PageMeta) ([]models.TmdbMovie, int, error) {
+	return c.firstList(func(p Provider) ([]models.TmdbMovie, int, error) {
+		return p.DiscoverMovies(ctx, params, meta)
+	})
+}
+
+// returns list of the first provider able to serve it, its movies are marked with provider name
+func (c *Chain) firstList(list func(p Provider) ([]models.TmdbMovie, int, error)) ([]models.TmdbMovie, int, error) {
+	failure := &failure{status: http.StatusNotFound}
+	for _, p := range c.providers {
+		movies, status, err := list(p)
+		if err == nil && status == http.StatusOK {
+			for i := range movies {
+				movies[i].Provider = p.Name()
+			}
+			return movies, status, nil
+		}
+		failure.add(status, err)
+	}
+
+	return nil, failure.status, failure.err
+}
+
+// failure keeps the first failure of provider which was able to serve the call,
+// providers which don't know the movie or don't support the call end up as not found
+type failure struct {
+	status int
+	err    error
+	failed bool
+}
+
+func (f *failure) add(status int, err error) {
+	if f.failed || errors.Is(err, ErrNotSupported) || (err == nil && status == http.StatusNotFound) {
+		return
+	}
+	f.status, f.err, f.failed = status, err, true
+}
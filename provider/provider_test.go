@@ -0,0 +1,177 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/BarTar213/movies-service/models"
+)
+
+type fakeProvider struct {
+	name   string
+	movie  *models.TmdbMovie
+	status int
+	err    error
+	calls  int
+}
+
+func (f *fakeProvider) Name() string {
+	return f.name
+}
+
+func (f *fakeProvider) GetMovie(ctx context.Context, movie *models.TmdbMovie) (int, error) {
+	f.calls++
+	if f.err != nil || f.status != http.StatusOK {
+		return f.status, f.err
+	}
+	imdbId := movie.ImdbId
+	*movie = *f.movie
+	if len(movie.ImdbId) == 0 {
+		movie.ImdbId = imdbId
+	}
+	return http.StatusOK, nil
+}
+
+func (f *fakeProvider) GetCredits(ctx context.Context, movieId int, credit *models.Credit) (int, error) {
+	f.calls++
+	if f.err != nil || f.status != http.StatusOK {
+		return f.status, f.err
+	}
+	credit.Id = movieId
+	return http.StatusOK, nil
+}
+
+func (f *fakeProvider) ListMovies(ctx context.Context, list string, params *models.TmdbListParams, meta *models.PageMeta) ([]models.TmdbMovie, int, error) {
+	f.calls++
+	if f.err != nil || f.status != http.StatusOK {
+		return nil, f.status, f.err
+	}
+	return []models.TmdbMovie{*f.movie}, http.StatusOK, nil
+}
+
+func (f *fakeProvider) DiscoverMovies(ctx context.Context, params *models.DiscoverParams, meta *models.PageMeta) ([]models.TmdbMovie, int, error) {
+	return f.ListMovies(ctx, "", &params.TmdbListParams, meta)
+}
+
+func TestChain_GetMovie(t *testing.T) {
+	primary := &fakeProvider{
+		name:   TmdbName,
+		status: http.StatusOK,
+		movie:  &models.TmdbMovie{Title: "Tmdb title", ImdbId: "tt1", VoteAverage: 7.5},
+	}
+	secondary := &fakeProvider{
+		name:   OmdbName,
+		status: http.StatusOK,
+		movie:  &models.TmdbMovie{Title: "Omdb title", Overview: "plot", VoteAverage: 8.1},
+	}
+
+	tests := []struct {
+		name      string
+		preferred map[string]string
+		want      models.TmdbMovie
+	}{
+		{
+			name: "positive_primary_wins_empty_fields_filled",
+			want: models.TmdbMovie{Id: 1, Title: "Tmdb title", ImdbId: "tt1", Overview: "plot", VoteAverage: 7.5, Provider: TmdbName},
+		},
+		{
+			name:      "positive_preferred_provider_wins",
+			preferred: map[string]string{"vote_average": "OMDB"},
+			want:      models.TmdbMovie{Id: 1, Title: "Tmdb title", ImdbId: "tt1", Overview: "plot", VoteAverage: 8.1, Provider: TmdbName},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := NewChain(NewMergePolicy(tt.preferred), primary, secondary)
+
+			movie := &models.TmdbMovie{Id: 1}
+			status, err := chain.GetMovie(context.Background(), movie)
+			if err != nil || status != http.StatusOK {
+				t.Fatalf("GetMovie() status = %d, err = %v", status, err)
+			}
+			if movie.Title != tt.want.Title || movie.ImdbId != tt.want.ImdbId || movie.Overview != tt.want.Overview ||
+				movie.VoteAverage != tt.want.VoteAverage || movie.Provider != tt.want.Provider || movie.Id != tt.want.Id {
+				t.Errorf("GetMovie() = %+v, want %+v", movie, tt.want)
+			}
+		})
+	}
+}
+
+func TestChain_GetMovie_fallback(t *testing.T) {
+	unavailable := &fakeProvider{name: TmdbName, status: http.StatusServiceUnavailable}
+	catalogue := &fakeProvider{name: CatalogueName, status: http.StatusOK, movie: &models.TmdbMovie{Title: "title"}}
+
+	movie := &models.TmdbMovie{Id: 1}
+	status, err := NewChain(NewMergePolicy(nil), unavailable, catalogue).GetMovie(context.Background(), movie)
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("GetMovie() status = %d, err = %v", status, err)
+	}
+	if movie.Provider != CatalogueName {
+		t.Errorf("GetMovie() provider = %s, want %s", movie.Provider, CatalogueName)
+	}
+
+	notFound := &fakeProvider{name: CatalogueName, status: http.StatusNotFound}
+	status, _ = NewChain(NewMergePolicy(nil), unavailable, notFound).GetMovie(context.Background(), &models.TmdbMovie{Id: 1})
+	if status != http.StatusServiceUnavailable {
+		t.Errorf("GetMovie() status = %d, want %d", status, http.StatusServiceUnavailable)
+	}
+}
+
+func TestChain_GetCredits(t *testing.T) {
+	tests := []struct {
+		name       string
+		providers  []Provider
+		wantStatus int
+		wantErr    bool
+	}{
+		{
+			name: "positive_not_supported_skipped",
+			providers: []Provider{
+				&fakeProvider{name: OmdbName, status: http.StatusNotImplemented, err: ErrNotSupported},
+				&fakeProvider{name: TmdbName, status: http.StatusOK},
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "negative_not_found_anywhere",
+			providers: []Provider{
+				&fakeProvider{name: OmdbName, status: http.StatusNotImplemented, err: ErrNotSupported},
+				&fakeProvider{name: CatalogueName, status: http.StatusNotFound},
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "negative_first_failure_returned",
+			providers: []Provider{
+				&fakeProvider{name: TmdbName, status: http.StatusInternalServerError, err: errors.New("error")},
+				&fakeProvider{name: CatalogueName, status: http.StatusNotFound},
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, err := NewChain(NewMergePolicy(nil), tt.providers...).GetCredits(context.Background(), 1, &models.Credit{})
+			if status != tt.wantStatus || (err != nil) != tt.wantErr {
+				t.Errorf("GetCredits() status = %d, err = %v, want status %d", status, err, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestChain_ListMovies(t *testing.T) {
+	omdb := &fakeProvider{name: OmdbName, status: http.StatusNotImplemented, err: ErrNotSupported}
+	catalogue := &fakeProvider{name: CatalogueName, status: http.StatusOK, movie: &models.TmdbMovie{Id: 1}}
+
+	movies, status, err := NewChain(NewMergePolicy(nil), omdb, catalogue).
+		ListMovies(context.Background(), ListPopular, &models.TmdbListParams{Page: 1}, &models.PageMeta{})
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("ListMovies() status = %d, err = %v", status, err)
+	}
+	if len(movies) != 1 || movies[0].Provider != CatalogueName {
+		t.Errorf("ListMovies() = %+v", movies)
+	}
+}
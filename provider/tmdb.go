@@ -0,0 +1,62 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/BarTar213/movies-service/models"
+	"github.com/BarTar213/movies-service/tmdb"
+)
+
+// Tmdb serves movies by TMDB id through tmdb.Client
+type Tmdb struct {
+	client tmdb.Client
+}
+
+func NewTmdb(client tmdb.Client) *Tmdb {
+	return &Tmdb{client: client}
+}
+
+func (t *Tmdb) Name() string {
+	return TmdbName
+}
+
+func (t *Tmdb) GetMovie(ctx context.Context, movie *models.TmdbMovie) (int, error) {
+	if movie.Id <= 0 {
+		return http.StatusNotFound, nil
+	}
+	return t.client.GetMovieDetails(ctx, movie.Id, movie)
+}
+
+func (t *Tmdb) GetCredits(ctx context.Context, movieId int, credit *models.Credit) (int, error) {
+	return t.client.GetCredits(ctx, movieId, credit)
+}
+
+// TMDB top rated list holds only ids of movies, their details are expected to be read from storage
+func (t *Tmdb) ListMovies(ctx context.Context, list string, params *models.TmdbListParams, meta *models.PageMeta) ([]models.TmdbMovie, int, error) {
+	switch list {
+	case ListTrending:
+		return t.client.GetTrendingMovies(ctx, params, meta)
+	case ListTopRated:
+		ids, status, err := t.client.GetTopRatedMovies(ctx, params, meta)
+		if err != nil || status != http.StatusOK {
+			return nil, status, err
+		}
+		movies := make([]models.TmdbMovie, 0, len(ids))
+		for _, id := range ids {
+			movies = append(movies, models.TmdbMovie{Id: id})
+		}
+		return movies, status, nil
+	case ListNowPlaying:
+		return t.client.GetNowPlayingMovies(ctx, params, meta)
+	case ListUpcoming:
+		return t.client.GetUpcomingMovies(ctx, params, meta)
+	case ListPopular:
+		return t.client.GetPopularMovies(ctx, params, meta)
+	}
+	return nil, http.StatusNotImplemented, ErrNotSupported
+}
+
+func (t *Tmdb) DiscoverMovies(ctx context.Context, params *models.DiscoverParams, meta *models.PageMeta) ([]models.TmdbMovie, int, error) {
+	return t.client.DiscoverMovies(ctx, params, meta)
+}
@@ -1,13 +1,15 @@
 package storage
 
 import (
+	"context"
+
 	"github.com/BarTar213/movies-service/models"
 )
 
-func (p *Postgres) ListMovieComments(movieId int, params *models.PaginationParams) ([]models.Comment, error) {
+func (p *Postgres) ListMovieComments(ctx context.Context, movieId int, params *models.PaginationParams) ([]models.Comment, error) {
 	comments := make([]models.Comment, 0)
 
-	err := p.db.Model(&comments).
+	err := p.db.ModelContext(ctx, &comments).
 		Where("movie_id = ?", movieId).
 		ColumnExpr("comment.*").
 		ColumnExpr("count(lc.*) AS likes").
@@ -18,64 +20,68 @@ func (p *Postgres) ListMovieComments(movieId int, params *models.PaginationParam
 		Limit(params.Limit).
 		Select()
 
-	return comments, err
+	return comments, wrapError(err, commentsResource)
 }
 
-func (p *Postgres) ListLikedCommentsForMovie(movieID, userID int) ([]int, error) {
+func (p *Postgres) ListLikedCommentsForMovie(ctx context.Context, movieID, userID int) ([]int, error) {
 	ids := make([]int, 0)
 
-	err := p.db.Model((*models.LikedComment)(nil)).
+	err := p.db.ModelContext(ctx, (*models.LikedComment)(nil)).
 		Column("comment_id").
 		Where("c.movie_id = ?", movieID).
 		Where("liked_comment.user_id = ?", userID).
 		Join("LEFT JOIN comments c ON liked_comment.comment_id = c.id").
 		Select(&ids)
 
-	return ids, err
+	return ids, wrapError(err, likedCommentsResource)
 }
 
-func (p *Postgres) LikeComment(userId int, commentId int, comment *models.Comment) error {
-	_, err := p.db.ExecOne("INSERT INTO liked_comments (user_id, comment_id) VALUES (?, ?)", userId, commentId)
+func (p *Postgres) LikeComment(ctx context.Context, userId int, commentId int, comment *models.Comment) error {
+	_, err := p.db.ExecOneContext(ctx, "INSERT INTO liked_comments (user_id, comment_id) VALUES (?, ?)", userId, commentId)
 	if err != nil {
-		return err
+		return wrapError(err, likedCommentsResource)
 	}
 
 	comment.Id = commentId
-	err = p.db.Model(comment).
+	err = p.db.ModelContext(ctx, comment).
 		WherePK().
 		Select()
 
-	return err
+	return wrapError(err, commentsResource)
 }
 
-func (p *Postgres) DeleteCommentLike(userId int, commentId int) error {
-	_, err := p.db.ExecOne("DELETE FROM liked_comments WHERE user_id=? AND comment_id=?", userId, commentId)
+func (p *Postgres) DeleteCommentLike(ctx context.Context, userId int, commentId int) error {
+	_, err := p.db.ExecOneContext(ctx, "DELETE FROM liked_comments WHERE user_id=? AND comment_id=?", userId, commentId)
 
-	return err
+	return wrapError(err, likedCommentsResource)
 }
 
-func (p *Postgres) AddMovieComment(comment *models.Comment) error {
-	_, err := p.db.Model(comment).Returning(all).Insert()
+func (p *Postgres) AddMovieComment(ctx context.Context, comment *models.Comment) error {
+	_, err := p.db.ModelContext(ctx, comment).Returning(all).Insert()
 
-	return err
+	return wrapError(err, commentsResource)
 }
 
-func (p *Postgres) UpdateComment(comment *models.Comment) error {
-	_, err := p.db.Model(comment).
+func (p *Postgres) UpdateComment(ctx context.Context, comment *models.Comment) error {
+	_, err := p.db.ModelContext(ctx, comment).
 		WherePK().
 		Where("user_id = ?user_id").
 		Set("content = ?content, update_date = ?update_date").
 		Returning(all).
 		Update()
 
-	return err
+	return wrapError(err, commentsResource)
 }
 
-func (p *Postgres) DeleteComment(comment *models.Comment) error {
-	_, err := p.db.Model(comment).
+// DeleteComment reports whether comment of user was found and deleted
+func (p *Postgres) DeleteComment(ctx context.Context, comment *models.Comment) (bool, error) {
+	res, err := p.db.ModelContext(ctx, comment).
 		WherePK().
 		Where("user_id = ?user_id").
 		Delete()
+	if err != nil {
+		return false, wrapError(err, commentsResource)
+	}
 
-	return err
+	return res.RowsAffected() > 0, nil
 }
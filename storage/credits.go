@@ -2,39 +2,55 @@ package storage
 
 import (
 	"context"
-	"time"
 
 	"github.com/BarTar213/movies-service/models"
 	"github.com/go-pg/pg/v10"
 )
 
-func (p *Postgres) GetCredits(movieId int, credit *models.Credit) error {
-	return p.db.Model(credit).
+func (p *Postgres) GetCredits(ctx context.Context, movieId int, credit *models.Credit) error {
+	err := p.db.ModelContext(ctx, credit).
 		Where("movie_id = ?", movieId).
 		Relation("Cast").
 		Relation("Crew").
 		Select()
-}
 
-func (p *Postgres) AddCredits(credit *models.Credit) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	return wrapError(err, creditsResource)
+}
 
+func (p *Postgres) AddCredits(ctx context.Context, credit *models.Credit) error {
 	err := p.db.RunInTransaction(ctx, func(tx *pg.Tx) error {
-		_, err := tx.Model(credit).Insert()
+		_, err := tx.Model(credit).
+			OnConflict("(id) DO UPDATE").
+			Set("movie_id=?movie_id").
+			Set("fetched_at=?fetched_at").
+			Insert()
 		if err != nil {
 			return err
 		}
-		_, err = tx.Model(&credit.Cast).Insert()
+
+		_, err = tx.Model((*models.Cast)(nil)).Where("credit_id = ?", credit.Id).Delete()
 		if err != nil {
 			return err
 		}
-		_, err = tx.Model(&credit.Crew).Insert()
+		_, err = tx.Model((*models.Crew)(nil)).Where("credit_id = ?", credit.Id).Delete()
 		if err != nil {
 			return err
 		}
+
+		if len(credit.Cast) > 0 {
+			_, err = tx.Model(&credit.Cast).Insert()
+			if err != nil {
+				return err
+			}
+		}
+		if len(credit.Crew) > 0 {
+			_, err = tx.Model(&credit.Crew).Insert()
+			if err != nil {
+				return err
+			}
+		}
 		return nil
 	})
 
-	return err
+	return wrapError(err, creditsResource)
 }
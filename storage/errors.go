@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/go-pg/pg/v10"
+)
+
+// kinds of storage errors, check them with errors.Is
+var (
+	ErrNotFound            = errors.New("not found")
+	ErrAlreadyExists       = errors.New("already exists")
+	ErrForeignKeyViolation = errors.New("foreign key violation")
+	ErrConflict            = errors.New("conflict")
+	ErrUnavailable         = errors.New("unavailable")
+)
+
+// resources reported in errors
+const (
+	moviesResource        = "movies"
+	likedMoviesResource   = "liked_movies"
+	userHistoryResource   = "user_history"
+	commentsResource      = "comments"
+	likedCommentsResource = "liked_comments"
+	creditsResource       = "credits"
+	imagesResource        = "movie_images"
+	videosResource        = "movie_videos"
+	translationsResource  = "movie_translations"
+	peopleResource        = "people"
+	snapshotsResource     = "tmdb_snapshots"
+	rankingsResource      = "community_rankings"
+	ratingsResource       = "ratings"
+)
+
+// Error is returned by storage when operation on resource failed for known reason, Kind is one of the
+// kinds of storage errors and Err is the error of underlying database if there was any
+type Error struct {
+	Kind     error
+	Resource string
+	Err      error
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("%s: %s", e.Resource, e.Kind)
+	}
+	return fmt.Sprintf("%s: %s: %s", e.Resource, e.Kind, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) Is(target error) bool {
+	return e.Kind == target
+}
+
+func notFound(resource string) error {
+	return &Error{Kind: ErrNotFound, Resource: resource}
+}
+
+func alreadyExists(resource string) error {
+	return &Error{Kind: ErrAlreadyExists, Resource: resource}
+}
+
+func foreignKeyViolation(resource string, reference string) error {
+	return &Error{Kind: ErrForeignKeyViolation, Resource: resource, Err: fmt.Errorf("%s doesn't exist", reference)}
+}
+
+// wrapError translates error of go-pg to storage error of resource, errors of unknown kind are returned as they are
+func wrapError(err error, resource string) error {
+	if err == nil {
+		return nil
+	}
+
+	kind := errorKind(err)
+	if kind == nil {
+		return err
+	}
+	return &Error{Kind: kind, Resource: resource, Err: err}
+}
+
+func errorKind(err error) error {
+	var pgErr pg.Error
+	var netErr net.Error
+	switch {
+	case errors.Is(err, pg.ErrNoRows):
+		return ErrNotFound
+	case errors.As(err, &pgErr):
+		return pgErrorKind(pgErr.Field('C'))
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return ErrUnavailable
+	case errors.As(err, &netErr):
+		return ErrUnavailable
+	}
+	return nil
+}
+
+// pgErrorKind maps SQLSTATE codes, see https://www.postgresql.org/docs/current/errcodes-appendix.html
+func pgErrorKind(code string) error {
+	switch {
+	case code == "23505":
+		return ErrAlreadyExists
+	case code == "23503":
+		return ErrForeignKeyViolation
+	case code == "40001", code == "40P01", code == "55P03":
+		//serialization failure, deadlock and lock not available
+		return ErrConflict
+	case strings.HasPrefix(code, "08"), strings.HasPrefix(code, "53"), strings.HasPrefix(code, "57P"):
+		//connection exceptions, insufficient resources and shutdowns of server
+		return ErrUnavailable
+	}
+	return nil
+}
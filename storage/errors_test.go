@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-pg/pg/v10"
+)
+
+type pgError struct {
+	code string
+}
+
+func (e *pgError) Error() string {
+	return fmt.Sprintf("ERROR #%s", e.code)
+}
+
+func (e *pgError) Field(field byte) string {
+	if field == 'C' {
+		return e.code
+	}
+	return ""
+}
+
+func (e *pgError) IntegrityViolation() bool {
+	return e.code[:2] == "23"
+}
+
+func Test_wrapError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantKind error
+	}{
+		{
+			name:     "no_rows",
+			err:      pg.ErrNoRows,
+			wantKind: ErrNotFound,
+		},
+		{
+			name:     "unique_violation",
+			err:      &pgError{code: "23505"},
+			wantKind: ErrAlreadyExists,
+		},
+		{
+			name:     "foreign_key_violation",
+			err:      &pgError{code: "23503"},
+			wantKind: ErrForeignKeyViolation,
+		},
+		{
+			name:     "serialization_failure",
+			err:      &pgError{code: "40001"},
+			wantKind: ErrConflict,
+		},
+		{
+			name:     "connection_failure",
+			err:      &pgError{code: "08006"},
+			wantKind: ErrUnavailable,
+		},
+		{
+			name:     "deadline_exceeded",
+			err:      fmt.Errorf("query: %w", context.DeadlineExceeded),
+			wantKind: ErrUnavailable,
+		},
+		{
+			name:     "canceled",
+			err:      fmt.Errorf("query: %w", context.Canceled),
+			wantKind: ErrUnavailable,
+		},
+		{
+			name: "not_handled_postgres_error",
+			err:  &pgError{code: "42703"},
+		},
+	}
+	kinds := []error{ErrNotFound, ErrAlreadyExists, ErrForeignKeyViolation, ErrConflict, ErrUnavailable}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := wrapError(tt.err, moviesResource)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("wrapError() = %v, doesn't wrap %v", err, tt.err)
+			}
+			for _, kind := range kinds {
+				if errors.Is(err, kind) != (kind == tt.wantKind) {
+					t.Errorf("wrapError() = %v, want kind %v", err, tt.wantKind)
+				}
+			}
+
+			var storageErr *Error
+			if errors.As(err, &storageErr) && storageErr.Resource != moviesResource {
+				t.Errorf("wrapError() resource = %s, want %s", storageErr.Resource, moviesResource)
+			}
+		})
+	}
+}
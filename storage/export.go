@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/BarTar213/movies-service/models"
+	"github.com/go-pg/pg/v10"
+)
+
+// export queries are ordered by primary key so offset pagination is stable across batches
+
+func (p *Postgres) ExportMovies(ctx context.Context, params *models.PaginationParams) ([]models.TmdbMovie, error) {
+	movies := make([]models.TmdbMovie, 0, params.Limit)
+
+	err := p.db.ModelContext(ctx, &movies).
+		Relation(genres).
+		Relation("Countries").
+		Relation("Companies").
+		Relation("Languages").
+		Order("movie.id").
+		Offset(params.Offset).
+		Limit(params.Limit).
+		Select()
+
+	return movies, wrapError(err, moviesResource)
+}
+
+func (p *Postgres) ExportCredits(ctx context.Context, params *models.PaginationParams) ([]models.Credit, error) {
+	credits := make([]models.Credit, 0, params.Limit)
+
+	err := p.db.ModelContext(ctx, &credits).
+		Relation("Cast").
+		Relation("Crew").
+		Order("credit.id").
+		Offset(params.Offset).
+		Limit(params.Limit).
+		Select()
+
+	return credits, wrapError(err, creditsResource)
+}
+
+func (p *Postgres) ExportComments(ctx context.Context, params *models.PaginationParams) ([]models.Comment, error) {
+	comments := make([]models.Comment, 0, params.Limit)
+
+	err := p.db.ModelContext(ctx, &comments).
+		Order("id").
+		Offset(params.Offset).
+		Limit(params.Limit).
+		Select()
+
+	return comments, wrapError(err, commentsResource)
+}
+
+func (p *Postgres) ExportRatings(ctx context.Context, params *models.PaginationParams) ([]models.Rating, error) {
+	ratings := make([]models.Rating, 0, params.Limit)
+
+	err := p.db.ModelContext(ctx, &ratings).
+		Order("movie_id", "user_id").
+		Offset(params.Offset).
+		Limit(params.Limit).
+		Select()
+
+	return ratings, wrapError(err, ratingsResource)
+}
+
+// UpsertComment keeps id of imported comment and moves id sequence past it so new comments don't collide
+func (p *Postgres) UpsertComment(ctx context.Context, comment *models.Comment) error {
+	err := p.db.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		_, err := tx.Model(comment).
+			OnConflict("(id) DO UPDATE").
+			Set("user_id=?user_id").
+			Set("movie_id=?movie_id").
+			Set("content=?content").
+			Set("create_date=?create_date").
+			Set("update_date=?update_date").
+			Insert()
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec("SELECT setval(pg_get_serial_sequence('comments', 'id'), GREATEST(?, (SELECT max(id) FROM comments)))", comment.Id)
+		return err
+	})
+
+	return wrapError(err, commentsResource)
+}
@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/BarTar213/movies-service/models"
+)
+
+func (p *Postgres) ListImages(ctx context.Context, movieId int) ([]*models.Image, error) {
+	images := make([]*models.Image, 0)
+
+	err := p.db.ModelContext(ctx, &images).
+		Where("movie_id = ?", movieId).
+		Order("vote_average DESC").
+		Select()
+
+	return images, wrapError(err, imagesResource)
+}
+
+func (p *Postgres) AddImages(ctx context.Context, images []*models.Image) error {
+	if len(images) == 0 {
+		return nil
+	}
+	_, err := p.db.ModelContext(ctx, &images).
+		OnConflict("(movie_id, file_path) DO UPDATE").
+		Set("vote_average=EXCLUDED.vote_average").
+		Set("vote_count=EXCLUDED.vote_count").
+		Insert()
+
+	return wrapError(err, imagesResource)
+}
+
+func (p *Postgres) ListVideos(ctx context.Context, movieId int) ([]*models.Video, error) {
+	videos := make([]*models.Video, 0)
+
+	err := p.db.ModelContext(ctx, &videos).
+		Where("movie_id = ?", movieId).
+		Order("published_at DESC").
+		Select()
+
+	return videos, wrapError(err, videosResource)
+}
+
+func (p *Postgres) AddVideos(ctx context.Context, videos []*models.Video) error {
+	if len(videos) == 0 {
+		return nil
+	}
+	_, err := p.db.ModelContext(ctx, &videos).
+		OnConflict(doNothingStatement).
+		Insert()
+
+	return wrapError(err, videosResource)
+}
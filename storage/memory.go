@@ -0,0 +1,298 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/BarTar213/movies-service/models"
+)
+
+const (
+	//number of recently viewed movies kept for user
+	userHistoryLimit = 20
+
+	//resource reported in errors of cancelled operations
+	memoryResource = "memory"
+)
+
+// Memory keeps everything in process memory, it follows semantics of Postgres storage
+// including kinds of errors returned on missing rows and integrity violations, so it can replace it in tests and local runs
+type Memory struct {
+	mu sync.RWMutex
+
+	movies        map[int]*models.TmdbMovie
+	votes         map[int]*votes
+	likedMovies   map[models.LikedMovie]bool
+	history       map[int]map[int]time.Time
+	ratings       map[ratingKey]*models.Rating
+	comments      map[int]*models.Comment
+	commentSeq    int
+	likedComments map[models.LikedComment]bool
+	credits       map[int]*models.Credit
+	images        map[int][]*models.Image
+	videos        map[string]*models.Video
+	translations  map[translationKey]*models.Translation
+	people        map[int]*models.Person
+	snapshots     map[string]*models.Snapshot
+	rankings      []models.CommunityRanking
+}
+
+// votes aggregates community ratings of movie
+type votes struct {
+	count int
+	sum   int
+}
+
+type ratingKey struct {
+	userId  int
+	movieId int
+}
+
+type translationKey struct {
+	movieId  int
+	language string
+}
+
+func NewMemory() Storage {
+	return &Memory{
+		movies:        make(map[int]*models.TmdbMovie),
+		votes:         make(map[int]*votes),
+		likedMovies:   make(map[models.LikedMovie]bool),
+		history:       make(map[int]map[int]time.Time),
+		ratings:       make(map[ratingKey]*models.Rating),
+		comments:      make(map[int]*models.Comment),
+		likedComments: make(map[models.LikedComment]bool),
+		credits:       make(map[int]*models.Credit),
+		images:        make(map[int][]*models.Image),
+		videos:        make(map[string]*models.Video),
+		translations:  make(map[translationKey]*models.Translation),
+		people:        make(map[int]*models.Person),
+		snapshots:     make(map[string]*models.Snapshot),
+	}
+}
+
+// Ping never fails as memory is always available
+func (m *Memory) Ping(ctx context.Context) error {
+	return checkContext(ctx)
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
+
+// ctx is checked like database driver would do before running query,
+// its error is wrapped as unavailable storage like errors of Postgres are
+func checkContext(ctx context.Context) error {
+	return wrapError(ctx.Err(), memoryResource)
+}
+
+// paginate returns bounds of page, zero limit means no limit like in go-pg queries
+func paginate(length int, offset int, limit int) (int, int) {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > length {
+		offset = length
+	}
+	end := length
+	if limit > 0 && offset+limit < length {
+		end = offset + limit
+	}
+	return offset, end
+}
+
+// sortRows sorts slice of structs by ORDER BY clause, columns are matched with snake cased field names
+// of struct and its embedded structs, table prefixes are ignored
+func sortRows(rows interface{}, orderBy ...string) error {
+	value := reflect.ValueOf(rows)
+	orders := make([]columnOrder, 0)
+	for _, clause := range orderBy {
+		for _, part := range strings.Split(clause, ",") {
+			fields := strings.Fields(part)
+			if len(fields) == 0 {
+				continue
+			}
+			column := fields[0]
+			if i := strings.LastIndex(column, "."); i >= 0 {
+				column = column[i+1:]
+			}
+			order := columnOrder{column: strings.Trim(column, `"`)}
+			if len(fields) > 1 {
+				order.desc = strings.EqualFold(fields[1], "DESC")
+			}
+			orders = append(orders, order)
+		}
+	}
+
+	for _, order := range orders {
+		if value.Len() > 0 && !columnValue(value.Index(0), order.column).IsValid() {
+			return fmt.Errorf("column \"%s\" does not exist", order.column)
+		}
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		for _, order := range orders {
+			a := columnValue(value.Index(i), order.column)
+			b := columnValue(value.Index(j), order.column)
+			cmp := compareValues(a, b)
+			if cmp == 0 {
+				continue
+			}
+			if order.desc {
+				return cmp > 0
+			}
+			return cmp < 0
+		}
+		return false
+	})
+
+	return nil
+}
+
+type columnOrder struct {
+	column string
+	desc   bool
+}
+
+func columnValue(row reflect.Value, column string) reflect.Value {
+	for row.Kind() == reflect.Ptr {
+		if row.IsNil() {
+			return reflect.Value{}
+		}
+		row = row.Elem()
+	}
+	if row.Kind() != reflect.Struct {
+		return reflect.Value{}
+	}
+
+	embedded := make([]reflect.Value, 0)
+	for i := 0; i < row.NumField(); i++ {
+		field := row.Type().Field(i)
+		if len(field.PkgPath) > 0 {
+			continue
+		}
+		if field.Anonymous {
+			embedded = append(embedded, row.Field(i))
+			continue
+		}
+		if columnName(field) == column {
+			return row.Field(i)
+		}
+	}
+	for _, value := range embedded {
+		found := columnValue(value, column)
+		if found.IsValid() {
+			return found
+		}
+	}
+
+	return reflect.Value{}
+}
+
+func columnName(field reflect.StructField) string {
+	tag := field.Tag.Get("pg")
+	if i := strings.Index(tag, ","); i >= 0 {
+		tag = tag[:i]
+	}
+	if len(tag) > 0 && tag != "-" {
+		return tag
+	}
+
+	name := make([]rune, 0, len(field.Name)+4)
+	for i, r := range field.Name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				name = append(name, '_')
+			}
+			r = unicode.ToLower(r)
+		}
+		name = append(name, r)
+	}
+	return string(name)
+}
+
+func compareValues(a, b reflect.Value) int {
+	if t, ok := a.Interface().(time.Time); ok {
+		return compareTimes(t, b.Interface().(time.Time))
+	}
+	if t, ok := a.Interface().(models.Time); ok {
+		return compareTimes(t.Time, b.Interface().(models.Time).Time)
+	}
+
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return compareFloats(float64(a.Int()), float64(b.Int()))
+	case reflect.Float32, reflect.Float64:
+		return compareFloats(a.Float(), b.Float())
+	case reflect.String:
+		return strings.Compare(a.String(), b.String())
+	case reflect.Bool:
+		if a.Bool() == b.Bool() {
+			return 0
+		}
+		if b.Bool() {
+			return -1
+		}
+		return 1
+	case reflect.Ptr:
+		if a.IsNil() || b.IsNil() {
+			return compareFloats(boolToFloat(!a.IsNil()), boolToFloat(!b.IsNil()))
+		}
+		return compareValues(a.Elem(), b.Elem())
+	}
+	return 0
+}
+
+func compareTimes(a, b time.Time) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
+	}
+	return 0
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// like matches value with SQL LIKE pattern
+func like(value string, pattern string) bool {
+	expr := strings.Builder{}
+	expr.WriteString("(?s)^")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			expr.WriteString(".*")
+		case '_':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	expr.WriteString("$")
+
+	matched, err := regexp.MatchString(expr.String(), value)
+	return err == nil && matched
+}
@@ -0,0 +1,201 @@
+package storage
+
+import (
+	"context"
+	"sort"
+
+	"github.com/BarTar213/movies-service/models"
+)
+
+func (m *Memory) ListMovieComments(ctx context.Context, movieId int, params *models.PaginationParams) ([]models.Comment, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	likes := make(map[int]int)
+	for liked := range m.likedComments {
+		likes[liked.CommentId]++
+	}
+
+	comments := make([]models.Comment, 0)
+	for _, comment := range m.comments {
+		if comment.MovieId != movieId {
+			continue
+		}
+		copied := *comment
+		copied.Likes = likes[comment.Id]
+		comments = append(comments, copied)
+	}
+	sortComments(comments)
+	err := sortRows(comments, params.OrderBy)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end := paginate(len(comments), params.Offset, params.Limit)
+	return comments[start:end], nil
+}
+
+func (m *Memory) ListLikedCommentsForMovie(ctx context.Context, movieID, userID int) ([]int, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ids := make([]int, 0)
+	for liked := range m.likedComments {
+		comment, ok := m.comments[liked.CommentId]
+		if liked.UserId == userID && ok && comment.MovieId == movieID {
+			ids = append(ids, liked.CommentId)
+		}
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
+
+func (m *Memory) LikeComment(ctx context.Context, userId int, commentId int, comment *models.Comment) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.comments[commentId]
+	if !ok {
+		return foreignKeyViolation(likedCommentsResource, commentsResource)
+	}
+	key := models.LikedComment{CommentId: commentId, UserId: userId}
+	if m.likedComments[key] {
+		return alreadyExists(likedCommentsResource)
+	}
+	m.likedComments[key] = true
+
+	*comment = *stored
+	return nil
+}
+
+func (m *Memory) DeleteCommentLike(ctx context.Context, userId int, commentId int) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := models.LikedComment{CommentId: commentId, UserId: userId}
+	if !m.likedComments[key] {
+		return notFound(likedCommentsResource)
+	}
+	delete(m.likedComments, key)
+	return nil
+}
+
+func (m *Memory) AddMovieComment(ctx context.Context, comment *models.Comment) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.movies[comment.MovieId]; !ok {
+		return foreignKeyViolation(commentsResource, moviesResource)
+	}
+	m.commentSeq++
+	comment.Id = m.commentSeq
+
+	stored := *comment
+	m.comments[comment.Id] = &stored
+	return nil
+}
+
+// UpdateComment changes comment only when it belongs to user of the comment
+func (m *Memory) UpdateComment(ctx context.Context, comment *models.Comment) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.comments[comment.Id]
+	if !ok || stored.UserId != comment.UserId {
+		return notFound(commentsResource)
+	}
+	stored.Content = comment.Content
+	stored.UpdateDate = comment.UpdateDate
+
+	*comment = *stored
+	return nil
+}
+
+func (m *Memory) DeleteComment(ctx context.Context, comment *models.Comment) (bool, error) {
+	if err := checkContext(ctx); err != nil {
+		return false, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.comments[comment.Id]
+	if !ok || stored.UserId != comment.UserId {
+		return false, nil
+	}
+	delete(m.comments, comment.Id)
+	for liked := range m.likedComments {
+		if liked.CommentId == comment.Id {
+			delete(m.likedComments, liked)
+		}
+	}
+	return true, nil
+}
+
+func (m *Memory) UpsertComment(ctx context.Context, comment *models.Comment) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.movies[comment.MovieId]; !ok {
+		return foreignKeyViolation(commentsResource, moviesResource)
+	}
+	stored := *comment
+	stored.Likes = 0
+	m.comments[comment.Id] = &stored
+	if comment.Id > m.commentSeq {
+		m.commentSeq = comment.Id
+	}
+	return nil
+}
+
+func (m *Memory) ExportComments(ctx context.Context, params *models.PaginationParams) ([]models.Comment, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	comments := make([]models.Comment, 0, len(m.comments))
+	for _, comment := range m.comments {
+		comments = append(comments, *comment)
+	}
+	sortComments(comments)
+
+	start, end := paginate(len(comments), params.Offset, params.Limit)
+	return comments[start:end], nil
+}
+
+func sortComments(comments []models.Comment) {
+	sort.Slice(comments, func(i, j int) bool {
+		return comments[i].Id < comments[j].Id
+	})
+}
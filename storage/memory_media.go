@@ -0,0 +1,364 @@
+package storage
+
+import (
+	"context"
+	"sort"
+
+	"github.com/BarTar213/movies-service/models"
+)
+
+func (m *Memory) GetCredits(ctx context.Context, movieId int, credit *models.Credit) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, stored := range m.sortedCredits() {
+		if stored.MovieId == movieId {
+			*credit = copyCredit(stored)
+			return nil
+		}
+	}
+	return notFound(creditsResource)
+}
+
+// AddCredits replaces cast and crew of credit like Postgres storage does in transaction
+func (m *Memory) AddCredits(ctx context.Context, credit *models.Credit) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored := copyCredit(credit)
+	m.credits[credit.Id] = &stored
+	return nil
+}
+
+func (m *Memory) ExportCredits(ctx context.Context, params *models.PaginationParams) ([]models.Credit, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	credits := m.sortedCredits()
+	start, end := paginate(len(credits), params.Offset, params.Limit)
+	exported := make([]models.Credit, 0, end-start)
+	for _, credit := range credits[start:end] {
+		exported = append(exported, copyCredit(credit))
+	}
+	return exported, nil
+}
+
+func (m *Memory) sortedCredits() []*models.Credit {
+	credits := make([]*models.Credit, 0, len(m.credits))
+	for _, credit := range m.credits {
+		credits = append(credits, credit)
+	}
+	sort.Slice(credits, func(i, j int) bool {
+		return credits[i].Id < credits[j].Id
+	})
+	return credits
+}
+
+func (m *Memory) ListImages(ctx context.Context, movieId int) ([]*models.Image, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	images := make([]*models.Image, 0, len(m.images[movieId]))
+	for _, image := range m.images[movieId] {
+		copied := *image
+		images = append(images, &copied)
+	}
+	err := sortRows(images, "vote_average DESC")
+
+	return images, err
+}
+
+// AddImages updates votes of images already stored for movie under the same file path
+func (m *Memory) AddImages(ctx context.Context, images []*models.Image) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, image := range images {
+		if stored := m.findImage(image.MovieId, image.FilePath); stored != nil {
+			stored.VoteAverage = image.VoteAverage
+			stored.VoteCount = image.VoteCount
+			continue
+		}
+		copied := *image
+		copied.Url = ""
+		m.images[image.MovieId] = append(m.images[image.MovieId], &copied)
+	}
+	return nil
+}
+
+func (m *Memory) findImage(movieId int, filePath string) *models.Image {
+	for _, image := range m.images[movieId] {
+		if image.FilePath == filePath {
+			return image
+		}
+	}
+	return nil
+}
+
+func (m *Memory) ListVideos(ctx context.Context, movieId int) ([]*models.Video, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	videos := make([]*models.Video, 0)
+	for _, video := range m.videos {
+		if video.MovieId == movieId {
+			copied := *video
+			videos = append(videos, &copied)
+		}
+	}
+	sort.Slice(videos, func(i, j int) bool {
+		return videos[i].Id < videos[j].Id
+	})
+	err := sortRows(videos, "published_at DESC")
+
+	return videos, err
+}
+
+// AddVideos keeps videos which are already stored untouched
+func (m *Memory) AddVideos(ctx context.Context, videos []*models.Video) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, video := range videos {
+		if _, ok := m.videos[video.Id]; ok {
+			continue
+		}
+		copied := *video
+		copied.Url = ""
+		m.videos[video.Id] = &copied
+	}
+	return nil
+}
+
+func (m *Memory) GetTranslation(ctx context.Context, translation *models.Translation) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stored, ok := m.translations[translationKey{movieId: translation.MovieId, language: translation.Language}]
+	if !ok {
+		return notFound(translationsResource)
+	}
+	*translation = *stored
+	return nil
+}
+
+func (m *Memory) ListTranslations(ctx context.Context, movieIds []int, language string) ([]models.Translation, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	translations := make([]models.Translation, 0)
+	seen := make(map[int]bool, len(movieIds))
+	for _, movieId := range movieIds {
+		if seen[movieId] {
+			continue
+		}
+		seen[movieId] = true
+		if stored, ok := m.translations[translationKey{movieId: movieId, language: language}]; ok {
+			translations = append(translations, *stored)
+		}
+	}
+	return translations, nil
+}
+
+func (m *Memory) AddTranslations(ctx context.Context, translations []models.Translation) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range translations {
+		stored := translations[i]
+		m.translations[translationKey{movieId: stored.MovieId, language: stored.Language}] = &stored
+	}
+	return nil
+}
+
+func (m *Memory) GetPerson(ctx context.Context, person *models.Person) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stored, ok := m.people[person.Id]
+	if !ok {
+		return notFound(peopleResource)
+	}
+	*person = *stored
+	return nil
+}
+
+// AddPerson keeps gender and imdb id of person which is already stored, same as Postgres storage
+func (m *Memory) AddPerson(ctx context.Context, person *models.Person) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored := *person
+	if old, ok := m.people[person.Id]; ok {
+		stored.Gender = old.Gender
+		stored.ImdbId = old.ImdbId
+	}
+	m.people[person.Id] = &stored
+	return nil
+}
+
+func (m *Memory) ListPersonCast(ctx context.Context, personId int) ([]models.CastCredit, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	credits := make([]models.CastCredit, 0)
+	for _, credit := range m.sortedCredits() {
+		movie, ok := m.movies[credit.MovieId]
+		if !ok {
+			continue
+		}
+		for _, cast := range credit.Cast {
+			if cast.Id != personId {
+				continue
+			}
+			credits = append(credits, models.CastCredit{
+				MovieId:     movie.Id,
+				Title:       movie.Title,
+				PosterPath:  movie.PosterPath,
+				ReleaseDate: movie.ReleaseDate.Time,
+				VoteAverage: movie.VoteAverage,
+				Character:   cast.Character,
+				Order:       cast.Order,
+			})
+		}
+	}
+	err := sortRows(credits, "release_date DESC")
+
+	return credits, err
+}
+
+func (m *Memory) ListPersonCrew(ctx context.Context, personId int) ([]models.CrewCredit, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	credits := make([]models.CrewCredit, 0)
+	for _, credit := range m.sortedCredits() {
+		movie, ok := m.movies[credit.MovieId]
+		if !ok {
+			continue
+		}
+		for _, crew := range credit.Crew {
+			if crew.Id != personId {
+				continue
+			}
+			credits = append(credits, models.CrewCredit{
+				MovieId:     movie.Id,
+				Title:       movie.Title,
+				PosterPath:  movie.PosterPath,
+				ReleaseDate: movie.ReleaseDate.Time,
+				VoteAverage: movie.VoteAverage,
+				Department:  crew.Department,
+				Job:         crew.Job,
+			})
+		}
+	}
+	err := sortRows(credits, "department", "job", "release_date DESC")
+
+	return credits, err
+}
+
+func (m *Memory) GetSnapshot(ctx context.Context, snapshot *models.Snapshot) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stored, ok := m.snapshots[snapshot.Key]
+	if !ok {
+		return notFound(snapshotsResource)
+	}
+	*snapshot = *stored
+	snapshot.Data = append([]byte(nil), stored.Data...)
+	return nil
+}
+
+func (m *Memory) SaveSnapshot(ctx context.Context, snapshot *models.Snapshot) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored := *snapshot
+	stored.Data = append([]byte(nil), snapshot.Data...)
+	m.snapshots[snapshot.Key] = &stored
+	return nil
+}
+
+func copyCredit(credit *models.Credit) models.Credit {
+	copied := *credit
+
+	copied.Cast = make([]*models.Cast, 0, len(credit.Cast))
+	for _, cast := range credit.Cast {
+		c := *cast
+		c.CreditId = credit.Id
+		copied.Cast = append(copied.Cast, &c)
+	}
+	copied.Crew = make([]*models.Crew, 0, len(credit.Crew))
+	for _, crew := range credit.Crew {
+		c := *crew
+		c.CreditId = credit.Id
+		copied.Crew = append(copied.Crew, &c)
+	}
+
+	return copied
+}
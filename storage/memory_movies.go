@@ -0,0 +1,358 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/BarTar213/movies-service/models"
+)
+
+func (m *Memory) AddMovie(ctx context.Context, movie *models.TmdbMovie) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.upsertMovie(movie)
+	return nil
+}
+
+// movies are upserted under single lock, so the batch is visible at once like after transaction commit
+func (m *Memory) AddMovies(ctx context.Context, movies []models.TmdbMovie) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range movies {
+		m.upsertMovie(&movies[i])
+	}
+	return nil
+}
+
+// follows upsert of Postgres storage, only some of details are updated when movie is already stored
+func (m *Memory) upsertMovie(movie *models.TmdbMovie) {
+	stored, ok := m.movies[movie.Id]
+	if !ok {
+		m.movies[movie.Id] = copyTmdbMovie(movie)
+		m.votes[movie.Id] = &votes{count: movie.VoteCount}
+		return
+	}
+
+	stored.Budget = movie.Budget
+	stored.PosterPath = movie.PosterPath
+	stored.BackdropPath = movie.BackdropPath
+	stored.Revenue = movie.Revenue
+	stored.Runtime = movie.Runtime
+	stored.VoteAverage = movie.VoteAverage
+	if stored.Provider == "" {
+		stored.Provider = movie.Provider
+	}
+
+	relations := copyTmdbMovie(movie)
+	stored.Genres = relations.Genres
+	stored.Countries = relations.Countries
+	stored.Companies = relations.Companies
+	stored.Languages = relations.Languages
+}
+
+func (m *Memory) GetMovie(ctx context.Context, movie *models.Movie) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stored, ok := m.movies[movie.Id]
+	if !ok {
+		return notFound(moviesResource)
+	}
+
+	copied := copyTmdbMovie(stored)
+	*movie = models.Movie{
+		Id:               copied.Id,
+		Adult:            copied.Adult,
+		Budget:           copied.Budget,
+		BackdropPath:     copied.BackdropPath,
+		Homepage:         copied.Homepage,
+		ImdbId:           copied.ImdbId,
+		OriginalLanguage: copied.OriginalLanguage,
+		OriginalTitle:    copied.OriginalTitle,
+		Overview:         copied.Overview,
+		Popularity:       copied.Popularity,
+		PosterPath:       copied.PosterPath,
+		ReleaseDate:      copied.ReleaseDate.Time,
+		Revenue:          copied.Revenue,
+		Runtime:          copied.Runtime,
+		Status:           copied.Status,
+		Tagline:          copied.Tagline,
+		Title:            copied.Title,
+		VoteAverage:      copied.VoteAverage,
+		VoteCount:        m.votes[movie.Id].count,
+		Countries:        copied.Countries,
+		Companies:        copied.Companies,
+		Genres:           copied.Genres,
+		Languages:        copied.Languages,
+		Provider:         copied.Provider,
+	}
+	return nil
+}
+
+func (m *Memory) ListMovies(ctx context.Context, filters *models.MovieFilters, params *models.PaginationParams) ([]models.MoviePreview, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	movies := make([]*models.TmdbMovie, 0)
+	for _, movie := range m.movies {
+		if !m.matchesFilters(movie, filters) {
+			continue
+		}
+		movies = append(movies, movie)
+	}
+
+	return m.previews(movies, params)
+}
+
+func (m *Memory) matchesFilters(movie *models.TmdbMovie, filters *models.MovieFilters) bool {
+	matched := like(movie.Title, filters.Title)
+	if !matched && len(filters.Language) > 0 {
+		translation, ok := m.translations[translationKey{movieId: movie.Id, language: filters.Language}]
+		matched = ok && like(translation.Title, filters.Title)
+	}
+	if !matched {
+		return false
+	}
+
+	if filters.CastId > 0 && !m.hasCredit(movie.Id, filters.CastId, true) {
+		return false
+	}
+	if filters.CrewId > 0 && !m.hasCredit(movie.Id, filters.CrewId, false) {
+		return false
+	}
+	return true
+}
+
+func (m *Memory) hasCredit(movieId int, personId int, cast bool) bool {
+	for _, credit := range m.credits {
+		if credit.MovieId != movieId {
+			continue
+		}
+		if cast {
+			for _, c := range credit.Cast {
+				if c.Id == personId {
+					return true
+				}
+			}
+			continue
+		}
+		for _, c := range credit.Crew {
+			if c.Id == personId {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sorts and paginates movies, returned previews don't include rating
+func (m *Memory) previews(movies []*models.TmdbMovie, params *models.PaginationParams) ([]models.MoviePreview, error) {
+	sortById(movies)
+	err := sortRows(movies, params.OrderBy)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end := paginate(len(movies), params.Offset, params.Limit)
+	previews := make([]models.MoviePreview, 0, end-start)
+	for _, movie := range movies[start:end] {
+		previews = append(previews, preview(movie))
+	}
+	return previews, nil
+}
+
+func (m *Memory) ListMoviesFromIDs(ctx context.Context, IDs []int) ([]models.MoviePreview, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	movies := make([]models.MoviePreview, 0, len(IDs))
+	for _, id := range IDs {
+		movie, ok := m.movies[id]
+		if ok {
+			movies = append(movies, preview(movie))
+		}
+	}
+	return movies, nil
+}
+
+// keeps only the most recently viewed movies of user
+func (m *Memory) AddRecentViewedMovie(ctx context.Context, userId int, movieId int) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	viewed, ok := m.history[userId]
+	if !ok {
+		viewed = make(map[int]time.Time)
+		m.history[userId] = viewed
+	}
+	viewed[movieId] = time.Now()
+
+	for len(viewed) > userHistoryLimit {
+		oldest, oldestTime := 0, time.Time{}
+		for id, viewedAt := range viewed {
+			if oldestTime.IsZero() || viewedAt.Before(oldestTime) {
+				oldest, oldestTime = id, viewedAt
+			}
+		}
+		delete(viewed, oldest)
+	}
+	return nil
+}
+
+func (m *Memory) LikeMovie(ctx context.Context, userId int, movieId int) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.movies[movieId]; !ok {
+		return foreignKeyViolation(likedMoviesResource, moviesResource)
+	}
+	key := models.LikedMovie{MovieId: movieId, UserId: userId}
+	if m.likedMovies[key] {
+		return alreadyExists(likedMoviesResource)
+	}
+	m.likedMovies[key] = true
+	return nil
+}
+
+func (m *Memory) DeleteMovieLike(ctx context.Context, userId int, movieId int) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := models.LikedMovie{MovieId: movieId, UserId: userId}
+	if !m.likedMovies[key] {
+		return notFound(likedMoviesResource)
+	}
+	delete(m.likedMovies, key)
+	return nil
+}
+
+func (m *Memory) ListLikedMovies(ctx context.Context, userId int, params *models.PaginationParams) ([]models.MoviePreview, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	movies := make([]*models.TmdbMovie, 0)
+	for liked := range m.likedMovies {
+		if liked.UserId == userId {
+			movies = append(movies, m.movies[liked.MovieId])
+		}
+	}
+
+	return m.previews(movies, params)
+}
+
+func (m *Memory) CheckLiked(ctx context.Context, likedMovie *models.LikedMovie) (bool, error) {
+	if err := checkContext(ctx); err != nil {
+		return false, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.likedMovies[*likedMovie], nil
+}
+
+func (m *Memory) ExportMovies(ctx context.Context, params *models.PaginationParams) ([]models.TmdbMovie, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	movies := make([]*models.TmdbMovie, 0, len(m.movies))
+	for _, movie := range m.movies {
+		movies = append(movies, movie)
+	}
+	sortById(movies)
+
+	start, end := paginate(len(movies), params.Offset, params.Limit)
+	exported := make([]models.TmdbMovie, 0, end-start)
+	for _, movie := range movies[start:end] {
+		exported = append(exported, *copyTmdbMovie(movie))
+	}
+	return exported, nil
+}
+
+// map iteration order is random, movies are ordered by id before sorting by other columns
+func sortById(movies []*models.TmdbMovie) {
+	sort.Slice(movies, func(i, j int) bool {
+		return movies[i].Id < movies[j].Id
+	})
+}
+
+func preview(movie *models.TmdbMovie) models.MoviePreview {
+	return models.MoviePreview{
+		Id:          movie.Id,
+		PosterPath:  movie.PosterPath,
+		ReleaseDate: movie.ReleaseDate.Time,
+		VoteAverage: movie.VoteAverage,
+		Title:       movie.Title,
+	}
+}
+
+// stored movies don't share relations with callers
+func copyTmdbMovie(movie *models.TmdbMovie) *models.TmdbMovie {
+	copied := *movie
+
+	copied.Genres = make([]*models.Genre, 0, len(movie.Genres))
+	for _, genre := range movie.Genres {
+		g := *genre
+		copied.Genres = append(copied.Genres, &g)
+	}
+	copied.Countries = make([]*models.Country, 0, len(movie.Countries))
+	for _, country := range movie.Countries {
+		c := *country
+		copied.Countries = append(copied.Countries, &c)
+	}
+	copied.Companies = make([]*models.Company, 0, len(movie.Companies))
+	for _, company := range movie.Companies {
+		c := *company
+		copied.Companies = append(copied.Companies, &c)
+	}
+	copied.Languages = make([]*models.Language, 0, len(movie.Languages))
+	for _, language := range movie.Languages {
+		l := *language
+		copied.Languages = append(copied.Languages, &l)
+	}
+
+	return &copied
+}
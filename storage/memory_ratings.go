@@ -0,0 +1,256 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/BarTar213/movies-service/models"
+)
+
+// ratedMovie is a row of ratings joined with movies
+type ratedMovie struct {
+	*models.Rating
+	*models.TmdbMovie
+}
+
+func (m *Memory) GetRating(ctx context.Context, rating *models.Rating) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stored, ok := m.ratings[ratingKey{userId: rating.UserId, movieId: rating.MovieId}]
+	if !ok {
+		return notFound(ratingsResource)
+	}
+	*rating = copyRating(stored)
+	return nil
+}
+
+// AddRating upserts rating and updates votes of movie like Postgres storage does, new rating increments
+// vote count only and changed rating adds the difference to vote sum
+func (m *Memory) AddRating(ctx context.Context, rating *models.Rating) (RatingChange, error) {
+	if err := checkContext(ctx); err != nil {
+		return RatingUnchanged, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if rating.Rating == nil {
+		return RatingUnchanged, fmt.Errorf("%s: null value in column \"rating\" violates not-null constraint", ratingsResource)
+	}
+	aggregate, ok := m.votes[rating.MovieId]
+	if !ok {
+		return RatingUnchanged, foreignKeyViolation(ratingsResource, moviesResource)
+	}
+
+	key := ratingKey{userId: rating.UserId, movieId: rating.MovieId}
+	change := RatingAdded
+	if old, ok := m.ratings[key]; ok {
+		if *old.Rating == *rating.Rating {
+			return RatingUnchanged, nil
+		}
+		change = RatingChanged
+		aggregate.sum += *rating.Rating - *old.Rating
+	} else {
+		aggregate.count++
+	}
+
+	stored := copyRating(rating)
+	m.ratings[key] = &stored
+	return change, nil
+}
+
+// DeleteRating leaves votes of movie as they are like Postgres storage does
+func (m *Memory) DeleteRating(ctx context.Context, rating *models.Rating) (bool, error) {
+	if err := checkContext(ctx); err != nil {
+		return false, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := ratingKey{userId: rating.UserId, movieId: rating.MovieId}
+	if _, ok := m.ratings[key]; !ok {
+		return false, nil
+	}
+	delete(m.ratings, key)
+	return true, nil
+}
+
+func (m *Memory) ListRatedMovies(ctx context.Context, userID int, params *models.PaginationParams) ([]models.MoviePreview, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	rows := make([]ratedMovie, 0)
+	for key, rating := range m.ratings {
+		if key.userId == userID {
+			rows = append(rows, ratedMovie{Rating: rating, TmdbMovie: m.movies[key.movieId]})
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].MovieId < rows[j].MovieId
+	})
+	err := sortRows(rows, params.OrderBy)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end := paginate(len(rows), params.Offset, params.Limit)
+	movies := make([]models.MoviePreview, 0, end-start)
+	for _, row := range rows[start:end] {
+		movie := preview(row.TmdbMovie)
+		movie.Rating = *row.Rating.Rating
+		movies = append(movies, movie)
+	}
+	return movies, nil
+}
+
+func (m *Memory) ExportRatings(ctx context.Context, params *models.PaginationParams) ([]models.Rating, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ratings := make([]models.Rating, 0, len(m.ratings))
+	for _, rating := range m.ratings {
+		ratings = append(ratings, copyRating(rating))
+	}
+	sort.Slice(ratings, func(i, j int) bool {
+		if ratings[i].MovieId != ratings[j].MovieId {
+			return ratings[i].MovieId < ratings[j].MovieId
+		}
+		return ratings[i].UserId < ratings[j].UserId
+	})
+
+	start, end := paginate(len(ratings), params.Offset, params.Limit)
+	return ratings[start:end], nil
+}
+
+// RefreshCommunityRankings computes rankings the same way as Postgres storage,
+// see its documentation for description of the score
+func (m *Memory) RefreshCommunityRankings(ctx context.Context, minVotes int) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	likes := make(map[int]int)
+	for liked := range m.likedMovies {
+		likes[liked.MovieId]++
+	}
+
+	now := time.Now()
+	rankings := make([]models.CommunityRanking, 0)
+	for _, period := range models.RankingPeriods {
+		start := models.PeriodStart(period, now)
+
+		aggregates := make(map[int]*votes)
+		total := &votes{}
+		for _, rating := range m.ratings {
+			if rating.CreateDate.Before(start) {
+				continue
+			}
+			aggregate, ok := aggregates[rating.MovieId]
+			if !ok {
+				aggregate = &votes{}
+				aggregates[rating.MovieId] = aggregate
+			}
+			aggregate.count++
+			aggregate.sum += *rating.Rating
+			total.count++
+			total.sum += *rating.Rating
+		}
+
+		mean := 0.0
+		if total.count > 0 {
+			mean = float64(total.sum) / float64(total.count)
+		}
+		for movieId, aggregate := range aggregates {
+			if aggregate.count < minVotes {
+				continue
+			}
+			rankings = append(rankings, models.CommunityRanking{
+				Period:      period,
+				MovieId:     movieId,
+				Score:       (float64(aggregate.sum) + float64(minVotes)*mean) / float64(aggregate.count+minVotes),
+				VoteCount:   aggregate.count,
+				VoteAverage: float64(aggregate.sum) / float64(aggregate.count),
+				Likes:       likes[movieId],
+				UpdatedAt:   now,
+			})
+		}
+	}
+
+	m.rankings = rankings
+	return nil
+}
+
+func (m *Memory) ListCommunityRanking(ctx context.Context, params *models.CommunityRankingParams, pagination *models.PaginationParams) ([]models.RankedMovie, int, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, 0, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	rankings := make([]models.CommunityRanking, 0)
+	for _, ranking := range m.rankings {
+		movie, ok := m.movies[ranking.MovieId]
+		if !ok || ranking.Period != params.Period {
+			continue
+		}
+		if params.GenreId > 0 && !hasGenre(movie, params.GenreId) {
+			continue
+		}
+		rankings = append(rankings, ranking)
+	}
+	err := sortRows(rankings, "score DESC", "likes DESC", "movie_id")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	start, end := paginate(len(rankings), pagination.Offset, pagination.Limit)
+	movies := make([]models.RankedMovie, 0, end-start)
+	for _, ranking := range rankings[start:end] {
+		movies = append(movies, models.RankedMovie{
+			MoviePreview:     preview(m.movies[ranking.MovieId]),
+			CommunityScore:   ranking.Score,
+			CommunityVotes:   ranking.VoteCount,
+			CommunityAverage: ranking.VoteAverage,
+			Likes:            ranking.Likes,
+		})
+	}
+	return movies, len(rankings), nil
+}
+
+func hasGenre(movie *models.TmdbMovie, genreId int) bool {
+	for _, genre := range movie.Genres {
+		if genre.Id == genreId {
+			return true
+		}
+	}
+	return false
+}
+
+func copyRating(rating *models.Rating) models.Rating {
+	copied := *rating
+	if rating.Rating != nil {
+		value := *rating.Rating
+		copied.Rating = &value
+	}
+	return copied
+}
@@ -0,0 +1,369 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/BarTar213/movies-service/models"
+)
+
+func newMemoryWithMovies(t *testing.T, movies ...models.TmdbMovie) *Memory {
+	m := NewMemory().(*Memory)
+	if err := m.AddMovies(context.Background(), movies); err != nil {
+		t.Fatalf("AddMovies() error = %v", err)
+	}
+	return m
+}
+
+func TestMemory_LikeMovie(t *testing.T) {
+	tests := []struct {
+		name    string
+		liked   []int
+		movieId int
+		wantErr error
+	}{
+		{
+			name:    "positive_like_movie",
+			movieId: 1,
+		},
+		{
+			name:    "negative_like_movie_already_liked",
+			liked:   []int{1},
+			movieId: 1,
+			wantErr: ErrAlreadyExists,
+		},
+		{
+			name:    "negative_like_movie_not_existing",
+			movieId: 2,
+			wantErr: ErrForeignKeyViolation,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m := newMemoryWithMovies(t, models.TmdbMovie{Id: 1})
+			for _, movieId := range tt.liked {
+				if err := m.LikeMovie(ctx, 1, movieId); err != nil {
+					t.Fatalf("LikeMovie() error = %v", err)
+				}
+			}
+
+			err := m.LikeMovie(ctx, 1, tt.movieId)
+			if !errors.Is(err, tt.wantErr) || (err != nil) != (tt.wantErr != nil) {
+				t.Errorf("LikeMovie() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMemory_ListMovies(t *testing.T) {
+	m := newMemoryWithMovies(t,
+		models.TmdbMovie{Id: 1, Title: "Alien", VoteAverage: 8.4},
+		models.TmdbMovie{Id: 2, Title: "Aliens", VoteAverage: 8.3},
+		models.TmdbMovie{Id: 3, Title: "Heat", VoteAverage: 8.3},
+		models.TmdbMovie{Id: 4, Title: "Alien 3", VoteAverage: 6.4},
+	)
+
+	tests := []struct {
+		name    string
+		filters *models.MovieFilters
+		params  *models.PaginationParams
+		wantIds []int
+		wantErr bool
+	}{
+		{
+			name:    "positive_list_movies_ordered",
+			filters: &models.MovieFilters{Title: "%"},
+			params:  &models.PaginationParams{OrderBy: "vote_average DESC, id DESC"},
+			wantIds: []int{1, 3, 2, 4},
+		},
+		{
+			name:    "positive_list_movies_paginated",
+			filters: &models.MovieFilters{Title: "%"},
+			params:  &models.PaginationParams{OrderBy: "title", Offset: 1, Limit: 2},
+			wantIds: []int{4, 2},
+		},
+		{
+			name:    "positive_list_movies_title_filter",
+			filters: &models.MovieFilters{Title: "Alien%"},
+			params:  &models.PaginationParams{},
+			wantIds: []int{1, 2, 4},
+		},
+		{
+			name:    "negative_list_movies_unknown_column",
+			filters: &models.MovieFilters{Title: "%"},
+			params:  &models.PaginationParams{OrderBy: "unknown"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			movies, err := m.ListMovies(context.Background(), tt.filters, tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ListMovies() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(movies) != len(tt.wantIds) {
+				t.Fatalf("ListMovies() got %d movies, want %d", len(movies), len(tt.wantIds))
+			}
+			for i, movie := range movies {
+				if movie.Id != tt.wantIds[i] {
+					t.Errorf("ListMovies()[%d] = %d, want %d", i, movie.Id, tt.wantIds[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMemory_RatingAggregates(t *testing.T) {
+	ctx := context.Background()
+	m := newMemoryWithMovies(t, models.TmdbMovie{Id: 1, VoteCount: 10})
+
+	ratings := []struct {
+		userId int
+		rating int
+	}{
+		{userId: 1, rating: 5},
+		{userId: 2, rating: 3},
+		{userId: 1, rating: 4},
+	}
+	for _, r := range ratings {
+		value := r.rating
+		if _, err := m.AddRating(ctx, &models.Rating{UserId: r.userId, MovieId: 1, Rating: &value}); err != nil {
+			t.Fatalf("AddRating() error = %v", err)
+		}
+	}
+	if deleted, err := m.DeleteRating(ctx, &models.Rating{UserId: 2, MovieId: 1}); err != nil || !deleted {
+		t.Fatalf("DeleteRating() deleted = %v, error = %v", deleted, err)
+	}
+
+	movie := &models.Movie{Id: 1}
+	if err := m.GetMovie(ctx, movie); err != nil {
+		t.Fatalf("GetMovie() error = %v", err)
+	}
+	//like in Postgres new ratings only increment vote count, changes add difference to vote sum
+	//and deleted ratings leave votes as they are
+	if movie.VoteCount != 12 {
+		t.Errorf("GetMovie() vote count = %d, want %d", movie.VoteCount, 12)
+	}
+	if aggregate := m.votes[1]; aggregate.sum != -1 {
+		t.Errorf("votes sum = %d, want %d", aggregate.sum, -1)
+	}
+
+	value := 3
+	_, err := m.AddRating(ctx, &models.Rating{UserId: 1, MovieId: 2, Rating: &value})
+	if !errors.Is(err, ErrForeignKeyViolation) {
+		t.Errorf("AddRating() error = %v, want %v", err, ErrForeignKeyViolation)
+	}
+}
+
+func TestMemory_NotFound(t *testing.T) {
+	ctx := context.Background()
+	m := newMemoryWithMovies(t, models.TmdbMovie{Id: 1})
+	comment := &models.Comment{MovieId: 1, UserId: 1, Content: "content"}
+	if err := m.AddMovieComment(ctx, comment); err != nil {
+		t.Fatalf("AddMovieComment() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{
+			name: "negative_get_movie",
+			call: func() error { return m.GetMovie(ctx, &models.Movie{Id: 2}) },
+		},
+		{
+			name: "negative_delete_movie_like",
+			call: func() error { return m.DeleteMovieLike(ctx, 1, 1) },
+		},
+		{
+			name: "negative_update_comment_of_other_user",
+			call: func() error {
+				return m.UpdateComment(ctx, &models.Comment{Id: comment.Id, UserId: 2, Content: "updated"})
+			},
+		},
+		{
+			name: "negative_get_credits",
+			call: func() error { return m.GetCredits(ctx, 1, &models.Credit{}) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !errors.Is(err, ErrNotFound) {
+				t.Errorf("error = %v, want %v", err, ErrNotFound)
+			}
+		})
+	}
+}
+
+func TestMemory_ConcurrentLikes(t *testing.T) {
+	ctx := context.Background()
+	m := newMemoryWithMovies(t, models.TmdbMovie{Id: 1})
+
+	wg := sync.WaitGroup{}
+	for userId := 1; userId <= 50; userId++ {
+		wg.Add(1)
+		go func(userId int) {
+			defer wg.Done()
+			_ = m.LikeMovie(ctx, userId, 1)
+			_, _ = m.ListLikedMovies(ctx, userId, &models.PaginationParams{})
+		}(userId)
+	}
+	wg.Wait()
+
+	if err := m.RefreshCommunityRankings(ctx, 0); err != nil {
+		t.Fatalf("RefreshCommunityRankings() error = %v", err)
+	}
+	if len(m.likedMovies) != 50 {
+		t.Errorf("liked movies = %d, want %d", len(m.likedMovies), 50)
+	}
+}
+
+func TestMemory_AddMovie_keepsProvider(t *testing.T) {
+	tests := []struct {
+		name         string
+		stored       string
+		added        string
+		wantProvider string
+	}{
+		{
+			name:         "positive_keeps_stored_provider",
+			stored:       "tmdb",
+			added:        "omdb",
+			wantProvider: "tmdb",
+		},
+		{
+			name:         "positive_sets_provider_when_stored_without_it",
+			added:        "omdb",
+			wantProvider: "omdb",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMemoryWithMovies(t, models.TmdbMovie{Id: 1, Provider: tt.stored})
+
+			if err := m.AddMovie(context.Background(), &models.TmdbMovie{Id: 1, Provider: tt.added}); err != nil {
+				t.Fatalf("AddMovie() error = %v", err)
+			}
+			if provider := m.movies[1].Provider; provider != tt.wantProvider {
+				t.Errorf("AddMovie() provider = %q, want %q", provider, tt.wantProvider)
+			}
+		})
+	}
+}
+
+func TestMemory_AddRating_change(t *testing.T) {
+	tests := []struct {
+		name       string
+		stored     int
+		rating     int
+		wantChange RatingChange
+	}{
+		{
+			name:       "positive_rating_added",
+			rating:     5,
+			wantChange: RatingAdded,
+		},
+		{
+			name:       "positive_rating_changed",
+			stored:     3,
+			rating:     5,
+			wantChange: RatingChanged,
+		},
+		{
+			name:       "positive_rating_unchanged",
+			stored:     5,
+			rating:     5,
+			wantChange: RatingUnchanged,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m := newMemoryWithMovies(t, models.TmdbMovie{Id: 1})
+			if tt.stored != 0 {
+				if _, err := m.AddRating(ctx, &models.Rating{UserId: 1, MovieId: 1, Rating: &tt.stored}); err != nil {
+					t.Fatalf("AddRating() error = %v", err)
+				}
+			}
+
+			change, err := m.AddRating(ctx, &models.Rating{UserId: 1, MovieId: 1, Rating: &tt.rating})
+			if err != nil {
+				t.Fatalf("AddRating() error = %v", err)
+			}
+			if change != tt.wantChange {
+				t.Errorf("AddRating() change = %v, want %v", change, tt.wantChange)
+			}
+		})
+	}
+}
+
+func TestMemory_checkContext(t *testing.T) {
+	tests := []struct {
+		name    string
+		ctx     func() context.Context
+		wantErr error
+	}{
+		{
+			name: "positive_active_context",
+			ctx:  context.Background,
+		},
+		{
+			name: "negative_cancelled_context",
+			ctx: func() context.Context {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx
+			},
+			wantErr: ErrUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMemoryWithMovies(t, models.TmdbMovie{Id: 1})
+
+			err := m.GetMovie(tt.ctx(), &models.Movie{Id: 1})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetMovie() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMemory_DeleteComment_notMatched(t *testing.T) {
+	tests := []struct {
+		name    string
+		comment *models.Comment
+	}{
+		{
+			name:    "positive_missing_comment",
+			comment: &models.Comment{Id: 2, UserId: 1},
+		},
+		{
+			name:    "positive_comment_of_other_user",
+			comment: &models.Comment{Id: 1, UserId: 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m := newMemoryWithMovies(t, models.TmdbMovie{Id: 1})
+			if err := m.AddMovieComment(ctx, &models.Comment{MovieId: 1, UserId: 1, Content: "content"}); err != nil {
+				t.Fatalf("AddMovieComment() error = %v", err)
+			}
+
+			//like DELETE of Postgres matching no rows, nothing is deleted and no error is returned
+			if deleted, err := m.DeleteComment(ctx, tt.comment); err != nil || deleted {
+				t.Errorf("DeleteComment() deleted = %v, error = %v", deleted, err)
+			}
+			if _, ok := m.comments[1]; !ok {
+				t.Errorf("DeleteComment() deleted comment 1")
+			}
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/BarTar213/movies-service/metrics"
+	"github.com/BarTar213/movies-service/models"
+	"github.com/go-pg/pg/v10"
+)
+
+// BatchRelationsError lists relations which couldn't be stored for batch of movies, movies are then stored one by one
+type BatchRelationsError struct {
+	Movies int
+	Errors []*RelationError
+}
+
+func (e *BatchRelationsError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("batch of %d movies relations: %s", e.Movies, strings.Join(msgs, "; "))
+}
+
+// AddMovies upserts batch of movies in single transaction using multi row statements,
+// related rows shared by movies are inserted once and the last occurrence of duplicated movie wins.
+// When relations of batch can't be stored, movies are stored one by one, so only movies with broken relations are lost
+func (p *Postgres) AddMovies(ctx context.Context, movies []models.TmdbMovie) error {
+	batch := uniqueMovies(movies)
+	if len(batch) == 0 {
+		return nil
+	}
+	start := time.Now()
+
+	ids := make([]interface{}, 0, len(batch))
+	for _, movie := range batch {
+		ids = append(ids, movie.Id)
+	}
+
+	err := p.db.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		_, err := upsertMovies(tx.Model(&batch)).Insert()
+		if err != nil {
+			return err
+		}
+
+		errs := upsertRelations(tx, batchRelations(batch), func(r *relation) error {
+			return insertBatchRelation(tx, ids, r)
+		})
+		if len(errs) > 0 {
+			return &BatchRelationsError{Movies: len(batch), Errors: errs}
+		}
+		return nil
+	})
+	relationsErr := &BatchRelationsError{}
+	if errors.As(err, &relationsErr) {
+		stored, err := p.addMoviesOneByOne(ctx, batch)
+		p.observeBatch(stored, len(batch)-stored, time.Since(start))
+		return err
+	}
+
+	stored := len(batch)
+	if err != nil {
+		stored = 0
+	}
+	p.observeBatch(stored, len(batch)-stored, time.Since(start))
+
+	return wrapError(err, moviesResource)
+}
+
+// stores each movie in its own transaction, returns number of stored movies and errors of the rest
+func (p *Postgres) addMoviesOneByOne(ctx context.Context, movies []*models.TmdbMovie) (int, error) {
+	stored := 0
+	errs := make([]error, 0)
+	for _, movie := range movies {
+		err := p.addMovie(ctx, movie)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		stored++
+	}
+
+	return stored, errors.Join(errs...)
+}
+
+func uniqueMovies(movies []models.TmdbMovie) []*models.TmdbMovie {
+	positions := make(map[int]int, len(movies))
+	unique := make([]*models.TmdbMovie, 0, len(movies))
+	for i := range movies {
+		position, ok := positions[movies[i].Id]
+		if ok {
+			unique[position] = &movies[i]
+			continue
+		}
+		positions[movies[i].Id] = len(unique)
+		unique = append(unique, &movies[i])
+	}
+
+	return unique
+}
+
+// relations of batch hold related rows without duplicates and (movie_id, related key) pairs of links as keys
+func batchRelations(movies []*models.TmdbMovie) []*relation {
+	genres := make([]*models.Genre, 0)
+	genreLinks := make([]*models.MovieGenre, 0)
+	genreKeys := make([]interface{}, 0)
+	seenGenres := make(map[int]bool)
+	seenGenreLinks := make(map[models.MovieGenre]bool)
+
+	countries := make([]*models.Country, 0)
+	countryLinks := make([]*models.MovieCountry, 0)
+	countryKeys := make([]interface{}, 0)
+	seenCountries := make(map[string]bool)
+	seenCountryLinks := make(map[models.MovieCountry]bool)
+
+	companies := make([]*models.Company, 0)
+	companyLinks := make([]*models.MovieCompany, 0)
+	companyKeys := make([]interface{}, 0)
+	seenCompanies := make(map[int]bool)
+	seenCompanyLinks := make(map[models.MovieCompany]bool)
+
+	languages := make([]*models.Language, 0)
+	languageLinks := make([]*models.MovieLanguage, 0)
+	languageKeys := make([]interface{}, 0)
+	seenLanguages := make(map[string]bool)
+	seenLanguageLinks := make(map[models.MovieLanguage]bool)
+
+	for _, movie := range movies {
+		for _, genre := range movie.Genres {
+			if !seenGenres[genre.Id] {
+				seenGenres[genre.Id] = true
+				genres = append(genres, genre)
+			}
+			link := models.MovieGenre{MovieId: movie.Id, GenreId: genre.Id}
+			if !seenGenreLinks[link] {
+				seenGenreLinks[link] = true
+				genreLinks = append(genreLinks, &link)
+				genreKeys = append(genreKeys, []interface{}{movie.Id, genre.Id})
+			}
+		}
+
+		for _, country := range movie.Countries {
+			if !seenCountries[country.Code] {
+				seenCountries[country.Code] = true
+				countries = append(countries, country)
+			}
+			link := models.MovieCountry{MovieId: movie.Id, CountryCode: country.Code}
+			if !seenCountryLinks[link] {
+				seenCountryLinks[link] = true
+				countryLinks = append(countryLinks, &link)
+				countryKeys = append(countryKeys, []interface{}{movie.Id, country.Code})
+			}
+		}
+
+		for _, company := range movie.Companies {
+			if !seenCompanies[company.Id] {
+				seenCompanies[company.Id] = true
+				companies = append(companies, company)
+			}
+			link := models.MovieCompany{MovieId: movie.Id, CompanyId: company.Id}
+			if !seenCompanyLinks[link] {
+				seenCompanyLinks[link] = true
+				companyLinks = append(companyLinks, &link)
+				companyKeys = append(companyKeys, []interface{}{movie.Id, company.Id})
+			}
+		}
+
+		for _, language := range movie.Languages {
+			if !seenLanguages[language.IsoCode] {
+				seenLanguages[language.IsoCode] = true
+				languages = append(languages, language)
+			}
+			link := models.MovieLanguage{MovieId: movie.Id, IsoCode: language.IsoCode}
+			if !seenLanguageLinks[link] {
+				seenLanguageLinks[link] = true
+				languageLinks = append(languageLinks, &link)
+				languageKeys = append(languageKeys, []interface{}{movie.Id, language.IsoCode})
+			}
+		}
+	}
+
+	return []*relation{
+		{name: "genres", related: &genres, links: &genreLinks, table: "movie_genres", column: "genre_id", keys: genreKeys},
+		{name: "countries", related: &countries, links: &countryLinks, table: "movie_countries", column: "country_code", keys: countryKeys},
+		{name: "companies", related: &companies, links: &companyLinks, table: "movie_companies", column: "company_id", keys: companyKeys},
+		{name: "languages", related: &languages, links: &languageLinks, table: "movie_languages", column: "language_iso_639_1", keys: languageKeys},
+	}
+}
+
+func insertBatchRelation(tx *pg.Tx, movieIds []interface{}, r *relation) error {
+	if len(r.keys) > 0 {
+		_, err := tx.Model(r.related).OnConflict(doNothingStatement).Insert()
+		if err != nil {
+			return err
+		}
+		_, err = tx.Model(r.links).OnConflict(doNothingStatement).Insert()
+		if err != nil {
+			return err
+		}
+	}
+
+	query, params := staleBatchLinks(movieIds, r)
+	_, err := tx.Exec(query, params...)
+	return err
+}
+
+// statement removing links of batch movies which aren't listed in keys of relation,
+// all links of movies are removed when relation has no keys
+func staleBatchLinks(movieIds []interface{}, r *relation) (string, []interface{}) {
+	if len(r.keys) == 0 {
+		return "DELETE FROM ? WHERE movie_id IN (?)", []interface{}{pg.Ident(r.table), pg.In(movieIds)}
+	}
+
+	return "DELETE FROM ? WHERE movie_id IN (?) AND (movie_id, ?) NOT IN (?)",
+		[]interface{}{pg.Ident(r.table), pg.In(movieIds), pg.Ident(r.column), pg.InMulti(r.keys...)}
+}
+
+func (p *Postgres) observeBatch(stored, failed int, duration time.Duration) {
+	if p.metrics == nil {
+		return
+	}
+
+	p.observeIngested(stored, failed)
+	p.metrics.IngestionBatchDuration.Observe(duration.Seconds())
+	p.metrics.IngestionBatchSize.Observe(float64(stored + failed))
+}
+
+// movies stored one by one and in batches are counted by the same metric
+func (p *Postgres) observeIngested(stored, failed int) {
+	if p.metrics == nil {
+		return
+	}
+
+	p.metrics.IngestedMovies.WithLabelValues(metrics.StoredOutcome).Add(float64(stored))
+	p.metrics.IngestedMovies.WithLabelValues(metrics.FailedOutcome).Add(float64(failed))
+}
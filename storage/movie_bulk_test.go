@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/BarTar213/movies-service/models"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+func Test_uniqueMovies(t *testing.T) {
+	tests := []struct {
+		name       string
+		movies     []models.TmdbMovie
+		wantIds    []int
+		wantTitles []string
+	}{
+		{
+			name:       "positive_no_duplicates",
+			movies:     []models.TmdbMovie{{Id: 1, Title: "first"}, {Id: 2, Title: "second"}},
+			wantIds:    []int{1, 2},
+			wantTitles: []string{"first", "second"},
+		},
+		{
+			name:       "positive_last_duplicate_wins_in_place_of_first",
+			movies:     []models.TmdbMovie{{Id: 1, Title: "old"}, {Id: 2, Title: "second"}, {Id: 1, Title: "new"}},
+			wantIds:    []int{1, 2},
+			wantTitles: []string{"new", "second"},
+		},
+		{
+			name:       "positive_empty",
+			movies:     []models.TmdbMovie{},
+			wantIds:    []int{},
+			wantTitles: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unique := uniqueMovies(tt.movies)
+
+			ids := make([]int, 0, len(unique))
+			titles := make([]string, 0, len(unique))
+			for _, movie := range unique {
+				ids = append(ids, movie.Id)
+				titles = append(titles, movie.Title)
+			}
+			if !reflect.DeepEqual(ids, tt.wantIds) || !reflect.DeepEqual(titles, tt.wantTitles) {
+				t.Errorf("uniqueMovies() ids = %v, titles = %v, want %v, %v", ids, titles, tt.wantIds, tt.wantTitles)
+			}
+		})
+	}
+}
+
+func Test_batchRelations(t *testing.T) {
+	drama := &models.Genre{Id: 18, Name: "Drama"}
+	comedy := &models.Genre{Id: 35, Name: "Comedy"}
+	english := &models.Language{IsoCode: "en"}
+	movies := []*models.TmdbMovie{
+		{Id: 1, Genres: []*models.Genre{drama, comedy, drama}, Languages: []*models.Language{english}},
+		{Id: 2, Genres: []*models.Genre{drama}, Languages: []*models.Language{english}},
+	}
+
+	relations := batchRelations(movies)
+
+	genres := *relations[0].related.(*[]*models.Genre)
+	if want := []*models.Genre{drama, comedy}; !reflect.DeepEqual(genres, want) {
+		t.Errorf("batchRelations() genres = %v, want shared genres once %v", genres, want)
+	}
+	genreLinks := *relations[0].links.(*[]*models.MovieGenre)
+	wantGenreLinks := []*models.MovieGenre{{MovieId: 1, GenreId: 18}, {MovieId: 1, GenreId: 35}, {MovieId: 2, GenreId: 18}}
+	if !reflect.DeepEqual(genreLinks, wantGenreLinks) {
+		t.Errorf("batchRelations() genre links = %v, want %v", genreLinks, wantGenreLinks)
+	}
+	wantGenreKeys := []interface{}{[]interface{}{1, 18}, []interface{}{1, 35}, []interface{}{2, 18}}
+	if !reflect.DeepEqual(relations[0].keys, wantGenreKeys) {
+		t.Errorf("batchRelations() genre keys = %v, want %v", relations[0].keys, wantGenreKeys)
+	}
+
+	languages := *relations[3].related.(*[]*models.Language)
+	if want := []*models.Language{english}; !reflect.DeepEqual(languages, want) {
+		t.Errorf("batchRelations() languages = %v, want shared languages once %v", languages, want)
+	}
+	if len(relations[1].keys) != 0 || len(relations[2].keys) != 0 {
+		t.Errorf("batchRelations() keys of countries = %v, companies = %v, want empty", relations[1].keys, relations[2].keys)
+	}
+}
+
+func Test_staleBatchLinks(t *testing.T) {
+	tests := []struct {
+		name     string
+		movieIds []interface{}
+		relation *relation
+		want     string
+	}{
+		{
+			name:     "positive_links_not_in_keys_removed",
+			movieIds: []interface{}{1, 2},
+			relation: &relation{table: "movie_genres", column: "genre_id", keys: []interface{}{[]interface{}{1, 18}, []interface{}{2, 35}}},
+			want:     `DELETE FROM "movie_genres" WHERE movie_id IN (1,2) AND (movie_id, "genre_id") NOT IN ((1,18),(2,35))`,
+		},
+		{
+			name:     "positive_text_keys_quoted",
+			movieIds: []interface{}{1},
+			relation: &relation{table: "movie_countries", column: "country_code", keys: []interface{}{[]interface{}{1, "US"}}},
+			want:     `DELETE FROM "movie_countries" WHERE movie_id IN (1) AND (movie_id, "country_code") NOT IN ((1,'US'))`,
+		},
+		{
+			name:     "positive_empty_keys_remove_all_links",
+			movieIds: []interface{}{1, 2},
+			relation: &relation{table: "movie_genres", column: "genre_id", keys: []interface{}{}},
+			want:     `DELETE FROM "movie_genres" WHERE movie_id IN (1,2)`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, params := staleBatchLinks(tt.movieIds, tt.relation)
+			if got := string(orm.NewFormatter().FormatQuery(nil, query, params...)); got != tt.want {
+				t.Errorf("staleBatchLinks() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,159 +1,195 @@
 package storage
 
 import (
-	"errors"
+	"context"
 	"fmt"
-	"sync"
+	"strings"
 
 	"github.com/BarTar213/movies-service/models"
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
 )
 
 const (
 	doNothingStatement = "DO NOTHING"
+
+	relationSavepoint = "movie_relation"
 )
 
-func (p *Postgres) AddMovie(movie *models.TmdbMovie) error {
-	_, insertErr := p.db.Model(movie).
-		Relation(genres).
-		Relation("Countries").
-		Relation("Companies").
-		Relation("Languages").
-		OnConflict("(id) DO UPDATE").
-		Set("budget=?budget").
-		Set("poster_path=?poster_path").
-		Set("backdrop_path=?backdrop_path").
-		Set("revenue=?revenue").
-		Set("runtime=?runtime").
-		Set("vote_average=?vote_average").
-		Insert()
-	if insertErr != nil {
-		return insertErr
+// MovieRelationsError lists relations of movie which couldn't be stored, movie isn't stored either then
+type MovieRelationsError struct {
+	MovieId int
+	Errors  []*RelationError
+}
+
+type RelationError struct {
+	Relation string
+	Err      error
+}
+
+func (e *MovieRelationsError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
 	}
+	return fmt.Sprintf("movie %d relations: %s", e.MovieId, strings.Join(msgs, "; "))
+}
 
-	wg := sync.WaitGroup{}
-	errs := sync.Map{}
+func (e *RelationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Relation, e.Err)
+}
 
-	wg.Add(1)
-	go p.addGenres(movie, &wg, &errs)
+func (e *RelationError) Unwrap() error {
+	return e.Err
+}
 
-	wg.Add(1)
-	go p.addCountries(movie, &wg, &errs)
+// execer runs statements of transaction, it's satisfied by *pg.Tx
+type execer interface {
+	Exec(query interface{}, params ...interface{}) (pg.Result, error)
+}
 
-	wg.Add(1)
-	go p.addCompanies(movie, &wg, &errs)
+// relation describes many to many relation of movie, related rows are inserted before links pointing to them
+type relation struct {
+	name string
+	// pointers to slices of related rows and link rows
+	related interface{}
+	links   interface{}
+	// link table and its column referencing related rows
+	table  string
+	column string
+	keys   []interface{}
+}
 
-	wg.Add(1)
-	go p.addLanguages(movie, &wg, &errs)
+// AddMovie upserts movie together with its relations in single transaction,
+// links which movie doesn't have anymore are removed
+func (p *Postgres) AddMovie(ctx context.Context, movie *models.TmdbMovie) error {
+	err := p.addMovie(ctx, movie)
+	if err != nil {
+		p.observeIngested(0, 1)
+	} else {
+		p.observeIngested(1, 0)
+	}
 
-	wg.Wait()
-	errMsg := ""
-	errs.Range(func(key, value interface{}) bool {
-		errMsg += fmt.Sprintf("%v: %v\n", key, value)
-		return false
-	})
-	if len(errMsg) == 0 {
+	return err
+}
+
+func (p *Postgres) addMovie(ctx context.Context, movie *models.TmdbMovie) error {
+	err := p.db.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		_, err := upsertMovies(tx.Model(movie)).Insert()
+		if err != nil {
+			return err
+		}
+
+		errs := upsertRelations(tx, movieRelations(movie), func(r *relation) error {
+			return insertRelation(tx, movie.Id, r)
+		})
+		if len(errs) > 0 {
+			return &MovieRelationsError{MovieId: movie.Id, Errors: errs}
+		}
 		return nil
-	}
-	return errors.New(errMsg)
+	})
+
+	return wrapError(err, moviesResource)
 }
 
-func (p *Postgres) addGenres(movie *models.TmdbMovie, wg *sync.WaitGroup, errs *sync.Map) {
-	defer wg.Done()
-	if len(movie.Genres) == 0 {
-		return
-	}
+// upsertMovies refreshes details of stored movies, provider of record is kept
+// and set only for movies which were stored without it
+func upsertMovies(q *orm.Query) *orm.Query {
+	return q.OnConflict("(id) DO UPDATE").
+		Set("budget=EXCLUDED.budget").
+		Set("poster_path=EXCLUDED.poster_path").
+		Set("backdrop_path=EXCLUDED.backdrop_path").
+		Set("revenue=EXCLUDED.revenue").
+		Set("runtime=EXCLUDED.runtime").
+		Set("vote_average=EXCLUDED.vote_average").
+		Set("provider=COALESCE(NULLIF(movie.provider, ''), EXCLUDED.provider)")
+}
 
+func movieRelations(movie *models.TmdbMovie) []*relation {
 	genres := make([]*models.MovieGenre, 0, len(movie.Genres))
+	genreIds := make([]interface{}, 0, len(movie.Genres))
 	for _, genre := range movie.Genres {
-		genres = append(genres, &models.MovieGenre{
-			MovieId: movie.Id,
-			GenreId: genre.Id,
-		})
+		genres = append(genres, &models.MovieGenre{MovieId: movie.Id, GenreId: genre.Id})
+		genreIds = append(genreIds, genre.Id)
 	}
-	_, err := p.db.Model(&genres).OnConflict(doNothingStatement).Insert()
-	if err != nil {
-		errs.Store("genres", err)
 
-		err = nil
-		_, err := p.db.Model(&(movie.Genres)).OnConflict(doNothingStatement).Insert()
-		if err != nil{
-			fmt.Printf("Additional genres: %s", err)
-		}
+	countries := make([]*models.MovieCountry, 0, len(movie.Countries))
+	countryCodes := make([]interface{}, 0, len(movie.Countries))
+	for _, country := range movie.Countries {
+		countries = append(countries, &models.MovieCountry{MovieId: movie.Id, CountryCode: country.Code})
+		countryCodes = append(countryCodes, country.Code)
 	}
-}
 
-func (p *Postgres) addCountries(movie *models.TmdbMovie, wg *sync.WaitGroup, errs *sync.Map) {
-	defer wg.Done()
-	if len(movie.Countries) == 0 {
-		return
+	companies := make([]*models.MovieCompany, 0, len(movie.Companies))
+	companyIds := make([]interface{}, 0, len(movie.Companies))
+	for _, company := range movie.Companies {
+		companies = append(companies, &models.MovieCompany{MovieId: movie.Id, CompanyId: company.Id})
+		companyIds = append(companyIds, company.Id)
 	}
 
-	countries := make([]*models.MovieCountry, 0, len(movie.Countries))
-	for _, country := range movie.Countries {
-		countries = append(countries, &models.MovieCountry{
-			MovieId:     movie.Id,
-			CountryCode: country.Code,
-		})
+	languages := make([]*models.MovieLanguage, 0, len(movie.Languages))
+	languageCodes := make([]interface{}, 0, len(movie.Languages))
+	for _, language := range movie.Languages {
+		languages = append(languages, &models.MovieLanguage{MovieId: movie.Id, IsoCode: language.IsoCode})
+		languageCodes = append(languageCodes, language.IsoCode)
 	}
-	_, err := p.db.Model(&countries).OnConflict(doNothingStatement).Insert()
-	if err != nil {
-		fmt.Printf("countries: %v\n", movie.Countries)
-		errs.Store("countries", err)
 
-		err = nil
-		_, err := p.db.Model(&(movie.Countries)).OnConflict(doNothingStatement).Insert()
-		if err != nil{
-			fmt.Printf("Additional countires: %s", err)
-		}
+	return []*relation{
+		{name: "genres", related: &movie.Genres, links: &genres, table: "movie_genres", column: "genre_id", keys: genreIds},
+		{name: "countries", related: &movie.Countries, links: &countries, table: "movie_countries", column: "country_code", keys: countryCodes},
+		{name: "companies", related: &movie.Companies, links: &companies, table: "movie_companies", column: "company_id", keys: companyIds},
+		{name: "languages", related: &movie.Languages, links: &languages, table: "movie_languages", column: "language_iso_639_1", keys: languageCodes},
 	}
 }
 
-func (p *Postgres) addCompanies(movie *models.TmdbMovie, wg *sync.WaitGroup, errs *sync.Map) {
-	defer wg.Done()
-	if len(movie.Companies) == 0 {
-		return
+// each relation is upserted within savepoint, so failure of one relation doesn't abort transaction
+// and errors of remaining relations are reported as well
+func upsertRelations(tx execer, relations []*relation, insert func(r *relation) error) []*RelationError {
+	errs := make([]*RelationError, 0)
+	for _, r := range relations {
+		err := upsertRelation(tx, r, insert)
+		if err != nil {
+			errs = append(errs, &RelationError{Relation: r.name, Err: err})
+		}
 	}
+	return errs
+}
 
-	companies := make([]*models.MovieCompany, 0, len(movie.Companies))
-	for _, company := range movie.Companies {
-		companies = append(companies, &models.MovieCompany{
-			MovieId:   movie.Id,
-			CompanyId: company.Id,
-		})
-	}
-	_, err := p.db.Model(&companies).OnConflict(doNothingStatement).Insert()
+func upsertRelation(tx execer, r *relation, insert func(r *relation) error) error {
+	_, err := tx.Exec("SAVEPOINT " + relationSavepoint)
 	if err != nil {
-		errs.Store("companies", err)
+		return err
+	}
 
-		err = nil
-		_, err := p.db.Model(&(movie.Companies)).OnConflict(doNothingStatement).Insert()
-		if err != nil{
-			fmt.Printf("Additional companies: %s", err)
+	err = insert(r)
+	if err != nil {
+		_, rollbackErr := tx.Exec("ROLLBACK TO SAVEPOINT " + relationSavepoint)
+		if rollbackErr != nil {
+			return rollbackErr
 		}
+		return err
 	}
+
+	_, err = tx.Exec("RELEASE SAVEPOINT " + relationSavepoint)
+	return err
 }
 
-func (p *Postgres) addLanguages(movie *models.TmdbMovie, wg *sync.WaitGroup, errs *sync.Map) {
-	defer wg.Done()
-	if len(movie.Languages) == 0 {
-		return
+func insertRelation(tx *pg.Tx, movieId int, r *relation) error {
+	if len(r.keys) == 0 {
+		_, err := tx.Exec("DELETE FROM ? WHERE movie_id = ?", pg.Ident(r.table), movieId)
+		return err
 	}
 
-	languages := make([]*models.MovieLanguage, 0, len(movie.Languages))
-	for _, language := range movie.Languages {
-		languages = append(languages, &models.MovieLanguage{
-			MovieId: movie.Id,
-			IsoCode: language.IsoCode,
-		})
+	_, err := tx.Model(r.related).OnConflict(doNothingStatement).Insert()
+	if err != nil {
+		return err
 	}
-	_, err := p.db.Model(&languages).OnConflict(doNothingStatement).Insert()
+	_, err = tx.Model(r.links).OnConflict(doNothingStatement).Insert()
 	if err != nil {
-		errs.Store("languages", err)
-
-		err = nil
-		_, err := p.db.Model(&(movie.Languages)).OnConflict(doNothingStatement).Insert()
-		if err != nil{
-			fmt.Printf("Additional languages: %s", err)
-		}
+		return err
 	}
+	_, err = tx.Exec("DELETE FROM ? WHERE movie_id = ? AND ? NOT IN (?)",
+		pg.Ident(r.table), movieId, pg.Ident(r.column), pg.In(r.keys))
+
+	return err
 }
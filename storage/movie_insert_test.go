@@ -0,0 +1,198 @@
+package storage
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/BarTar213/movies-service/models"
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// execer recording statements, statements listed in fail return error
+type fakeExecer struct {
+	statements []string
+	fail       map[string]error
+}
+
+func (e *fakeExecer) Exec(query interface{}, params ...interface{}) (pg.Result, error) {
+	statement := query.(string)
+	e.statements = append(e.statements, statement)
+	return nil, e.fail[statement]
+}
+
+func init() {
+	orm.RegisterTable((*models.MovieCompany)(nil))
+	orm.RegisterTable((*models.MovieCountry)(nil))
+	orm.RegisterTable((*models.MovieGenre)(nil))
+	orm.RegisterTable((*models.MovieLanguage)(nil))
+}
+
+func Test_upsertMovies(t *testing.T) {
+	tests := []struct {
+		name  string
+		model interface{}
+	}{
+		{
+			name:  "positive_single_movie",
+			model: &models.TmdbMovie{Id: 1, Provider: "tmdb"},
+		},
+		{
+			name:  "positive_batch_of_movies",
+			model: &[]*models.TmdbMovie{{Id: 1, Provider: "tmdb"}, {Id: 2, Provider: "omdb"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := orm.NewInsertQuery(upsertMovies(orm.NewQuery(nil, tt.model))).AppendQuery(orm.NewFormatter(), nil)
+			if err != nil {
+				t.Fatalf("upsertMovies() error = %v", err)
+			}
+			query := string(b)
+
+			if !strings.Contains(query, "provider=COALESCE(NULLIF(movie.provider, ''), EXCLUDED.provider)") {
+				t.Errorf("upsertMovies() = %s, want stored provider kept on conflict", query)
+			}
+			if strings.Contains(query, "provider=EXCLUDED.provider") {
+				t.Errorf("upsertMovies() = %s, overwrites stored provider", query)
+			}
+		})
+	}
+}
+
+func Test_movieRelations(t *testing.T) {
+	movie := &models.TmdbMovie{
+		Id:        1,
+		Genres:    []*models.Genre{{Id: 18}, {Id: 35}},
+		Countries: []*models.Country{{Code: "US"}},
+		Languages: []*models.Language{{IsoCode: "en"}},
+	}
+
+	relations := movieRelations(movie)
+
+	wantKeys := map[string][]interface{}{
+		"genres":    {18, 35},
+		"countries": {"US"},
+		"companies": {},
+		"languages": {"en"},
+	}
+	if len(relations) != len(wantKeys) {
+		t.Fatalf("movieRelations() relations = %d, want %d", len(relations), len(wantKeys))
+	}
+	for _, r := range relations {
+		if !reflect.DeepEqual(r.keys, wantKeys[r.name]) {
+			t.Errorf("movieRelations() %s keys = %v, want %v", r.name, r.keys, wantKeys[r.name])
+		}
+	}
+
+	wantGenres := []*models.MovieGenre{{MovieId: 1, GenreId: 18}, {MovieId: 1, GenreId: 35}}
+	if genres := *relations[0].links.(*[]*models.MovieGenre); !reflect.DeepEqual(genres, wantGenres) {
+		t.Errorf("movieRelations() genre links = %v, want %v", genres, wantGenres)
+	}
+	wantLanguages := []*models.MovieLanguage{{MovieId: 1, IsoCode: "en"}}
+	if languages := *relations[3].links.(*[]*models.MovieLanguage); !reflect.DeepEqual(languages, wantLanguages) {
+		t.Errorf("movieRelations() language links = %v, want %v", languages, wantLanguages)
+	}
+}
+
+func TestMovieRelationsError_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *MovieRelationsError
+		want string
+	}{
+		{
+			name: "single_relation",
+			err: &MovieRelationsError{MovieId: 1, Errors: []*RelationError{
+				{Relation: "genres", Err: errors.New("failed")},
+			}},
+			want: "movie 1 relations: genres: failed",
+		},
+		{
+			name: "many_relations",
+			err: &MovieRelationsError{MovieId: 2, Errors: []*RelationError{
+				{Relation: "genres", Err: errors.New("failed")},
+				{Relation: "languages", Err: errors.New("timeout")},
+			}},
+			want: "movie 2 relations: genres: failed; languages: timeout",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.want {
+				t.Errorf("Error() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_upsertRelations(t *testing.T) {
+	errInsert := errors.New("insert failed")
+	errSavepoint := errors.New("savepoint failed")
+
+	tests := []struct {
+		name           string
+		failInsert     map[string]bool
+		failExec       map[string]error
+		wantErrs       map[string]error
+		wantStatements []string
+	}{
+		{
+			name: "positive_all_relations_stored",
+			wantStatements: []string{
+				"SAVEPOINT movie_relation", "RELEASE SAVEPOINT movie_relation",
+				"SAVEPOINT movie_relation", "RELEASE SAVEPOINT movie_relation",
+			},
+		},
+		{
+			name:       "negative_errors_of_all_relations_collected",
+			failInsert: map[string]bool{"genres": true, "languages": true},
+			wantErrs:   map[string]error{"genres": errInsert, "languages": errInsert},
+			wantStatements: []string{
+				"SAVEPOINT movie_relation", "ROLLBACK TO SAVEPOINT movie_relation",
+				"SAVEPOINT movie_relation", "ROLLBACK TO SAVEPOINT movie_relation",
+			},
+		},
+		{
+			name:     "negative_savepoint_failed",
+			failExec: map[string]error{"SAVEPOINT movie_relation": errSavepoint},
+			wantErrs: map[string]error{"genres": errSavepoint, "languages": errSavepoint},
+			wantStatements: []string{
+				"SAVEPOINT movie_relation",
+				"SAVEPOINT movie_relation",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := &fakeExecer{fail: tt.failExec}
+			relations := []*relation{{name: "genres"}, {name: "languages"}}
+
+			inserted := make([]string, 0)
+			errs := upsertRelations(tx, relations, func(r *relation) error {
+				inserted = append(inserted, r.name)
+				if tt.failInsert[r.name] {
+					return errInsert
+				}
+				return nil
+			})
+
+			if len(errs) != len(tt.wantErrs) {
+				t.Fatalf("upsertRelations() errors = %v, want %v", errs, tt.wantErrs)
+			}
+			for _, err := range errs {
+				if !errors.Is(err, tt.wantErrs[err.Relation]) {
+					t.Errorf("upsertRelations() %s error = %v, want %v", err.Relation, err.Err, tt.wantErrs[err.Relation])
+				}
+			}
+			if !reflect.DeepEqual(tx.statements, tt.wantStatements) {
+				t.Errorf("upsertRelations() statements = %v, want %v", tx.statements, tt.wantStatements)
+			}
+			if len(tt.failExec) == 0 && len(inserted) != len(relations) {
+				t.Errorf("upsertRelations() inserted = %v, want all relations", inserted)
+			}
+		})
+	}
+}
@@ -1,12 +1,15 @@
 package storage
 
 import (
+	"context"
+
 	"github.com/BarTar213/movies-service/models"
 	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
 )
 
-func (p *Postgres) GetMovie(movie *models.Movie) error {
-	err := p.db.Model(movie).
+func (p *Postgres) GetMovie(ctx context.Context, movie *models.Movie) error {
+	err := p.db.ModelContext(ctx, movie).
 		WherePK().
 		Relation(genres).
 		Relation("Countries").
@@ -14,48 +17,69 @@ func (p *Postgres) GetMovie(movie *models.Movie) error {
 		Relation("Languages").
 		Select()
 
-	return err
+	return wrapError(err, moviesResource)
 }
 
-func (p *Postgres) ListMovies(title string, params *models.PaginationParams) ([]models.MoviePreview, error) {
+func (p *Postgres) ListMovies(ctx context.Context, filters *models.MovieFilters, params *models.PaginationParams) ([]models.MoviePreview, error) {
 	movies := make([]models.MoviePreview, 0)
-	err := p.db.Model(&movies).
+	query := p.db.ModelContext(ctx, &movies).
 		ExcludeColumn("rating").
-		Where("title like ?", title).
+		WhereGroup(func(q *orm.Query) (*orm.Query, error) {
+			q.Where("title like ?", filters.Title)
+			if len(filters.Language) > 0 {
+				q.WhereOr(`EXISTS (SELECT 1
+					FROM movie_translations t
+					WHERE t.movie_id = movie_preview.id AND t.iso_639_1 = ? AND t.title like ?)`, filters.Language, filters.Title)
+			}
+			return q, nil
+		})
+
+	if filters.CastId > 0 {
+		query.Where(`EXISTS (SELECT 1
+			FROM casts c JOIN credits cr ON cr.id = c.credit_id
+			WHERE cr.movie_id = movie_preview.id AND c.id = ?)`, filters.CastId)
+	}
+	if filters.CrewId > 0 {
+		query.Where(`EXISTS (SELECT 1
+			FROM crews c JOIN credits cr ON cr.id = c.credit_id
+			WHERE cr.movie_id = movie_preview.id AND c.id = ?)`, filters.CrewId)
+	}
+
+	err := query.
 		Order(params.OrderBy).
 		Offset(params.Offset).
 		Limit(params.Limit).
 		Select()
 
-	return movies, err
+	return movies, wrapError(err, moviesResource)
 }
 
-func (p *Postgres) ListMoviesFromIDs(IDs []int) ([]models.MoviePreview, error) {
+func (p *Postgres) ListMoviesFromIDs(ctx context.Context, IDs []int) ([]models.MoviePreview, error) {
 	movies := make([]models.MoviePreview, 0)
-	err := p.db.Model(&movies).
+	err := p.db.ModelContext(ctx, &movies).
 		ExcludeColumn("rating").
 		Join("JOIN unnest(?::int[]) WITH ORDINALITY t(id, ord) USING (id)", pg.Array(IDs)).
 		Order("t.ord").
 		Select()
 
-	return movies, err
+	return movies, wrapError(err, moviesResource)
 }
 
-func (p *Postgres) LikeMovie(userId int, movieId int) error {
-	_, err := p.db.ExecOne("INSERT INTO liked_movies (user_id, movie_id) values (?, ?)", userId, movieId)
+func (p *Postgres) LikeMovie(ctx context.Context, userId int, movieId int) error {
+	_, err := p.db.ExecOneContext(ctx, "INSERT INTO liked_movies (user_id, movie_id) values (?, ?)", userId, movieId)
 
-	return err
+	return wrapError(err, likedMoviesResource)
 }
 
-func (p *Postgres) DeleteMovieLike(userId int, movieId int) error {
-	_, err := p.db.ExecOne("DELETE FROM liked_movies WHERE user_id=? AND movie_id=?", userId, movieId)
+func (p *Postgres) DeleteMovieLike(ctx context.Context, userId int, movieId int) error {
+	_, err := p.db.ExecOneContext(ctx, "DELETE FROM liked_movies WHERE user_id=? AND movie_id=?", userId, movieId)
 
-	return err
+	return wrapError(err, likedMoviesResource)
 }
 
-func (p *Postgres) ListLikedMovies(userId int, params *models.PaginationParams) ([]models.MoviePreview, error) {
+func (p *Postgres) ListLikedMovies(ctx context.Context, userId int, params *models.PaginationParams) ([]models.MoviePreview, error) {
 	movies := make([]models.MoviePreview, 0)
-	err := p.db.Model((*models.LikedMovie)(nil)).
+	err := p.db.ModelContext(ctx, (*models.LikedMovie)(nil)).
 		Column("m.*").
 		Where("user_id=?", userId).
 		Join("LEFT JOIN movies m ON m.id = liked_movie.movie_id").
@@ -64,16 +88,18 @@ func (p *Postgres) ListLikedMovies(userId int, params *models.PaginationParams)
 		Limit(params.Limit).
 		Select(&movies)
 
-	return movies, err
+	return movies, wrapError(err, likedMoviesResource)
 }
 
-func (p *Postgres) CheckLiked(likedMovie *models.LikedMovie) (bool, error) {
-	return p.db.Model(likedMovie).
+func (p *Postgres) CheckLiked(ctx context.Context, likedMovie *models.LikedMovie) (bool, error) {
+	exists, err := p.db.ModelContext(ctx, likedMovie).
 		WherePK().
 		Exists()
+
+	return exists, wrapError(err, likedMoviesResource)
 }
 
-func (p *Postgres) AddRecentViewedMovie(userId int, movieId int) error {
+func (p *Postgres) AddRecentViewedMovie(ctx context.Context, userId int, movieId int) error {
 	deleteQuery := `
 		DELETE
 		FROM user_history
@@ -85,12 +111,12 @@ func (p *Postgres) AddRecentViewedMovie(userId int, movieId int) error {
 		values (?, ?)
 		on conflict (user_id, movie_id) do update set time = now()`
 
-	_, err := p.db.Exec(insertQuery, userId, movieId)
+	_, err := p.db.ExecContext(ctx, insertQuery, userId, movieId)
 	if err != nil {
-		return err
+		return wrapError(err, userHistoryResource)
 	}
 
-	_, err = p.db.Exec(deleteQuery, userId)
+	_, err = p.db.ExecContext(ctx, deleteQuery, userId)
 
-	return err
+	return wrapError(err, userHistoryResource)
 }
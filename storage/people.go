@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/BarTar213/movies-service/models"
+)
+
+func (p *Postgres) GetPerson(ctx context.Context, person *models.Person) error {
+	err := p.db.ModelContext(ctx, person).
+		WherePK().
+		Select()
+
+	return wrapError(err, peopleResource)
+}
+
+func (p *Postgres) AddPerson(ctx context.Context, person *models.Person) error {
+	_, err := p.db.ModelContext(ctx, person).
+		OnConflict("(id) DO UPDATE").
+		Set("name=?name").
+		Set("biography=?biography").
+		Set("birthday=?birthday").
+		Set("deathday=?deathday").
+		Set("place_of_birth=?place_of_birth").
+		Set("gender=?gender").
+		Set("known_for_department=?known_for_department").
+		Set("homepage=?homepage").
+		Set("imdb_id=?imdb_id").
+		Set("popularity=?popularity").
+		Set("profile_path=?profile_path").
+		Insert()
+
+	return wrapError(err, peopleResource)
+}
+
+func (p *Postgres) ListPersonCast(ctx context.Context, personId int) ([]models.CastCredit, error) {
+	credits := make([]models.CastCredit, 0)
+
+	query := `
+		SELECT m.id AS movie_id, m.title, m.poster_path, m.release_date, m.vote_average, c.character, c."order"
+		FROM casts c
+		         JOIN credits cr ON cr.id = c.credit_id
+		         JOIN movies m ON m.id = cr.movie_id
+		WHERE c.id = ?
+		ORDER BY m.release_date DESC`
+
+	_, err := p.db.QueryContext(ctx, &credits, query, personId)
+
+	return credits, wrapError(err, peopleResource)
+}
+
+func (p *Postgres) ListPersonCrew(ctx context.Context, personId int) ([]models.CrewCredit, error) {
+	credits := make([]models.CrewCredit, 0)
+
+	query := `
+		SELECT m.id AS movie_id, m.title, m.poster_path, m.release_date, m.vote_average, c.department, c.job
+		FROM crews c
+		         JOIN credits cr ON cr.id = c.credit_id
+		         JOIN movies m ON m.id = cr.movie_id
+		WHERE c.id = ?
+		ORDER BY c.department, c.job, m.release_date DESC`
+
+	_, err := p.db.QueryContext(ctx, &credits, query, personId)
+
+	return credits, wrapError(err, peopleResource)
+}
@@ -2,9 +2,11 @@ package storage
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/BarTar213/movies-service/config"
+	"github.com/BarTar213/movies-service/metrics"
 	"github.com/BarTar213/movies-service/models"
 	"github.com/go-pg/pg/v10"
 	"github.com/go-pg/pg/v10/orm"
@@ -13,43 +15,98 @@ import (
 const (
 	all    = "*"
 	genres = "Genres"
+
+	PostgresName = "postgres"
+	MemoryName   = "memory"
 )
 
 type Postgres struct {
-	db *pg.DB
+	db      *pg.DB
+	metrics *metrics.Metrics
 }
 
 type Storage interface {
-	AddMovie(movie *models.TmdbMovie) error
-
-	GetMovie(movie *models.Movie) error
-	ListMovies(title string, params *models.PaginationParams) ([]models.MoviePreview, error)
-	ListMoviesFromIDs(IDs []int) ([]models.MoviePreview, error)
-	AddRecentViewedMovie(userId int, movieId int) error
-
-	LikeMovie(userId int, movieId int) error
-	DeleteMovieLike(userId int, movieId int) error
-	ListLikedMovies(userId int, params *models.PaginationParams) ([]models.MoviePreview, error)
-	CheckLiked(likedMovie *models.LikedMovie) (bool, error)
-
-	ListMovieComments(movieId int, params *models.PaginationParams) ([]models.Comment, error)
-	ListLikedCommentsForMovie(movieID, userID int) ([]int, error)
-	LikeComment(userId int, commentId int, comment *models.Comment) error
-	DeleteCommentLike(userId int, commentId int) error
-	AddMovieComment(comment *models.Comment) error
-	UpdateComment(comment *models.Comment) error
-	DeleteComment(comment *models.Comment) error
-
-	GetCredits(movieId int, credit *models.Credit) error
-	AddCredits(credit *models.Credit) error
-
-	GetRating(rating *models.Rating) error
-	AddRating(rating *models.Rating) error
-	DeleteRating(rating *models.Rating) error
-	ListRatedMovies(userID int, params *models.PaginationParams) ([]models.MoviePreview, error)
+	Ping(ctx context.Context) error
+	Close() error
+
+	AddMovie(ctx context.Context, movie *models.TmdbMovie) error
+	AddMovies(ctx context.Context, movies []models.TmdbMovie) error
+
+	GetMovie(ctx context.Context, movie *models.Movie) error
+	ListMovies(ctx context.Context, filters *models.MovieFilters, params *models.PaginationParams) ([]models.MoviePreview, error)
+	ListMoviesFromIDs(ctx context.Context, IDs []int) ([]models.MoviePreview, error)
+	AddRecentViewedMovie(ctx context.Context, userId int, movieId int) error
+
+	LikeMovie(ctx context.Context, userId int, movieId int) error
+	DeleteMovieLike(ctx context.Context, userId int, movieId int) error
+	ListLikedMovies(ctx context.Context, userId int, params *models.PaginationParams) ([]models.MoviePreview, error)
+	CheckLiked(ctx context.Context, likedMovie *models.LikedMovie) (bool, error)
+
+	ListMovieComments(ctx context.Context, movieId int, params *models.PaginationParams) ([]models.Comment, error)
+	ListLikedCommentsForMovie(ctx context.Context, movieID, userID int) ([]int, error)
+	LikeComment(ctx context.Context, userId int, commentId int, comment *models.Comment) error
+	DeleteCommentLike(ctx context.Context, userId int, commentId int) error
+	AddMovieComment(ctx context.Context, comment *models.Comment) error
+	UpdateComment(ctx context.Context, comment *models.Comment) error
+	DeleteComment(ctx context.Context, comment *models.Comment) (bool, error)
+	UpsertComment(ctx context.Context, comment *models.Comment) error
+
+	GetCredits(ctx context.Context, movieId int, credit *models.Credit) error
+	AddCredits(ctx context.Context, credit *models.Credit) error
+
+	ListImages(ctx context.Context, movieId int) ([]*models.Image, error)
+	AddImages(ctx context.Context, images []*models.Image) error
+	ListVideos(ctx context.Context, movieId int) ([]*models.Video, error)
+	AddVideos(ctx context.Context, videos []*models.Video) error
+
+	GetTranslation(ctx context.Context, translation *models.Translation) error
+	ListTranslations(ctx context.Context, movieIds []int, language string) ([]models.Translation, error)
+	AddTranslations(ctx context.Context, translations []models.Translation) error
+
+	GetPerson(ctx context.Context, person *models.Person) error
+	AddPerson(ctx context.Context, person *models.Person) error
+	ListPersonCast(ctx context.Context, personId int) ([]models.CastCredit, error)
+	ListPersonCrew(ctx context.Context, personId int) ([]models.CrewCredit, error)
+
+	GetSnapshot(ctx context.Context, snapshot *models.Snapshot) error
+	SaveSnapshot(ctx context.Context, snapshot *models.Snapshot) error
+
+	RefreshCommunityRankings(ctx context.Context, minVotes int) error
+	ListCommunityRanking(ctx context.Context, params *models.CommunityRankingParams, pagination *models.PaginationParams) ([]models.RankedMovie, int, error)
+
+	GetRating(ctx context.Context, rating *models.Rating) error
+	AddRating(ctx context.Context, rating *models.Rating) (RatingChange, error)
+	DeleteRating(ctx context.Context, rating *models.Rating) (bool, error)
+	ListRatedMovies(ctx context.Context, userID int, params *models.PaginationParams) ([]models.MoviePreview, error)
+
+	ExportMovies(ctx context.Context, params *models.PaginationParams) ([]models.TmdbMovie, error)
+	ExportCredits(ctx context.Context, params *models.PaginationParams) ([]models.Credit, error)
+	ExportComments(ctx context.Context, params *models.PaginationParams) ([]models.Comment, error)
+	ExportRatings(ctx context.Context, params *models.PaginationParams) ([]models.Rating, error)
+}
+
+func (p *Postgres) Ping(ctx context.Context) error {
+	return wrapError(p.db.Ping(ctx), "postgres")
+}
+
+// Close closes connection pool, it should be called after all queries finished
+func (p *Postgres) Close() error {
+	return p.db.Close()
 }
 
-func NewPostgres(config *config.Postgres) (Storage, error) {
+// New creates storage selected in config, Postgres is used when none is selected
+func New(conf *config.Config, metrics *metrics.Metrics) (Storage, error) {
+	switch conf.Storage {
+	case "", PostgresName:
+		return NewPostgres(&conf.Postgres, metrics)
+	case MemoryName:
+		return NewMemory(), nil
+	default:
+		return nil, fmt.Errorf("storage: unknown storage %s", conf.Storage)
+	}
+}
+
+func NewPostgres(config *config.Postgres, metrics *metrics.Metrics) (Storage, error) {
 	db := pg.Connect(&pg.Options{
 		Addr:        config.Address,
 		User:        config.User,
@@ -68,5 +125,13 @@ func NewPostgres(config *config.Postgres) (Storage, error) {
 	orm.RegisterTable((*models.MovieGenre)(nil))
 	orm.RegisterTable((*models.MovieLanguage)(nil))
 
-	return &Postgres{db: db}, nil
+	db.AddQueryHook(&queryTracingHook{})
+	if metrics != nil {
+		db.AddQueryHook(&queryMetricsHook{metrics: metrics})
+	}
+
+	return &Postgres{
+		db:      db,
+		metrics: metrics,
+	}, nil
 }
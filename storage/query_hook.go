@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/BarTar213/movies-service/metrics"
+	"github.com/go-pg/pg/v10"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/BarTar213/movies-service/storage")
+
+// statements used as label of query duration, other statements are reported as "other"
+var statements = map[string]bool{
+	"select":    true,
+	"insert":    true,
+	"update":    true,
+	"delete":    true,
+	"with":      true,
+	"begin":     true,
+	"commit":    true,
+	"rollback":  true,
+	"savepoint": true,
+	"release":   true,
+}
+
+// queryMetricsHook observes duration of every query run by go-pg, including queries of transactions
+type queryMetricsHook struct {
+	metrics *metrics.Metrics
+}
+
+func (h *queryMetricsHook) BeforeQuery(ctx context.Context, event *pg.QueryEvent) (context.Context, error) {
+	return ctx, nil
+}
+
+func (h *queryMetricsHook) AfterQuery(ctx context.Context, event *pg.QueryEvent) error {
+	outcome := "success"
+	if event.Err != nil {
+		outcome = "error"
+	}
+	h.metrics.StorageQueryDuration.WithLabelValues(statement(event), outcome).Observe(time.Since(event.StartTime).Seconds())
+	return nil
+}
+
+// queryTracingHook starts child span of context for every query, query text is recorded without
+// formatted params so values of users don't leave the service
+type queryTracingHook struct{}
+
+func (h *queryTracingHook) BeforeQuery(ctx context.Context, event *pg.QueryEvent) (context.Context, error) {
+	operation := statement(event)
+	ctx, span := tracer.Start(ctx, operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperationName(operation),
+		),
+	)
+	if query, err := event.UnformattedQuery(); err == nil {
+		span.SetAttributes(semconv.DBQueryText(string(query)))
+	}
+	return ctx, nil
+}
+
+func (h *queryTracingHook) AfterQuery(ctx context.Context, event *pg.QueryEvent) error {
+	span := trace.SpanFromContext(ctx)
+	if event.Err != nil && !errors.Is(event.Err, pg.ErrNoRows) {
+		span.RecordError(event.Err)
+		span.SetStatus(codes.Error, event.Err.Error())
+	}
+	span.End()
+	return nil
+}
+
+func statement(event *pg.QueryEvent) string {
+	query, err := event.UnformattedQuery()
+	if err != nil {
+		return "other"
+	}
+
+	fields := bytes.Fields(bytes.TrimSpace(query))
+	if len(fields) == 0 {
+		return "other"
+	}
+	name := strings.ToLower(string(fields[0]))
+	if !statements[name] {
+		return "other"
+	}
+	return name
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/BarTar213/movies-service/models"
+	"github.com/go-pg/pg/v10"
+)
+
+const rankingRefreshTimeout = time.Minute
+
+// RefreshCommunityRankings replaces materialised rankings of every period,
+// score is Bayesian average of ratings pulled towards mean of the period with weight of minVotes,
+// movies with less than minVotes ratings in the period are left out
+func (p *Postgres) RefreshCommunityRankings(ctx context.Context, minVotes int) error {
+	ctx, cancel := context.WithTimeout(ctx, rankingRefreshTimeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO community_rankings (period, movie_id, score, vote_count, vote_average, likes, updated_at)
+		SELECT ?0, r.movie_id,
+		       (COUNT(*) * AVG(r.rating) + ?1 * s.mean) / (COUNT(*) + ?1),
+		       COUNT(*), AVG(r.rating), COALESCE(l.likes, 0), ?3
+		FROM ratings r
+		         CROSS JOIN (SELECT COALESCE(AVG(rating), 0) AS mean FROM ratings WHERE create_date >= ?2) s
+		         LEFT JOIN (SELECT movie_id, COUNT(*) AS likes FROM liked_movies GROUP BY movie_id) l
+		                   ON l.movie_id = r.movie_id
+		WHERE r.create_date >= ?2
+		GROUP BY r.movie_id, s.mean, l.likes
+		HAVING COUNT(*) >= ?1`
+
+	err := p.db.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		_, err := tx.Exec("DELETE FROM community_rankings")
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, period := range models.RankingPeriods {
+			_, err = tx.Exec(query, period, minVotes, models.PeriodStart(period, now), now)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return wrapError(err, rankingsResource)
+}
+
+// ListCommunityRanking returns page of ranked movies and number of all movies ranked in the period,
+// likes break ties between equal scores
+func (p *Postgres) ListCommunityRanking(ctx context.Context, params *models.CommunityRankingParams, pagination *models.PaginationParams) ([]models.RankedMovie, int, error) {
+	movies := make([]models.RankedMovie, 0)
+
+	query := p.db.ModelContext(ctx, (*models.CommunityRanking)(nil)).
+		ColumnExpr("m.id, m.poster_path, m.release_date, m.vote_average, m.title").
+		ColumnExpr("community_ranking.score AS community_score").
+		ColumnExpr("community_ranking.vote_count AS community_votes").
+		ColumnExpr("community_ranking.vote_average AS community_average").
+		ColumnExpr("community_ranking.likes").
+		Join("JOIN movies m ON m.id = community_ranking.movie_id").
+		Where("community_ranking.period = ?", params.Period)
+
+	if params.GenreId > 0 {
+		query.Where(`EXISTS (SELECT 1
+			FROM movie_genres g
+			WHERE g.movie_id = community_ranking.movie_id AND g.genre_id = ?)`, params.GenreId)
+	}
+
+	count, err := query.
+		Order("community_ranking.score DESC", "community_ranking.likes DESC", "community_ranking.movie_id").
+		Offset(pagination.Offset).
+		Limit(pagination.Limit).
+		SelectAndCount(&movies)
+
+	return movies, count, wrapError(err, rankingsResource)
+}
@@ -2,79 +2,95 @@ package storage
 
 import (
 	"context"
-	"time"
 
 	"github.com/BarTar213/movies-service/models"
 	"github.com/go-pg/pg/v10"
 )
 
-func (p *Postgres) AddRating(rating *models.Rating) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+// RatingChange tells how AddRating changed stored rating of user
+type RatingChange int
 
+const (
+	RatingUnchanged RatingChange = iota
+	RatingAdded
+	RatingChanged
+)
+
+// AddRating inserts rating or locks and updates the stored one, so the change is told within the same transaction
+func (p *Postgres) AddRating(ctx context.Context, rating *models.Rating) (RatingChange, error) {
+	change := RatingUnchanged
 	err := p.db.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		//without RETURNING, as go-pg reports no rows error for struct model when insert conflicts
+		res, err := tx.Model(rating).
+			OnConflict("(user_id, movie_id) DO NOTHING").
+			Insert()
+		if err != nil {
+			return err
+		}
+		if res.RowsAffected() > 0 {
+			change = RatingAdded
+			_, err = tx.Model((*models.Movie)(nil)).
+				Where("id=?", rating.MovieId).
+				Set("vote_count=vote_count+1").
+				Update()
+			return err
+		}
+
 		oldRating := &models.Rating{
 			UserId:  rating.UserId,
 			MovieId: rating.MovieId,
 		}
-		err := tx.Model(oldRating).WherePK().Select()
-		if err != nil && err != pg.ErrNoRows {
+		err = tx.Model(oldRating).WherePK().For("UPDATE").Select()
+		if err != nil {
 			return err
 		}
-
-		if oldRating.Rating == rating.Rating {
+		if *oldRating.Rating == *rating.Rating {
 			return nil
 		}
 
+		change = RatingChanged
 		_, err = tx.Model(rating).
-			OnConflict("(user_id, movie_id) DO UPDATE").
-			Set("rating=?rating").
-			Set("create_date=?create_date").
+			WherePK().
+			Column("rating", "create_date").
 			Returning(all).
-			Insert()
-		if err != nil {
-			return err
-		}
-
-		query := tx.Model((*models.Movie)(nil)).
-			Where("id=?", rating.MovieId)
-
-		if oldRating.Rating != nil {
-			additionalValue := *rating.Rating - *oldRating.Rating
-			query.Set("vote_sum=vote_sum+?", additionalValue)
-		} else {
-			query.Set("vote_count=vote_count+1")
-		}
-		_, err = query.Update()
+			Update()
 		if err != nil {
 			return err
 		}
-		return nil
+		_, err = tx.Model((*models.Movie)(nil)).
+			Where("id=?", rating.MovieId).
+			Set("vote_sum=vote_sum+?", *rating.Rating-*oldRating.Rating).
+			Update()
+		return err
 	})
 
-	return err
+	return change, wrapError(err, ratingsResource)
 }
 
-func (p *Postgres) GetRating(rating *models.Rating) error {
-	err := p.db.Model(rating).
+func (p *Postgres) GetRating(ctx context.Context, rating *models.Rating) error {
+	err := p.db.ModelContext(ctx, rating).
 		WherePK().
 		Select()
 
-	return err
+	return wrapError(err, ratingsResource)
 }
 
-func (p *Postgres) DeleteRating(rating *models.Rating) error {
-	_, err := p.db.Model(rating).
+// DeleteRating reports whether rating was stored and deleted
+func (p *Postgres) DeleteRating(ctx context.Context, rating *models.Rating) (bool, error) {
+	res, err := p.db.ModelContext(ctx, rating).
 		WherePK().
 		Delete()
+	if err != nil {
+		return false, wrapError(err, ratingsResource)
+	}
 
-	return err
+	return res.RowsAffected() > 0, nil
 }
 
-func (p *Postgres) ListRatedMovies(userID int, params *models.PaginationParams) ([]models.MoviePreview, error) {
-	movies :=  make([]models.MoviePreview, 0)
+func (p *Postgres) ListRatedMovies(ctx context.Context, userID int, params *models.PaginationParams) ([]models.MoviePreview, error) {
+	movies := make([]models.MoviePreview, 0)
 
-	err := p.db.Model((*models.Rating)(nil)).
+	err := p.db.ModelContext(ctx, (*models.Rating)(nil)).
 		Column("m.*").
 		Column("rating").
 		Where("user_id=?", userID).
@@ -84,5 +100,5 @@ func (p *Postgres) ListRatedMovies(userID int, params *models.PaginationParams)
 		Limit(params.Limit).
 		Select(&movies)
 
-	return movies, err
+	return movies, wrapError(err, moviesResource)
 }
@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/BarTar213/movies-service/models"
+)
+
+func (p *Postgres) GetSnapshot(ctx context.Context, snapshot *models.Snapshot) error {
+	err := p.db.ModelContext(ctx, snapshot).
+		WherePK().
+		Select()
+
+	return wrapError(err, snapshotsResource)
+}
+
+func (p *Postgres) SaveSnapshot(ctx context.Context, snapshot *models.Snapshot) error {
+	_, err := p.db.ModelContext(ctx, snapshot).
+		OnConflict("(key) DO UPDATE").
+		Set("data=?data").
+		Set("updated_at=?updated_at").
+		Insert()
+
+	return wrapError(err, snapshotsResource)
+}
@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/BarTar213/movies-service/models"
+	"github.com/go-pg/pg/v10"
+)
+
+func (p *Postgres) GetTranslation(ctx context.Context, translation *models.Translation) error {
+	err := p.db.ModelContext(ctx, translation).
+		WherePK().
+		Select()
+
+	return wrapError(err, translationsResource)
+}
+
+func (p *Postgres) ListTranslations(ctx context.Context, movieIds []int, language string) ([]models.Translation, error) {
+	translations := make([]models.Translation, 0)
+
+	err := p.db.ModelContext(ctx, &translations).
+		Where("movie_id = ANY(?)", pg.Array(movieIds)).
+		Where("iso_639_1 = ?", language).
+		Select()
+
+	return translations, wrapError(err, translationsResource)
+}
+
+func (p *Postgres) AddTranslations(ctx context.Context, translations []models.Translation) error {
+	if len(translations) == 0 {
+		return nil
+	}
+	_, err := p.db.ModelContext(ctx, &translations).
+		OnConflict("(movie_id, iso_639_1) DO UPDATE").
+		Set("iso_3166_1=EXCLUDED.iso_3166_1").
+		Set("title=EXCLUDED.title").
+		Set("overview=EXCLUDED.overview").
+		Set("tagline=EXCLUDED.tagline").
+		Insert()
+
+	return wrapError(err, translationsResource)
+}
@@ -0,0 +1,411 @@
+package tmdb
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BarTar213/movies-service/config"
+	"github.com/BarTar213/movies-service/logging"
+	"github.com/BarTar213/movies-service/metrics"
+	"github.com/BarTar213/movies-service/models"
+	"github.com/BarTar213/movies-service/storage"
+)
+
+const (
+	defaultCacheTTL  = time.Hour
+	defaultCacheSize = 1000
+	refreshTimeout   = time.Minute
+
+	trendingList   = "trending"
+	topRatedList   = "top_rated"
+	nowPlayingList = "now_playing"
+	upcomingList   = "upcoming"
+	popularList    = "popular"
+	discoverList   = "discover"
+
+	cacheHit   = "hit"
+	cacheStale = "stale"
+	cacheMiss  = "miss"
+)
+
+// SnapshotStore persists cached lists so they survive restarts
+type SnapshotStore interface {
+	GetSnapshot(ctx context.Context, snapshot *models.Snapshot) error
+	SaveSnapshot(ctx context.Context, snapshot *models.Snapshot) error
+}
+
+type cacheEntry struct {
+	data       []byte
+	fetchedAt  time.Time
+	refreshing bool
+	//position in recently used keys
+	element *list.Element
+}
+
+// fetch of list shared by callers which missed the same key at once
+type cacheCall struct {
+	done   chan struct{}
+	data   []byte
+	status int
+	err    error
+}
+
+// CachedClient serves TMDB lists from memory, expired entries are served while being refreshed in background,
+// calls other than lists are passed to the wrapped client. At most size entries are kept,
+// the least recently used one is evicted when another is added
+type CachedClient struct {
+	Client
+
+	ttl       time.Duration
+	size      int
+	snapshots SnapshotStore
+	metrics   *metrics.Metrics
+	logger    *slog.Logger
+
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+	//keys from the most to the least recently used
+	recent *list.List
+	calls  map[string]*cacheCall
+	closed bool
+
+	//background refreshes and shared fetches, cancelled when closing didn't finish before its deadline
+	refreshes sync.WaitGroup
+	ctx       context.Context
+	cancel    context.CancelFunc
+}
+
+// snapshots are optional, nil disables persisting lists, discover results are never persisted
+// as their parameters are chosen freely by users
+func NewCachedClient(client Client, config *config.Config, snapshots SnapshotStore, metrics *metrics.Metrics, logger *slog.Logger) *CachedClient {
+	ttl := config.Tmdb.CacheTTL
+	if ttl <= 0 {
+		ttl = defaultCacheTTL
+	}
+	size := config.Tmdb.CacheSize
+	if size <= 0 {
+		size = defaultCacheSize
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &CachedClient{
+		Client:    client,
+		ttl:       ttl,
+		size:      size,
+		snapshots: snapshots,
+		metrics:   metrics,
+		logger:    logger,
+		entries:   make(map[string]*cacheEntry),
+		recent:    list.New(),
+		calls:     make(map[string]*cacheCall),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// Close stops refreshing expired entries and waits for running refreshes and fetches, so snapshots aren't saved
+// after storage is closed. When ctx is done first, running refreshes are cancelled and ctx error is returned
+func (c *CachedClient) Close(ctx context.Context) error {
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		c.refreshes.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		c.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// cached page of list together with its pagination metadata
+type cachedPage struct {
+	Movies []models.TmdbMovie `json:"movies,omitempty"`
+	Ids    []int              `json:"ids,omitempty"`
+	Meta   models.PageMeta    `json:"meta"`
+}
+
+func (c *CachedClient) GetTrendingMovies(ctx context.Context, params *models.TmdbListParams, meta *models.PageMeta) ([]models.TmdbMovie, int, error) {
+	key := fmt.Sprintf("%s:%s:%d", trendingList, params.Window, params.Page)
+
+	return c.cachedMovies(ctx, trendingList, key, meta, func(ctx context.Context, meta *models.PageMeta) ([]models.TmdbMovie, int, error) {
+		return c.Client.GetTrendingMovies(ctx, params, meta)
+	})
+}
+
+func (c *CachedClient) GetNowPlayingMovies(ctx context.Context, params *models.TmdbListParams, meta *models.PageMeta) ([]models.TmdbMovie, int, error) {
+	key := fmt.Sprintf("%s:%s:%d", nowPlayingList, params.Region, params.Page)
+
+	return c.cachedMovies(ctx, nowPlayingList, key, meta, func(ctx context.Context, meta *models.PageMeta) ([]models.TmdbMovie, int, error) {
+		return c.Client.GetNowPlayingMovies(ctx, params, meta)
+	})
+}
+
+func (c *CachedClient) GetUpcomingMovies(ctx context.Context, params *models.TmdbListParams, meta *models.PageMeta) ([]models.TmdbMovie, int, error) {
+	key := fmt.Sprintf("%s:%s:%d", upcomingList, params.Region, params.Page)
+
+	return c.cachedMovies(ctx, upcomingList, key, meta, func(ctx context.Context, meta *models.PageMeta) ([]models.TmdbMovie, int, error) {
+		return c.Client.GetUpcomingMovies(ctx, params, meta)
+	})
+}
+
+func (c *CachedClient) GetPopularMovies(ctx context.Context, params *models.TmdbListParams, meta *models.PageMeta) ([]models.TmdbMovie, int, error) {
+	key := fmt.Sprintf("%s:%s:%d", popularList, params.Region, params.Page)
+
+	return c.cachedMovies(ctx, popularList, key, meta, func(ctx context.Context, meta *models.PageMeta) ([]models.TmdbMovie, int, error) {
+		return c.Client.GetPopularMovies(ctx, params, meta)
+	})
+}
+
+func (c *CachedClient) DiscoverMovies(ctx context.Context, params *models.DiscoverParams, meta *models.PageMeta) ([]models.TmdbMovie, int, error) {
+	key := fmt.Sprintf("%s:%s:%d:%s:%s:%d", discoverList, params.Region, params.Page, params.SortBy, params.Genres, params.Year)
+
+	return c.cachedMovies(ctx, discoverList, key, meta, func(ctx context.Context, meta *models.PageMeta) ([]models.TmdbMovie, int, error) {
+		return c.Client.DiscoverMovies(ctx, params, meta)
+	})
+}
+
+func (c *CachedClient) GetTopRatedMovies(ctx context.Context, params *models.TmdbListParams, meta *models.PageMeta) ([]int, int, error) {
+	key := fmt.Sprintf("%s:%s:%d", topRatedList, params.Region, params.Page)
+
+	page := &cachedPage{}
+	status, err := c.cached(ctx, topRatedList, key, page, func(ctx context.Context) (interface{}, int, error) {
+		fetched := &cachedPage{}
+		ids, status, err := c.Client.GetTopRatedMovies(ctx, params, &fetched.Meta)
+		fetched.Ids = ids
+		return fetched, status, err
+	})
+	if err != nil || status != http.StatusOK {
+		return nil, status, err
+	}
+	*meta = page.Meta
+
+	return page.Ids, status, nil
+}
+
+func (c *CachedClient) cachedMovies(ctx context.Context, list, key string, meta *models.PageMeta, fetch func(context.Context, *models.PageMeta) ([]models.TmdbMovie, int, error)) ([]models.TmdbMovie, int, error) {
+	page := &cachedPage{}
+	status, err := c.cached(ctx, list, key, page, func(ctx context.Context) (interface{}, int, error) {
+		fetched := &cachedPage{}
+		movies, status, err := fetch(ctx, &fetched.Meta)
+		fetched.Movies = movies
+		return fetched, status, err
+	})
+	if err != nil || status != http.StatusOK {
+		return nil, status, err
+	}
+	*meta = page.Meta
+
+	return page.Movies, status, nil
+}
+
+// decodes cached value of key into v, fetch is called synchronously only when there is no cached value
+func (c *CachedClient) cached(ctx context.Context, list, key string, v interface{}, fetch func(context.Context) (interface{}, int, error)) (int, error) {
+	data, expired, ok := c.read(ctx, key, fetch)
+	if ok {
+		if expired {
+			c.observe(list, cacheStale)
+		} else {
+			c.observe(list, cacheHit)
+		}
+		return http.StatusOK, json.Unmarshal(data, v)
+	}
+	c.observe(list, cacheMiss)
+
+	data, status, err := c.load(ctx, key, fetch)
+	if err != nil || status != http.StatusOK {
+		return status, err
+	}
+
+	return status, json.Unmarshal(data, v)
+}
+
+// returns cached data from memory or from stored snapshot and starts background refresh when it expired,
+// expired data is served without refresh once closed
+func (c *CachedClient) read(ctx context.Context, key string, fetch func(context.Context) (interface{}, int, error)) ([]byte, bool, bool) {
+	c.mu.Lock()
+	_, ok := c.entries[key]
+	c.mu.Unlock()
+
+	var snapshot *models.Snapshot
+	if !ok {
+		snapshot = c.restore(ctx, key)
+		if snapshot == nil {
+			return nil, false, false
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	switch {
+	case ok:
+		c.recent.MoveToFront(entry.element)
+	case snapshot != nil:
+		entry = c.add(key, snapshot.Data, snapshot.UpdatedAt)
+	default:
+		//evicted in the meantime
+		return nil, false, false
+	}
+
+	expired := time.Since(entry.fetchedAt) > c.ttl
+	if expired && !entry.refreshing && !c.closed {
+		entry.refreshing = true
+		c.refreshes.Add(1)
+		go c.refresh(key, fetch)
+	}
+
+	return entry.data, expired, true
+}
+
+// returns stored snapshot of key or nil when there is none
+func (c *CachedClient) restore(ctx context.Context, key string) *models.Snapshot {
+	if !c.persisted(key) {
+		return nil
+	}
+
+	snapshot := &models.Snapshot{Key: key}
+	err := c.snapshots.GetSnapshot(ctx, snapshot)
+	if err != nil {
+		if !errors.Is(err, storage.ErrNotFound) {
+			c.logger.ErrorContext(ctx, "get tmdb snapshot", slog.String("list", key), logging.Err(err))
+		}
+		return nil
+	}
+	return snapshot
+}
+
+// add stores data of key as the most recently used entry and evicts the least recently used ones
+// above size, c.mu has to be held
+func (c *CachedClient) add(key string, data []byte, fetchedAt time.Time) *cacheEntry {
+	entry, ok := c.entries[key]
+	if ok {
+		entry.data, entry.fetchedAt, entry.refreshing = data, fetchedAt, false
+		c.recent.MoveToFront(entry.element)
+		return entry
+	}
+
+	entry = &cacheEntry{data: data, fetchedAt: fetchedAt, element: c.recent.PushFront(key)}
+	c.entries[key] = entry
+	for c.recent.Len() > c.size {
+		oldest := c.recent.Back()
+		c.recent.Remove(oldest)
+		delete(c.entries, oldest.Value.(string))
+	}
+	return entry
+}
+
+// discover parameters are chosen freely by users, so their results are kept only in memory
+func (c *CachedClient) persisted(key string) bool {
+	return c.snapshots != nil && !strings.HasPrefix(key, discoverList+":")
+}
+
+func (c *CachedClient) refresh(key string, fetch func(context.Context) (interface{}, int, error)) {
+	defer c.refreshes.Done()
+
+	ctx, cancel := context.WithTimeout(c.ctx, refreshTimeout)
+	defer cancel()
+
+	_, status, err := c.fetch(ctx, key, fetch)
+	if err != nil || status != http.StatusOK {
+		c.logger.WarnContext(ctx, "refresh tmdb list", slog.String("list", key), slog.Int("status", status), logging.Err(err))
+
+		c.mu.Lock()
+		if entry, ok := c.entries[key]; ok {
+			entry.refreshing = false
+		}
+		c.mu.Unlock()
+	}
+}
+
+// load fetches missed key once for all concurrent callers. The fetch keeps values of ctx of the first caller
+// but not its deadline, so it isn't cancelled when that caller gives up, every caller waits only as long as its ctx allows
+func (c *CachedClient) load(ctx context.Context, key string, fetch func(context.Context) (interface{}, int, error)) ([]byte, int, error) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return c.fetch(ctx, key, fetch)
+	}
+	call, ok := c.calls[key]
+	if !ok {
+		call = &cacheCall{done: make(chan struct{})}
+		c.calls[key] = call
+		c.refreshes.Add(1)
+		go c.share(ctx, key, call, fetch)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.data, call.status, call.err
+	case <-ctx.Done():
+		return nil, http.StatusGatewayTimeout, ctx.Err()
+	}
+}
+
+// share runs fetch of load with its own timeout, cancelled only when closing didn't finish before its deadline
+func (c *CachedClient) share(ctx context.Context, key string, call *cacheCall, fetch func(context.Context) (interface{}, int, error)) {
+	defer c.refreshes.Done()
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), refreshTimeout)
+	defer cancel()
+	stop := context.AfterFunc(c.ctx, cancel)
+	defer stop()
+
+	call.data, call.status, call.err = c.fetch(ctx, key, fetch)
+
+	c.mu.Lock()
+	delete(c.calls, key)
+	c.mu.Unlock()
+	close(call.done)
+}
+
+func (c *CachedClient) fetch(ctx context.Context, key string, fetch func(context.Context) (interface{}, int, error)) ([]byte, int, error) {
+	value, status, err := fetch(ctx)
+	if err != nil || status != http.StatusOK {
+		return nil, status, err
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	now := time.Now()
+	c.mu.Lock()
+	c.add(key, data, now)
+	c.mu.Unlock()
+
+	if c.persisted(key) {
+		err = c.snapshots.SaveSnapshot(ctx, &models.Snapshot{Key: key, Data: data, UpdatedAt: now})
+		if err != nil {
+			c.logger.ErrorContext(ctx, "save tmdb snapshot", slog.String("list", key), logging.Err(err))
+		}
+	}
+
+	return data, status, nil
+}
+
+func (c *CachedClient) observe(list, result string) {
+	if c.metrics == nil {
+		return
+	}
+	c.metrics.TmdbCache.WithLabelValues(list, result).Inc()
+}
@@ -0,0 +1,353 @@
+package tmdb
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/BarTar213/movies-service/config"
+	"github.com/BarTar213/movies-service/models"
+	"github.com/BarTar213/movies-service/storage"
+)
+
+type countingClient struct {
+	Client
+	calls  int32
+	status int
+	err    error
+	delay  time.Duration
+}
+
+func (c *countingClient) GetTrendingMovies(ctx context.Context, params *models.TmdbListParams, meta *models.PageMeta) ([]models.TmdbMovie, int, error) {
+	atomic.AddInt32(&c.calls, 1)
+	select {
+	case <-time.After(c.delay):
+	case <-ctx.Done():
+		return nil, http.StatusInternalServerError, ctx.Err()
+	}
+	if c.err != nil || c.status != http.StatusOK {
+		return nil, c.status, c.err
+	}
+	*meta = models.PageMeta{Page: params.Page, TotalPages: 10}
+	return []models.TmdbMovie{{Id: 1, Title: "title"}}, http.StatusOK, nil
+}
+
+func (c *countingClient) DiscoverMovies(ctx context.Context, params *models.DiscoverParams, meta *models.PageMeta) ([]models.TmdbMovie, int, error) {
+	return c.GetTrendingMovies(ctx, &params.TmdbListParams, meta)
+}
+
+func (c *countingClient) GetTopRatedMovies(ctx context.Context, params *models.TmdbListParams, meta *models.PageMeta) ([]int, int, error) {
+	atomic.AddInt32(&c.calls, 1)
+	if c.err != nil || c.status != http.StatusOK {
+		return nil, c.status, c.err
+	}
+	*meta = models.PageMeta{Page: params.Page, TotalPages: 10}
+	return []int{1, 2, 3}, http.StatusOK, nil
+}
+
+type snapshotStore struct {
+	snapshots map[string]models.Snapshot
+}
+
+func (s *snapshotStore) GetSnapshot(ctx context.Context, snapshot *models.Snapshot) error {
+	stored, ok := s.snapshots[snapshot.Key]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	*snapshot = stored
+	return nil
+}
+
+func (s *snapshotStore) SaveSnapshot(ctx context.Context, snapshot *models.Snapshot) error {
+	s.snapshots[snapshot.Key] = *snapshot
+	return nil
+}
+
+// snapshotStore safe for refreshes running in background
+type lockedSnapshotStore struct {
+	snapshotStore
+	mu    sync.Mutex
+	saves int
+}
+
+func (s *lockedSnapshotStore) GetSnapshot(ctx context.Context, snapshot *models.Snapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.snapshotStore.GetSnapshot(ctx, snapshot)
+}
+
+func (s *lockedSnapshotStore) SaveSnapshot(ctx context.Context, snapshot *models.Snapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.saves++
+	return s.snapshotStore.SaveSnapshot(ctx, snapshot)
+}
+
+func (s *lockedSnapshotStore) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.saves
+}
+
+func newTestCachedClient(client Client, ttl time.Duration, snapshots SnapshotStore) *CachedClient {
+	conf := &config.Config{Tmdb: config.Tmdb{CacheTTL: ttl}}
+	return NewCachedClient(client, conf, snapshots, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestCachedClient_GetTrendingMovies(t *testing.T) {
+	params := &models.TmdbListParams{Window: "day", Page: 1}
+	meta := &models.PageMeta{}
+	client := &countingClient{status: http.StatusOK}
+	cache := newTestCachedClient(client, time.Hour, nil)
+
+	for i := 0; i < 3; i++ {
+		movies, status, err := cache.GetTrendingMovies(context.Background(), params, meta)
+		if err != nil || status != http.StatusOK {
+			t.Fatalf("GetTrendingMovies() status = %d, err = %v", status, err)
+		}
+		if len(movies) != 1 || movies[0].Title != "title" || meta.TotalPages != 10 {
+			t.Fatalf("GetTrendingMovies() movies = %v, meta = %+v", movies, meta)
+		}
+	}
+
+	if calls := atomic.LoadInt32(&client.calls); calls != 1 {
+		t.Errorf("GetTrendingMovies() client calls = %d, want 1", calls)
+	}
+
+	_, _, _ = cache.GetTrendingMovies(context.Background(), &models.TmdbListParams{Window: "week", Page: 2}, meta)
+	if calls := atomic.LoadInt32(&client.calls); calls != 2 {
+		t.Errorf("GetTrendingMovies() client calls = %d, want 2 as pages are cached separately", calls)
+	}
+	if meta.Page != 2 {
+		t.Errorf("GetTrendingMovies() meta page = %d, want 2", meta.Page)
+	}
+}
+
+func TestCachedClient_concurrentMisses(t *testing.T) {
+	params := &models.TmdbListParams{Window: "day", Page: 1}
+	client := &countingClient{status: http.StatusOK, delay: 20 * time.Millisecond}
+	cache := newTestCachedClient(client, time.Hour, nil)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			movies, status, err := cache.GetTrendingMovies(context.Background(), params, &models.PageMeta{})
+			if err != nil || status != http.StatusOK || len(movies) != 1 {
+				t.Errorf("GetTrendingMovies() movies = %v, status = %d, err = %v", movies, status, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if calls := atomic.LoadInt32(&client.calls); calls != 1 {
+		t.Errorf("GetTrendingMovies() client calls = %d, want 1 as concurrent misses share fetch", calls)
+	}
+}
+
+func TestCachedClient_concurrentMisses_callerGivesUp(t *testing.T) {
+	params := &models.TmdbListParams{Window: "day", Page: 1}
+	client := &countingClient{status: http.StatusOK, delay: 50 * time.Millisecond}
+	cache := newTestCachedClient(client, time.Hour, nil)
+
+	//first caller starts shared fetch and disconnects before it completes
+	first, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, status, err := cache.GetTrendingMovies(first, params, &models.PageMeta{})
+		if !errors.Is(err, context.DeadlineExceeded) || status != http.StatusGatewayTimeout {
+			t.Errorf("GetTrendingMovies() status = %d, err = %v, want %d, %v", status, err, http.StatusGatewayTimeout, context.DeadlineExceeded)
+		}
+	}()
+	time.Sleep(5 * time.Millisecond)
+
+	movies, status, err := cache.GetTrendingMovies(context.Background(), params, &models.PageMeta{})
+	if err != nil || status != http.StatusOK || len(movies) != 1 {
+		t.Errorf("GetTrendingMovies() movies = %v, status = %d, err = %v", movies, status, err)
+	}
+	<-done
+
+	if calls := atomic.LoadInt32(&client.calls); calls != 1 {
+		t.Errorf("client calls = %d, want 1 as callers share fetch", calls)
+	}
+}
+
+func TestCachedClient_GetTopRatedMovies_error(t *testing.T) {
+	params := &models.TmdbListParams{Window: "day", Page: 1}
+	meta := &models.PageMeta{}
+	client := &countingClient{status: http.StatusServiceUnavailable, err: errors.New("unavailable")}
+	cache := newTestCachedClient(client, time.Hour, nil)
+
+	for i := 0; i < 2; i++ {
+		_, status, err := cache.GetTopRatedMovies(context.Background(), params, meta)
+		if err == nil || status != http.StatusServiceUnavailable {
+			t.Fatalf("GetTopRatedMovies() status = %d, err = %v", status, err)
+		}
+	}
+
+	if calls := atomic.LoadInt32(&client.calls); calls != 2 {
+		t.Errorf("GetTopRatedMovies() client calls = %d, want 2 as errors are not cached", calls)
+	}
+}
+
+func TestCachedClient_staleRefresh(t *testing.T) {
+	params := &models.TmdbListParams{Window: "day", Page: 1}
+	meta := &models.PageMeta{}
+	client := &countingClient{status: http.StatusOK}
+	cache := newTestCachedClient(client, time.Millisecond, nil)
+
+	_, _, _ = cache.GetTopRatedMovies(context.Background(), params, meta)
+	time.Sleep(5 * time.Millisecond)
+
+	ids, status, err := cache.GetTopRatedMovies(context.Background(), params, meta)
+	if err != nil || status != http.StatusOK || len(ids) != 3 {
+		t.Fatalf("GetTopRatedMovies() ids = %v, status = %d, err = %v", ids, status, err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&client.calls) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("stale entry was not refreshed in background")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCachedClient_Close(t *testing.T) {
+	tests := []struct {
+		name     string
+		delay    time.Duration
+		deadline time.Duration
+		wantErr  error
+	}{
+		{
+			name:     "positive_waits_for_running_refresh",
+			delay:    20 * time.Millisecond,
+			deadline: time.Second,
+		},
+		{
+			name:     "negative_deadline_cancels_running_refresh",
+			delay:    time.Minute,
+			deadline: 20 * time.Millisecond,
+			wantErr:  context.DeadlineExceeded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := &models.TmdbListParams{Window: "day", Page: 1}
+			snapshots := &lockedSnapshotStore{snapshotStore: snapshotStore{snapshots: map[string]models.Snapshot{}}}
+			client := &countingClient{status: http.StatusOK}
+			cache := newTestCachedClient(client, time.Millisecond, snapshots)
+
+			_, _, _ = cache.GetTrendingMovies(context.Background(), params, &models.PageMeta{})
+			time.Sleep(5 * time.Millisecond)
+			client.delay = tt.delay
+			//stale entry starts refresh
+			_, _, _ = cache.GetTrendingMovies(context.Background(), params, &models.PageMeta{})
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.deadline)
+			defer cancel()
+			if err := cache.Close(ctx); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Close() error = %v, want %v", err, tt.wantErr)
+			}
+			saves := snapshots.count()
+
+			//closed cache serves stale entry without refreshing it
+			_, status, err := cache.GetTrendingMovies(context.Background(), params, &models.PageMeta{})
+			if err != nil || status != http.StatusOK {
+				t.Fatalf("GetTrendingMovies() status = %d, err = %v", status, err)
+			}
+			time.Sleep(20 * time.Millisecond)
+			if calls := atomic.LoadInt32(&client.calls); calls != 2 {
+				t.Errorf("client calls = %d, want 2 as closed cache doesn't refresh", calls)
+			}
+			if snapshots.count() != saves {
+				t.Errorf("snapshot saved after Close()")
+			}
+		})
+	}
+}
+
+func TestCachedClient_snapshots(t *testing.T) {
+	params := &models.TmdbListParams{Window: "day", Page: 1}
+	meta := &models.PageMeta{}
+	snapshots := &snapshotStore{snapshots: map[string]models.Snapshot{}}
+
+	client := &countingClient{status: http.StatusOK}
+	_, _, _ = newTestCachedClient(client, time.Hour, snapshots).GetTopRatedMovies(context.Background(), params, meta)
+	if _, ok := snapshots.snapshots["top_rated::1"]; !ok {
+		t.Fatal("snapshot was not saved")
+	}
+
+	restarted := &countingClient{status: http.StatusServiceUnavailable}
+	ids, status, err := newTestCachedClient(restarted, time.Hour, snapshots).GetTopRatedMovies(context.Background(), params, meta)
+	if err != nil || status != http.StatusOK || len(ids) != 3 {
+		t.Fatalf("GetTopRatedMovies() ids = %v, status = %d, err = %v", ids, status, err)
+	}
+	if calls := atomic.LoadInt32(&restarted.calls); calls != 0 {
+		t.Errorf("GetTopRatedMovies() client calls = %d, want 0", calls)
+	}
+}
+
+func TestCachedClient_evictsLeastRecentlyUsed(t *testing.T) {
+	client := &countingClient{status: http.StatusOK}
+	conf := &config.Config{Tmdb: config.Tmdb{CacheTTL: time.Hour, CacheSize: 2}}
+	cache := NewCachedClient(client, conf, nil, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	get := func(page int) {
+		_, status, err := cache.GetTrendingMovies(context.Background(), &models.TmdbListParams{Window: "day", Page: page}, &models.PageMeta{})
+		if err != nil || status != http.StatusOK {
+			t.Fatalf("GetTrendingMovies() status = %d, err = %v", status, err)
+		}
+	}
+	get(1)
+	get(2)
+	//page 1 becomes the most recently used, so page 2 is evicted by page 3
+	get(1)
+	get(3)
+	if calls := atomic.LoadInt32(&client.calls); calls != 3 {
+		t.Fatalf("client calls = %d, want 3", calls)
+	}
+
+	get(1)
+	if calls := atomic.LoadInt32(&client.calls); calls != 3 {
+		t.Errorf("client calls = %d, want 3 as page 1 is cached", calls)
+	}
+	get(2)
+	if calls := atomic.LoadInt32(&client.calls); calls != 4 {
+		t.Errorf("client calls = %d, want 4 as page 2 was evicted", calls)
+	}
+	if len(cache.entries) != 2 || cache.recent.Len() != 2 {
+		t.Errorf("entries = %d, recent = %d, want 2", len(cache.entries), cache.recent.Len())
+	}
+}
+
+func TestCachedClient_DiscoverMovies_notPersisted(t *testing.T) {
+	snapshots := &snapshotStore{snapshots: map[string]models.Snapshot{}}
+	client := &countingClient{status: http.StatusOK}
+	cache := newTestCachedClient(client, time.Hour, snapshots)
+
+	params := &models.DiscoverParams{TmdbListParams: models.TmdbListParams{Page: 1}, Genres: "18,35"}
+	for i := 0; i < 2; i++ {
+		movies, status, err := cache.DiscoverMovies(context.Background(), params, &models.PageMeta{})
+		if err != nil || status != http.StatusOK || len(movies) != 1 {
+			t.Fatalf("DiscoverMovies() movies = %v, status = %d, err = %v", movies, status, err)
+		}
+	}
+
+	if calls := atomic.LoadInt32(&client.calls); calls != 1 {
+		t.Errorf("client calls = %d, want 1 as results are cached in memory", calls)
+	}
+	if len(snapshots.snapshots) != 0 {
+		t.Errorf("snapshots = %v, want none for discover", snapshots.snapshots)
+	}
+}
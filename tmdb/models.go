@@ -1,9 +1,39 @@
 package tmdb
 
+import "github.com/BarTar213/movies-service/models"
+
 type Movie struct {
 	Id int `json:"id"`
 }
 
 type LatestResponse struct {
-	Results []Movie `json:"results"`
+	Page         int     `json:"page"`
+	TotalPages   int     `json:"total_pages"`
+	TotalResults int     `json:"total_results"`
+	Results      []Movie `json:"results"`
+}
+
+func (r *LatestResponse) meta() models.PageMeta {
+	return models.PageMeta{
+		Page:         r.Page,
+		TotalPages:   r.TotalPages,
+		TotalResults: r.TotalResults,
+	}
+}
+
+type TranslationsResponse struct {
+	Id           int           `json:"id"`
+	Translations []Translation `json:"translations"`
+}
+
+type Translation struct {
+	Country  string          `json:"iso_3166_1"`
+	Language string          `json:"iso_639_1"`
+	Data     TranslationData `json:"data"`
+}
+
+type TranslationData struct {
+	Title    string `json:"title"`
+	Overview string `json:"overview"`
+	Tagline  string `json:"tagline"`
 }
@@ -0,0 +1,184 @@
+package tmdb
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	stateClosed = iota
+	stateOpen
+	stateHalfOpen
+)
+
+var ErrCircuitOpen = errors.New("tmdb: circuit breaker is open")
+
+// rateLimiter is a token bucket refilled continuously with rate tokens per second
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	return &rateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait blocks until token is available or context is done
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+		l.last = now
+
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		l.mu.Unlock()
+
+		err := sleep(ctx, wait)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// circuitBreaker opens after threshold consecutive failures and lets single trial request through after cooldown
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	state     int
+	openedAt  time.Time
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+func (b *circuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case stateOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = stateHalfOpen
+		return true
+	case stateHalfOpen:
+		return false
+	}
+	return true
+}
+
+func (b *circuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.state = stateClosed
+}
+
+func (b *circuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.state == stateHalfOpen || b.failures >= b.threshold {
+		b.state = stateOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// Cancel releases trial request which ended without result so the next request can try again
+func (b *circuitBreaker) Cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == stateHalfOpen {
+		b.state = stateOpen
+	}
+}
+
+func (b *circuitBreaker) Open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state != stateClosed
+}
+
+// returns exponential backoff with full jitter, Retry-After takes precedence when present
+func backoff(attempt int, base, max, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return retryAfter
+	}
+
+	ceiling := base << uint(attempt)
+	if ceiling <= 0 || ceiling > max {
+		ceiling = max
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+// parses Retry-After header given either in seconds or as HTTP date
+func parseRetryAfter(header http.Header) time.Duration {
+	value := header.Get("Retry-After")
+	if len(value) == 0 {
+		return 0
+	}
+
+	seconds, err := strconv.Atoi(value)
+	if err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0
+	}
+	wait := time.Until(date)
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,165 @@
+package tmdb
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/BarTar213/movies-service/config"
+	"github.com/BarTar213/movies-service/models"
+)
+
+func newTestClient(url string, conf config.Tmdb) *Tmdb {
+	conf.Url = url
+	return NewClient(time.Second, &config.Config{Tmdb: conf}, nil).(*Tmdb)
+}
+
+func TestTmdb_get_retries(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int32
+		failStatus   int
+		maxRetries   int
+		wantStatus   int
+		wantRequests int32
+	}{
+		{
+			name:         "positive_retry_server_error",
+			failures:     2,
+			failStatus:   http.StatusBadGateway,
+			maxRetries:   3,
+			wantStatus:   http.StatusOK,
+			wantRequests: 3,
+		},
+		{
+			name:         "positive_retry_too_many_requests",
+			failures:     1,
+			failStatus:   http.StatusTooManyRequests,
+			maxRetries:   1,
+			wantStatus:   http.StatusOK,
+			wantRequests: 2,
+		},
+		{
+			name:         "negative_retries_exhausted",
+			failures:     5,
+			failStatus:   http.StatusServiceUnavailable,
+			maxRetries:   2,
+			wantStatus:   http.StatusServiceUnavailable,
+			wantRequests: 3,
+		},
+		{
+			name:         "negative_not_found_not_retried",
+			failures:     5,
+			failStatus:   http.StatusNotFound,
+			maxRetries:   3,
+			wantStatus:   http.StatusNotFound,
+			wantRequests: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&requests, 1) <= tt.failures {
+					w.Header().Set("Retry-After", "0")
+					w.WriteHeader(tt.failStatus)
+					return
+				}
+				_, _ = w.Write([]byte(`{"id": 1}`))
+			}))
+			defer server.Close()
+
+			c := newTestClient(server.URL, config.Tmdb{MaxRetries: tt.maxRetries})
+			status, _ := c.GetPerson(context.Background(), 1, &models.Person{})
+
+			if status != tt.wantStatus {
+				t.Errorf("get() status = %d, want %d", status, tt.wantStatus)
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("get() requests = %d, want %d", requests, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestTmdb_get_circuitBreaker(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	c := newTestClient(server.URL, config.Tmdb{BreakerThreshold: 2, BreakerCooldown: time.Hour})
+	for i := 0; i < 2; i++ {
+		_, _ = c.GetPerson(context.Background(), 1, &models.Person{})
+	}
+
+	status, err := c.GetPerson(context.Background(), 1, &models.Person{})
+	if err != ErrCircuitOpen || status != http.StatusServiceUnavailable {
+		t.Errorf("get() = %d, %v, want %d, %v", status, err, http.StatusServiceUnavailable, ErrCircuitOpen)
+	}
+	if requests != 2 {
+		t.Errorf("get() requests = %d, want 2", requests)
+	}
+}
+
+func Test_circuitBreaker_halfOpen(t *testing.T) {
+	b := newCircuitBreaker(1, time.Millisecond)
+	b.Failure()
+	if b.Allow() {
+		t.Errorf("Allow() = true right after opening")
+	}
+
+	time.Sleep(2 * time.Millisecond)
+	if !b.Allow() {
+		t.Errorf("Allow() = false after cooldown")
+	}
+	if b.Allow() {
+		t.Errorf("Allow() = true while trial request is in flight")
+	}
+
+	b.Success()
+	if !b.Allow() || b.Open() {
+		t.Errorf("breaker not closed after successful trial")
+	}
+}
+
+func Test_parseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{name: "empty", value: "", want: 0},
+		{name: "seconds", value: "3", want: 3 * time.Second},
+		{name: "negative_seconds", value: "-3", want: 0},
+		{name: "invalid", value: "soon", want: 0},
+		{name: "past_date", value: "Wed, 21 Oct 2015 07:28:00 GMT", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			header.Set("Retry-After", tt.value)
+			if got := parseRetryAfter(header); got != tt.want {
+				t.Errorf("parseRetryAfter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_rateLimiter_Wait(t *testing.T) {
+	l := newRateLimiter(1, 1)
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); err == nil {
+		t.Errorf("Wait() expected context error when bucket is empty")
+	}
+}
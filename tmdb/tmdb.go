@@ -1,127 +1,491 @@
 package tmdb
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
 	"net/http"
+	neturl "net/url"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/BarTar213/movies-service/config"
+	"github.com/BarTar213/movies-service/metrics"
 	"github.com/BarTar213/movies-service/models"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
+const (
+	defaultConcurrency      = 5
+	defaultRateLimit        = 20
+	defaultBurst            = 20
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = 30 * time.Second
+
+	baseBackoff = 200 * time.Millisecond
+	maxBackoff  = 5 * time.Second
+
+	outcomeSuccess      = "success"
+	outcomeClientError  = "client_error"
+	outcomeRateLimited  = "rate_limited"
+	outcomeServerError  = "server_error"
+	outcomeNetworkError = "network_error"
+	outcomeDecodeError  = "decode_error"
+	outcomeCircuitOpen  = "circuit_open"
+	outcomeCanceled     = "canceled"
+	outcomeRetry        = "retry"
+)
+
+var tracer = otel.Tracer("github.com/BarTar213/movies-service/tmdb")
+
 type Client interface {
-	GetCredits(movieId int, credit *models.Credit) (int, error)
-	GetTrendingMovies() ([]models.TmdbMovie, int, error)
-	GetTopRatedMovies() ([]int, int, error)
+	Ping(ctx context.Context) error
+	GetMovieDetails(ctx context.Context, id int, movie *models.TmdbMovie) (int, error)
+	GetCredits(ctx context.Context, movieId int, credit *models.Credit) (int, error)
+	GetTrendingMovies(ctx context.Context, params *models.TmdbListParams, meta *models.PageMeta) ([]models.TmdbMovie, int, error)
+	GetTopRatedMovies(ctx context.Context, params *models.TmdbListParams, meta *models.PageMeta) ([]int, int, error)
+	GetNowPlayingMovies(ctx context.Context, params *models.TmdbListParams, meta *models.PageMeta) ([]models.TmdbMovie, int, error)
+	GetUpcomingMovies(ctx context.Context, params *models.TmdbListParams, meta *models.PageMeta) ([]models.TmdbMovie, int, error)
+	GetPopularMovies(ctx context.Context, params *models.TmdbListParams, meta *models.PageMeta) ([]models.TmdbMovie, int, error)
+	DiscoverMovies(ctx context.Context, params *models.DiscoverParams, meta *models.PageMeta) ([]models.TmdbMovie, int, error)
+	GetPerson(ctx context.Context, personId int, person *models.Person) (int, error)
+	GetImages(ctx context.Context, movieId int, images *models.Images) (int, error)
+	GetVideos(ctx context.Context, movieId int, videos *models.Videos) (int, error)
+	GetTranslations(ctx context.Context, movieId int) ([]models.Translation, int, error)
 }
 
 type Tmdb struct {
-	HttpClient *http.Client
-	ApiKey     string
-	BaseUrl    string
+	HttpClient  *http.Client
+	ApiKey      string
+	BaseUrl     string
+	Concurrency int
+	MaxRetries  int
+
+	limiter *rateLimiter
+	breaker *circuitBreaker
+	metrics *metrics.Metrics
 }
 
-func NewClient(timeout time.Duration, config *config.Config) Client {
-	client := http.DefaultClient
-	client.Timeout = timeout
+// result of single request to TMDB
+type attempt struct {
+	status     int
+	retryAfter time.Duration
+	retryable  bool
+	outcome    string
+	err        error
+}
 
-	return &Tmdb{
-		HttpClient: client,
-		ApiKey:     config.Tmdb.Key,
-		BaseUrl:    config.Tmdb.Url,
+func NewClient(timeout time.Duration, config *config.Config, metrics *metrics.Metrics) Client {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   timeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   20,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   timeout,
+		ResponseHeaderTimeout: timeout,
+		ExpectContinueTimeout: time.Second,
 	}
-}
 
-func (c *Tmdb) GetCredits(movieId int, credit *models.Credit) (int, error) {
-	url := fmt.Sprintf("%s/movie/%d/credits?api_key=%s", c.BaseUrl, movieId, c.ApiKey)
+	concurrency := config.Tmdb.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+	rateLimit := config.Tmdb.RateLimit
+	if rateLimit <= 0 {
+		rateLimit = defaultRateLimit
+	}
+	burst := config.Tmdb.Burst
+	if burst <= 0 {
+		burst = defaultBurst
+	}
+	threshold := config.Tmdb.BreakerThreshold
+	if threshold <= 0 {
+		threshold = defaultBreakerThreshold
+	}
+	cooldown := config.Tmdb.BreakerCooldown
+	if cooldown <= 0 {
+		cooldown = defaultBreakerCooldown
+	}
 
-	request, err := http.NewRequest(http.MethodGet, url, nil)
+	return &Tmdb{
+		HttpClient: &http.Client{
+			Timeout:   timeout,
+			Transport: transport,
+		},
+		ApiKey:      config.Tmdb.Key,
+		BaseUrl:     config.Tmdb.Url,
+		Concurrency: concurrency,
+		MaxRetries:  config.Tmdb.MaxRetries,
+		limiter:     newRateLimiter(rateLimit, burst),
+		breaker:     newCircuitBreaker(threshold, cooldown),
+		metrics:     metrics,
+	}
+}
 
-	resp, err := c.HttpClient.Do(request)
+// Ping sends single request to TMDB bypassing retries and circuit breaker, url isn't returned in error as it contains api key
+func (c *Tmdb) Ping(ctx context.Context) error {
+	err := c.limiter.Wait(ctx)
 	if err != nil {
-		return http.StatusInternalServerError, err
+		return err
 	}
 
-	defer resp.Body.Close()
-	err = json.NewDecoder(resp.Body).Decode(credit)
-	if err != nil {
-		return http.StatusInternalServerError, err
+	result := c.do(ctx, fmt.Sprintf("%s/configuration?api_key=%s", c.BaseUrl, c.ApiKey), &struct{}{})
+	var urlErr *neturl.Error
+	switch {
+	case errors.As(result.err, &urlErr):
+		return urlErr.Err
+	case result.err != nil:
+		return result.err
+	case result.status != http.StatusOK:
+		return fmt.Errorf("tmdb responded with status %d", result.status)
 	}
+	return nil
+}
 
-	return resp.StatusCode, nil
+func (c *Tmdb) GetCredits(ctx context.Context, movieId int, credit *models.Credit) (int, error) {
+	url := fmt.Sprintf("%s/movie/%d/credits?api_key=%s", c.BaseUrl, movieId, c.ApiKey)
+
+	return c.get(ctx, url, credit)
 }
 
-func (c *Tmdb) GetTrendingMovies() ([]models.TmdbMovie, int, error) {
-	url := fmt.Sprintf("%s/trending/movie/day?api_key=%s", c.BaseUrl, c.ApiKey)
+func (c *Tmdb) GetTrendingMovies(ctx context.Context, params *models.TmdbListParams, meta *models.PageMeta) ([]models.TmdbMovie, int, error) {
+	url := fmt.Sprintf("%s/trending/movie/%s?api_key=%s&page=%d", c.BaseUrl, params.Window, c.ApiKey, params.Page)
 
-	request, err := http.NewRequest(http.MethodGet, url, nil)
+	return c.getMovies(ctx, url, meta)
+}
 
-	resp, err := c.HttpClient.Do(request)
-	if err != nil {
-		return nil, http.StatusInternalServerError, err
+func (c *Tmdb) GetNowPlayingMovies(ctx context.Context, params *models.TmdbListParams, meta *models.PageMeta) ([]models.TmdbMovie, int, error) {
+	return c.getMovies(ctx, c.listUrl("movie/now_playing", params), meta)
+}
+
+func (c *Tmdb) GetUpcomingMovies(ctx context.Context, params *models.TmdbListParams, meta *models.PageMeta) ([]models.TmdbMovie, int, error) {
+	return c.getMovies(ctx, c.listUrl("movie/upcoming", params), meta)
+}
+
+func (c *Tmdb) GetPopularMovies(ctx context.Context, params *models.TmdbListParams, meta *models.PageMeta) ([]models.TmdbMovie, int, error) {
+	return c.getMovies(ctx, c.listUrl("movie/popular", params), meta)
+}
+
+func (c *Tmdb) DiscoverMovies(ctx context.Context, params *models.DiscoverParams, meta *models.PageMeta) ([]models.TmdbMovie, int, error) {
+	query := c.listQuery(&params.TmdbListParams)
+	if len(params.SortBy) > 0 {
+		query.Set("sort_by", params.SortBy)
+	}
+	if len(params.Genres) > 0 {
+		query.Set("with_genres", params.Genres)
 	}
+	if params.Year > 0 {
+		query.Set("primary_release_year", strconv.Itoa(params.Year))
+	}
+	url := fmt.Sprintf("%s/discover/movie?%s", c.BaseUrl, query.Encode())
+
+	return c.getMovies(ctx, url, meta)
+}
 
+// fetches list of movies and their details concurrently, movies which details couldn't be fetched are skipped,
+// failure is returned only when none of the details could be fetched
+func (c *Tmdb) getMovies(ctx context.Context, url string, meta *models.PageMeta) ([]models.TmdbMovie, int, error) {
 	response := &LatestResponse{}
-	defer resp.Body.Close()
-	err = json.NewDecoder(resp.Body).Decode(&response)
-	if err != nil {
-		return nil, http.StatusInternalServerError, err
+	status, err := c.get(ctx, url, response)
+	if err != nil || status != http.StatusOK {
+		return nil, status, err
 	}
+	*meta = response.meta()
 
-	movies := make([]models.TmdbMovie, 0, len(response.Results))
-	for _, id := range response.Results {
-		movie := &models.TmdbMovie{}
-		status, err := c.GetMovieDetails(id.Id, movie)
-		if err != nil || status != http.StatusOK {
-			continue
+	details := make([]*models.TmdbMovie, len(response.Results))
+	semaphore := make(chan struct{}, c.Concurrency)
+	wg := sync.WaitGroup{}
+	once := sync.Once{}
+	failedStatus, failedErr := http.StatusOK, error(nil)
+
+	for i, result := range response.Results {
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return nil, http.StatusInternalServerError, ctx.Err()
 		}
-		movies = append(movies, *movie)
-	}
 
-	return movies, resp.StatusCode, nil
-}
+		wg.Add(1)
+		go func(i, id int) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
 
-func (c *Tmdb) GetTopRatedMovies() ([]int, int, error) {
-	url := fmt.Sprintf("%s/movie/top_rated?api_key=%s", c.BaseUrl, c.ApiKey)
+			movie := &models.TmdbMovie{}
+			status, err := c.GetMovieDetails(ctx, id, movie)
+			if err != nil || status != http.StatusOK {
+				once.Do(func() {
+					failedStatus, failedErr = status, err
+				})
+				return
+			}
+			details[i] = movie
+		}(i, result.Id)
+	}
+	wg.Wait()
 
-	request, err := http.NewRequest(http.MethodGet, url, nil)
+	if ctx.Err() != nil {
+		return nil, http.StatusInternalServerError, ctx.Err()
+	}
 
-	resp, err := c.HttpClient.Do(request)
-	if err != nil {
-		return nil, http.StatusInternalServerError, err
+	movies := make([]models.TmdbMovie, 0, len(details))
+	for _, movie := range details {
+		if movie != nil {
+			movies = append(movies, *movie)
+		}
+	}
+	if len(movies) == 0 && len(details) > 0 {
+		return nil, failedStatus, failedErr
 	}
 
+	return movies, status, nil
+}
+
+func (c *Tmdb) GetTopRatedMovies(ctx context.Context, params *models.TmdbListParams, meta *models.PageMeta) ([]int, int, error) {
+	url := c.listUrl("movie/top_rated", params)
+
 	response := &LatestResponse{}
-	defer resp.Body.Close()
-	err = json.NewDecoder(resp.Body).Decode(&response)
-	if err != nil {
-		return nil, http.StatusInternalServerError, err
+	status, err := c.get(ctx, url, response)
+	if err != nil || status != http.StatusOK {
+		return nil, status, err
 	}
+	*meta = response.meta()
 
 	ids := make([]int, 0, len(response.Results))
 	for i := range response.Results {
 		ids = append(ids, response.Results[i].Id)
 	}
 
-	return ids, resp.StatusCode, nil
+	return ids, status, nil
+}
+
+// returns url of paginated TMDB list, region is passed only when set
+func (c *Tmdb) listUrl(path string, params *models.TmdbListParams) string {
+	return fmt.Sprintf("%s/%s?%s", c.BaseUrl, path, c.listQuery(params).Encode())
+}
+
+// params are encoded, so values from requests can't add or cut query params
+func (c *Tmdb) listQuery(params *models.TmdbListParams) neturl.Values {
+	query := neturl.Values{}
+	query.Set("api_key", c.ApiKey)
+	query.Set("page", strconv.Itoa(params.Page))
+	if len(params.Region) > 0 {
+		query.Set("region", params.Region)
+	}
+	return query
 }
 
-func (c *Tmdb) GetMovieDetails(id int, movie *models.TmdbMovie) (int, error) {
+func (c *Tmdb) GetMovieDetails(ctx context.Context, id int, movie *models.TmdbMovie) (int, error) {
 	url := fmt.Sprintf("%s/movie/%d?api_key=%s", c.BaseUrl, id, c.ApiKey)
 
-	request, err := http.NewRequest(http.MethodGet, url, nil)
+	return c.get(ctx, url, movie)
+}
 
-	resp, err := c.HttpClient.Do(request)
+func (c *Tmdb) GetPerson(ctx context.Context, personId int, person *models.Person) (int, error) {
+	url := fmt.Sprintf("%s/person/%d?api_key=%s", c.BaseUrl, personId, c.ApiKey)
+
+	return c.get(ctx, url, person)
+}
+
+func (c *Tmdb) GetImages(ctx context.Context, movieId int, images *models.Images) (int, error) {
+	url := fmt.Sprintf("%s/movie/%d/images?api_key=%s", c.BaseUrl, movieId, c.ApiKey)
+
+	return c.get(ctx, url, images)
+}
+
+func (c *Tmdb) GetVideos(ctx context.Context, movieId int, videos *models.Videos) (int, error) {
+	url := fmt.Sprintf("%s/movie/%d/videos?api_key=%s", c.BaseUrl, movieId, c.ApiKey)
+
+	return c.get(ctx, url, videos)
+}
+
+// returns one translation per language, the first one listed by TMDB wins
+func (c *Tmdb) GetTranslations(ctx context.Context, movieId int) ([]models.Translation, int, error) {
+	url := fmt.Sprintf("%s/movie/%d/translations?api_key=%s", c.BaseUrl, movieId, c.ApiKey)
+
+	response := &TranslationsResponse{}
+	status, err := c.get(ctx, url, response)
+	if err != nil || status != http.StatusOK {
+		return nil, status, err
+	}
+
+	seen := make(map[string]bool, len(response.Translations))
+	translations := make([]models.Translation, 0, len(response.Translations))
+	for _, t := range response.Translations {
+		if seen[t.Language] {
+			continue
+		}
+		seen[t.Language] = true
+		translations = append(translations, models.Translation{
+			MovieId:  movieId,
+			Language: t.Language,
+			Country:  t.Country,
+			Title:    t.Data.Title,
+			Overview: t.Data.Overview,
+			Tagline:  t.Data.Tagline,
+		})
+	}
+
+	return translations, status, nil
+}
+
+// decodes response body into v only when TMDB responded with status OK,
+// requests are rate limited, retried on 429/5xx/network errors and fail fast when circuit is open
+func (c *Tmdb) get(ctx context.Context, url string, v interface{}) (int, error) {
+	start := time.Now()
+	ctx, span := c.startSpan(ctx, url)
+	defer span.End()
+
+	if !c.breaker.Allow() {
+		c.observe(outcomeCircuitOpen)
+		c.observeDuration(url, outcomeCircuitOpen, start)
+		endSpan(span, attempt{status: http.StatusServiceUnavailable, outcome: outcomeCircuitOpen, err: ErrCircuitOpen}, 0)
+		return http.StatusServiceUnavailable, ErrCircuitOpen
+	}
+
+	var result attempt
+	var retries int
+	for i := 0; ; i++ {
+		err := c.limiter.Wait(ctx)
+		if err != nil {
+			result = attempt{status: http.StatusInternalServerError, outcome: outcomeCanceled, err: err}
+			break
+		}
+
+		result = c.do(ctx, url, v)
+		if !result.retryable || i >= c.MaxRetries || ctx.Err() != nil {
+			break
+		}
+
+		wait := backoff(i, baseBackoff, maxBackoff, result.retryAfter)
+		if wait > maxBackoff {
+			break
+		}
+		c.observe(outcomeRetry)
+		retries++
+		err = sleep(ctx, wait)
+		if err != nil {
+			result = attempt{status: http.StatusInternalServerError, outcome: outcomeCanceled, err: err}
+			break
+		}
+	}
+
+	switch {
+	case ctx.Err() != nil:
+		result.outcome = outcomeCanceled
+		c.breaker.Cancel()
+	case result.retryable:
+		c.breaker.Failure()
+	default:
+		c.breaker.Success()
+	}
+	c.observe(result.outcome)
+	c.observeDuration(url, result.outcome, start)
+	endSpan(span, result, retries)
+
+	return result.status, result.err
+}
+
+// trace context isn't injected into requests, it's propagated only to internal services
+func (c *Tmdb) do(ctx context.Context, url string, v interface{}) attempt {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return http.StatusInternalServerError, err
+		return attempt{status: http.StatusInternalServerError, outcome: outcomeClientError, err: err}
 	}
 
+	resp, err := c.HttpClient.Do(request)
+	if err != nil {
+		return attempt{status: http.StatusInternalServerError, outcome: outcomeNetworkError, retryable: true, err: err}
+	}
 	defer resp.Body.Close()
-	err = json.NewDecoder(resp.Body).Decode(movie)
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		return attempt{status: resp.StatusCode, outcome: outcomeRateLimited, retryable: true, retryAfter: parseRetryAfter(resp.Header)}
+	case resp.StatusCode >= http.StatusInternalServerError:
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		return attempt{status: resp.StatusCode, outcome: outcomeServerError, retryable: true, retryAfter: parseRetryAfter(resp.Header)}
+	case resp.StatusCode != http.StatusOK:
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		return attempt{status: resp.StatusCode, outcome: outcomeClientError}
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(v)
 	if err != nil {
-		return http.StatusInternalServerError, err
+		return attempt{status: http.StatusInternalServerError, outcome: outcomeDecodeError, err: err}
 	}
 
-	return resp.StatusCode, nil
+	return attempt{status: resp.StatusCode, outcome: outcomeSuccess}
+}
+
+// span of call covers all retries, url isn't recorded as it contains api key
+func (c *Tmdb) startSpan(ctx context.Context, url string) (context.Context, trace.Span) {
+	endpoint := c.endpoint(url)
+	return tracer.Start(ctx, "tmdb GET "+endpoint,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodGet,
+			attribute.String("tmdb.endpoint", endpoint),
+		),
+	)
+}
+
+func endSpan(span trace.Span, result attempt, retries int) {
+	span.SetAttributes(
+		semconv.HTTPResponseStatusCode(result.status),
+		attribute.String("tmdb.outcome", result.outcome),
+		attribute.Int("tmdb.retries", retries),
+	)
+	if result.err != nil {
+		span.RecordError(result.err)
+	}
+	if result.outcome != outcomeSuccess {
+		span.SetStatus(codes.Error, result.outcome)
+	}
+}
+
+func (c *Tmdb) observe(outcome string) {
+	if c.metrics == nil {
+		return
+	}
+	c.metrics.TmdbRequests.WithLabelValues(outcome).Inc()
+}
+
+func (c *Tmdb) observeDuration(url string, outcome string, start time.Time) {
+	if c.metrics == nil {
+		return
+	}
+	c.metrics.TmdbRequestDuration.WithLabelValues(c.endpoint(url), outcome).Observe(time.Since(start).Seconds())
+}
+
+// endpoint returns path of url without query and with ids replaced, so it can be used as metric label
+func (c *Tmdb) endpoint(url string) string {
+	path := strings.TrimPrefix(url, c.BaseUrl)
+	if i := strings.Index(path, "?"); i >= 0 {
+		path = path[:i]
+	}
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if _, err := strconv.Atoi(segment); err == nil {
+			segments[i] = ":id"
+		}
+	}
+	return strings.Join(segments, "/")
 }
@@ -0,0 +1,254 @@
+package tmdb
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/BarTar213/movies-service/config"
+	"github.com/BarTar213/movies-service/models"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTmdb_GetTopRatedMovies(t *testing.T) {
+	tests := []struct {
+		name      string
+		params    *models.TmdbListParams
+		wantQuery string
+	}{
+		{
+			name:      "positive_first_page",
+			params:    &models.TmdbListParams{Page: 1},
+			wantQuery: "api_key=key&page=1",
+		},
+		{
+			name:      "positive_page_with_region",
+			params:    &models.TmdbListParams{Page: 3, Region: "PL"},
+			wantQuery: "api_key=key&page=3&region=PL",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotQuery := ""
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotQuery = r.URL.RawQuery
+				_, _ = w.Write([]byte(`{"page":3,"total_pages":7,"total_results":140,"results":[{"id":1},{"id":2}]}`))
+			}))
+			defer server.Close()
+
+			client := newTestClient(server.URL, config.Tmdb{Key: "key"})
+			meta := &models.PageMeta{}
+			ids, status, err := client.GetTopRatedMovies(context.Background(), tt.params, meta)
+			if err != nil || status != http.StatusOK {
+				t.Fatalf("GetTopRatedMovies() status = %d, err = %v", status, err)
+			}
+			if gotQuery != tt.wantQuery {
+				t.Errorf("GetTopRatedMovies() query = %s, want %s", gotQuery, tt.wantQuery)
+			}
+			if len(ids) != 2 || meta.TotalPages != 7 || meta.TotalResults != 140 {
+				t.Errorf("GetTopRatedMovies() ids = %v, meta = %+v", ids, meta)
+			}
+		})
+	}
+}
+
+func TestTmdb_GetTrendingMovies_window(t *testing.T) {
+	gotPath := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		_, _ = w.Write([]byte(`{"page":2,"total_pages":5,"total_results":100,"results":[]}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, config.Tmdb{Key: "key"})
+	meta := &models.PageMeta{}
+	_, status, err := client.GetTrendingMovies(context.Background(), &models.TmdbListParams{Window: "week", Page: 2}, meta)
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("GetTrendingMovies() status = %d, err = %v", status, err)
+	}
+	if gotPath != "/trending/movie/week" {
+		t.Errorf("GetTrendingMovies() path = %s, want /trending/movie/week", gotPath)
+	}
+	if meta.Page != 2 || meta.TotalPages != 5 {
+		t.Errorf("GetTrendingMovies() meta = %+v", meta)
+	}
+}
+
+func TestTmdb_traceContextNotSent(t *testing.T) {
+	propagator := otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTextMapPropagator(propagator)
+	})
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	header := http.Header{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		_, _ = w.Write([]byte(`{"page":1,"total_pages":1,"total_results":0,"results":[]}`))
+	}))
+	defer server.Close()
+
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9},
+		SpanID:     trace.SpanID{0x00, 0xf0},
+		TraceFlags: trace.FlagsSampled,
+	})
+	member, _ := baggage.NewMember("account_id", "1")
+	bag, _ := baggage.New(member)
+	ctx := baggage.ContextWithBaggage(trace.ContextWithSpanContext(context.Background(), spanContext), bag)
+
+	client := newTestClient(server.URL, config.Tmdb{Key: "key"})
+	_, status, err := client.GetTrendingMovies(ctx, &models.TmdbListParams{Window: "day", Page: 1}, &models.PageMeta{})
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("GetTrendingMovies() status = %d, err = %v", status, err)
+	}
+	for _, name := range []string{"traceparent", "tracestate", "baggage"} {
+		if value := header.Get(name); len(value) > 0 {
+			t.Errorf("GetTrendingMovies() sent %s = %s to TMDB", name, value)
+		}
+	}
+}
+
+func TestTmdb_DiscoverMovies(t *testing.T) {
+	gotPath, gotQuery := "", ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotQuery = r.URL.Path, r.URL.RawQuery
+		_, _ = w.Write([]byte(`{"page":1,"total_pages":1,"total_results":0,"results":[]}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, config.Tmdb{Key: "key"})
+	params := &models.DiscoverParams{
+		TmdbListParams: models.TmdbListParams{Page: 1, Region: "US"},
+		SortBy:         "vote_average.desc",
+		Genres:         "18,35",
+		Year:           2019,
+	}
+	_, status, err := client.DiscoverMovies(context.Background(), params, &models.PageMeta{})
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("DiscoverMovies() status = %d, err = %v", status, err)
+	}
+
+	wantQuery := "api_key=key&page=1&primary_release_year=2019&region=US&sort_by=vote_average.desc&with_genres=18%2C35"
+	if gotPath != "/discover/movie" || gotQuery != wantQuery {
+		t.Errorf("DiscoverMovies() path = %s, query = %s", gotPath, gotQuery)
+	}
+}
+
+func TestTmdb_listUrl(t *testing.T) {
+	client := &Tmdb{BaseUrl: "https://api.themoviedb.org/3", ApiKey: "key"}
+	tests := []struct {
+		name   string
+		params *models.TmdbListParams
+		want   string
+	}{
+		{
+			name:   "positive_list_url",
+			params: &models.TmdbListParams{Page: 2},
+			want:   "https://api.themoviedb.org/3/movie/popular?api_key=key&page=2",
+		},
+		{
+			name:   "positive_list_url_region",
+			params: &models.TmdbListParams{Page: 1, Region: "PL"},
+			want:   "https://api.themoviedb.org/3/movie/popular?api_key=key&page=1&region=PL",
+		},
+		{
+			name:   "positive_list_url_region_encoded",
+			params: &models.TmdbListParams{Page: 1, Region: "P&page=9#"},
+			want:   "https://api.themoviedb.org/3/movie/popular?api_key=key&page=1&region=P%26page%3D9%23",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := client.listUrl("movie/popular", tt.params); got != tt.want {
+				t.Errorf("listUrl() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTmdb_endpoint(t *testing.T) {
+	client := &Tmdb{BaseUrl: "https://api.themoviedb.org/3", ApiKey: "key"}
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{
+			name: "movie_details",
+			url:  "https://api.themoviedb.org/3/movie/550?api_key=key",
+			want: "/movie/:id",
+		},
+		{
+			name: "movie_credits",
+			url:  "https://api.themoviedb.org/3/movie/550/credits?api_key=key",
+			want: "/movie/:id/credits",
+		},
+		{
+			name: "list",
+			url:  "https://api.themoviedb.org/3/movie/top_rated?api_key=key&page=2",
+			want: "/movie/top_rated",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := client.endpoint(tt.url); got != tt.want {
+				t.Errorf("endpoint() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTmdb_Ping(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		closed  bool
+		wantErr bool
+	}{
+		{
+			name:   "positive_ping",
+			status: http.StatusOK,
+		},
+		{
+			name:    "negative_ping_unauthorized",
+			status:  http.StatusUnauthorized,
+			wantErr: true,
+		},
+		{
+			name:    "negative_ping_unreachable",
+			closed:  true,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/configuration" {
+					t.Errorf("Ping() path = %s, want /configuration", r.URL.Path)
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(`{}`))
+			}))
+			if tt.closed {
+				server.Close()
+			} else {
+				defer server.Close()
+			}
+
+			client := newTestClient(server.URL, config.Tmdb{Key: "secret"})
+			err := client.Ping(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Ping() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && strings.Contains(err.Error(), "secret") {
+				t.Errorf("Ping() error %s contains api key", err)
+			}
+		})
+	}
+}
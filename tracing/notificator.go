@@ -0,0 +1,64 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/BarTar213/notificator/client"
+	"github.com/BarTar213/notificator/models"
+	"github.com/BarTar213/notificator/senders"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/BarTar213/movies-service/tracing")
+
+// notificator records client span for every notification, trace context isn't sent to notificator
+// as its client doesn't allow setting request headers
+type notificator struct {
+	client client.Client
+}
+
+func NewNotificator(client client.Client) client.Client {
+	return &notificator{client: client}
+}
+
+func (n *notificator) SendInternal(ctx context.Context, templateName string, internal *senders.Internal) (int, *models.Response, error) {
+	ctx, span := n.start(ctx, templateName, "internal")
+	status, response, err := n.client.SendInternal(ctx, templateName, internal)
+	end(span, status, err)
+	return status, response, err
+}
+
+func (n *notificator) SendEmail(ctx context.Context, templateName string, email *senders.Email) (int, *models.Response, error) {
+	ctx, span := n.start(ctx, templateName, "email")
+	status, response, err := n.client.SendEmail(ctx, templateName, email)
+	end(span, status, err)
+	return status, response, err
+}
+
+func (n *notificator) start(ctx context.Context, templateName string, senderType string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "notificator send",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("notificator.template", templateName),
+			attribute.String("notificator.sender", senderType),
+		),
+	)
+}
+
+// end closes span of http call, error status is set on failed calls and responses with 4xx/5xx status
+func end(span trace.Span, status int, err error) {
+	defer span.End()
+
+	span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+	switch {
+	case err != nil:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	case status >= 400:
+		span.SetStatus(codes.Error, "")
+	}
+}
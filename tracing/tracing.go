@@ -0,0 +1,69 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/BarTar213/movies-service/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+const (
+	ServiceName = "movies-service"
+
+	NoneExporter   = "none"
+	StdoutExporter = "stdout"
+	OtlpExporter   = "otlp"
+)
+
+// Init sets global tracer provider exporting spans as configured and W3C trace context propagator,
+// returned function flushes spans that are not exported yet
+func Init(conf config.Tracing) (func(ctx context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	exporter, err := newExporter(conf)
+	if err != nil {
+		return nil, err
+	}
+	if exporter == nil {
+		//global provider stays no-op, incoming trace context is still propagated
+		return func(ctx context.Context) error { return nil }, nil
+	}
+
+	ratio := conf.SampleRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(ServiceName))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+func newExporter(conf config.Tracing) (sdktrace.SpanExporter, error) {
+	switch conf.Exporter {
+	case "", NoneExporter:
+		return nil, nil
+	case StdoutExporter:
+		//standard output is reserved for exported records of commands
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stderr))
+	case OtlpExporter:
+		options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(conf.Endpoint)}
+		if conf.Insecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(context.Background(), options...)
+	}
+	return nil, fmt.Errorf("unknown tracing exporter %q", conf.Exporter)
+}
@@ -0,0 +1,148 @@
+package transfer
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/BarTar213/movies-service/models"
+	"github.com/BarTar213/movies-service/storage"
+)
+
+const (
+	defaultBatchSize = 500
+	batchTimeout     = time.Minute
+)
+
+// Exporter writes records as JSON Lines reading storage in batches
+type Exporter struct {
+	storage   storage.Storage
+	batchSize int
+}
+
+func NewExporter(storage storage.Storage, batchSize int) *Exporter {
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+
+	return &Exporter{
+		storage:   storage,
+		batchSize: batchSize,
+	}
+}
+
+// Export writes records of given types in order of RecordTypes
+func (e *Exporter) Export(ctx context.Context, w io.Writer, types []string) (*Summary, error) {
+	summary := newSummary()
+	writer := bufio.NewWriter(w)
+	encoder := json.NewEncoder(writer)
+
+	selected := make(map[string]bool, len(types))
+	for _, t := range types {
+		if !validType(t) {
+			return summary, fmt.Errorf("unknown record type %q", t)
+		}
+		selected[t] = true
+	}
+
+	for _, t := range RecordTypes {
+		if !selected[t] {
+			continue
+		}
+
+		count, err := e.export(ctx, encoder, t)
+		summary.Records[t] = count
+		if err != nil {
+			return summary, &RecordError{Type: t, Err: err}
+		}
+	}
+	return summary, writer.Flush()
+}
+
+// writes all records of given type, batches are read until storage returns less records than requested
+func (e *Exporter) export(ctx context.Context, encoder *json.Encoder, recordType string) (int, error) {
+	count := 0
+	for offset := 0; ; offset += e.batchSize {
+		params := &models.PaginationParams{Offset: offset, Limit: e.batchSize}
+		records, err := e.batch(ctx, recordType, params)
+		if err != nil {
+			return count, err
+		}
+
+		for _, data := range records {
+			err = encoder.Encode(&Record{Type: recordType, Data: data})
+			if err != nil {
+				return count, err
+			}
+			count++
+		}
+
+		if len(records) < e.batchSize {
+			return count, nil
+		}
+	}
+}
+
+func (e *Exporter) batch(ctx context.Context, recordType string, params *models.PaginationParams) ([]json.RawMessage, error) {
+	ctx, cancel := context.WithTimeout(ctx, batchTimeout)
+	defer cancel()
+
+	records := make([]interface{}, 0, params.Limit)
+	switch recordType {
+	case MovieRecord:
+		movies, err := e.storage.ExportMovies(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		for i := range movies {
+			records = append(records, &movieRecord{TmdbMovie: movies[i], Provider: movies[i].Provider})
+		}
+	case CreditsRecord:
+		credits, err := e.storage.ExportCredits(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		for i := range credits {
+			records = append(records, &creditsRecord{Credit: credits[i], MovieId: credits[i].MovieId})
+		}
+	case CommentRecord:
+		comments, err := e.storage.ExportComments(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		for i := range comments {
+			records = append(records, &comments[i])
+		}
+	case RatingRecord:
+		ratings, err := e.storage.ExportRatings(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		for i := range ratings {
+			records = append(records, &ratings[i])
+		}
+	}
+
+	result := make([]json.RawMessage, 0, len(records))
+	for _, record := range records {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, data)
+	}
+
+	return result, nil
+}
+
+func validType(recordType string) bool {
+	for _, t := range RecordTypes {
+		if t == recordType {
+			return true
+		}
+	}
+	return false
+}
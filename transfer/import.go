@@ -0,0 +1,122 @@
+package transfer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/BarTar213/movies-service/models"
+	"github.com/BarTar213/movies-service/storage"
+)
+
+const recordTimeout = 30 * time.Second
+
+// Importer upserts records read line by line, records which failed are reported and skipped
+type Importer struct {
+	storage storage.Storage
+	onError func(*RecordError)
+}
+
+func NewImporter(storage storage.Storage, onError func(*RecordError)) *Importer {
+	return &Importer{
+		storage: storage,
+		onError: onError,
+	}
+}
+
+// Import reads JSON Lines from r, error is returned only when reading fails or ctx is done
+func (i *Importer) Import(ctx context.Context, r io.Reader) (*Summary, error) {
+	summary := newSummary()
+	reader := bufio.NewReader(r)
+
+	for line := 1; ; line++ {
+		if ctx.Err() != nil {
+			return summary, ctx.Err()
+		}
+		data, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return summary, err
+		}
+
+		data = bytes.TrimSpace(data)
+		if len(data) > 0 {
+			record := &Record{}
+			recordErr := json.Unmarshal(data, record)
+			if recordErr == nil {
+				recordErr = i.importRecord(ctx, record)
+			}
+			if recordErr != nil {
+				summary.Failed++
+				i.report(&RecordError{Line: line, Type: record.Type, Err: recordErr})
+			} else {
+				summary.Records[record.Type]++
+			}
+		}
+
+		if err == io.EOF {
+			return summary, nil
+		}
+	}
+}
+
+func (i *Importer) importRecord(ctx context.Context, record *Record) error {
+	ctx, cancel := context.WithTimeout(ctx, recordTimeout)
+	defer cancel()
+
+	switch record.Type {
+	case MovieRecord:
+		movie := &movieRecord{}
+		err := json.Unmarshal(record.Data, movie)
+		if err != nil {
+			return err
+		}
+		movie.TmdbMovie.Provider = movie.Provider
+		//vote count is rebuilt from imported ratings
+		movie.VoteCount = 0
+		return i.storage.AddMovie(ctx, &movie.TmdbMovie)
+	case CreditsRecord:
+		credits := &creditsRecord{}
+		err := json.Unmarshal(record.Data, credits)
+		if err != nil {
+			return err
+		}
+		credits.Credit.MovieId = credits.MovieId
+		for _, cast := range credits.Cast {
+			cast.CreditId = credits.Id
+		}
+		for _, crew := range credits.Crew {
+			crew.CreditId = credits.Id
+		}
+		return i.storage.AddCredits(ctx, &credits.Credit)
+	case CommentRecord:
+		comment := &models.Comment{}
+		err := json.Unmarshal(record.Data, comment)
+		if err != nil {
+			return err
+		}
+		return i.storage.UpsertComment(ctx, comment)
+	case RatingRecord:
+		rating := &models.Rating{}
+		err := json.Unmarshal(record.Data, rating)
+		if err != nil {
+			return err
+		}
+		if rating.Rating == nil {
+			return fmt.Errorf("rating is required")
+		}
+		_, err = i.storage.AddRating(ctx, rating)
+		return err
+	}
+
+	return fmt.Errorf("unknown record type %q", record.Type)
+}
+
+func (i *Importer) report(err *RecordError) {
+	if i.onError != nil {
+		i.onError(err)
+	}
+}
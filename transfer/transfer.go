@@ -0,0 +1,71 @@
+package transfer
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/BarTar213/movies-service/models"
+)
+
+const (
+	MovieRecord   = "movie"
+	CreditsRecord = "credits"
+	CommentRecord = "comment"
+	RatingRecord  = "rating"
+)
+
+// RecordTypes lists record types in the order they are exported, so records are imported after the ones they refer to
+var RecordTypes = []string{MovieRecord, CreditsRecord, CommentRecord, RatingRecord}
+
+// Record is a single line of JSON Lines file
+type Record struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// fields not serialized by models are added so the records can be imported without loss
+type movieRecord struct {
+	models.TmdbMovie
+	Provider string `json:"provider,omitempty"`
+}
+
+type creditsRecord struct {
+	models.Credit
+	MovieId int `json:"movie_id"`
+}
+
+// RecordError describes record which couldn't be imported or exported
+type RecordError struct {
+	Line int
+	Type string
+	Err  error
+}
+
+func (e *RecordError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("line %d: %s: %s", e.Line, e.Type, e.Err)
+	}
+	return fmt.Sprintf("%s: %s", e.Type, e.Err)
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// Summary counts processed records by type
+type Summary struct {
+	Records map[string]int
+	Failed  int
+}
+
+func newSummary() *Summary {
+	return &Summary{Records: make(map[string]int, len(RecordTypes))}
+}
+
+func (s *Summary) String() string {
+	result := ""
+	for _, t := range RecordTypes {
+		result += fmt.Sprintf("%s: %d, ", t, s.Records[t])
+	}
+	return fmt.Sprintf("%sfailed: %d", result, s.Failed)
+}
@@ -0,0 +1,181 @@
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/BarTar213/movies-service/mock"
+	"github.com/BarTar213/movies-service/models"
+	"github.com/BarTar213/movies-service/storage"
+)
+
+// recordingStorage keeps imported records and serves them back for export
+type recordingStorage struct {
+	mock.Storage
+	movies   []models.TmdbMovie
+	credits  []models.Credit
+	comments []models.Comment
+	ratings  []models.Rating
+}
+
+func (s *recordingStorage) AddMovie(ctx context.Context, movie *models.TmdbMovie) error {
+	s.movies = append(s.movies, *movie)
+	return nil
+}
+
+func (s *recordingStorage) AddCredits(ctx context.Context, credit *models.Credit) error {
+	s.credits = append(s.credits, *credit)
+	return nil
+}
+
+func (s *recordingStorage) UpsertComment(ctx context.Context, comment *models.Comment) error {
+	s.comments = append(s.comments, *comment)
+	return nil
+}
+
+func (s *recordingStorage) AddRating(ctx context.Context, rating *models.Rating) (storage.RatingChange, error) {
+	s.ratings = append(s.ratings, *rating)
+	return storage.RatingAdded, nil
+}
+
+func (s *recordingStorage) ExportMovies(ctx context.Context, params *models.PaginationParams) ([]models.TmdbMovie, error) {
+	start, end := bounds(len(s.movies), params)
+	return s.movies[start:end], nil
+}
+
+func (s *recordingStorage) ExportCredits(ctx context.Context, params *models.PaginationParams) ([]models.Credit, error) {
+	return s.credits, nil
+}
+
+func (s *recordingStorage) ExportComments(ctx context.Context, params *models.PaginationParams) ([]models.Comment, error) {
+	return s.comments, nil
+}
+
+func (s *recordingStorage) ExportRatings(ctx context.Context, params *models.PaginationParams) ([]models.Rating, error) {
+	return s.ratings, nil
+}
+
+func bounds(length int, params *models.PaginationParams) (int, int) {
+	start, end := params.Offset, params.Offset+params.Limit
+	if start > length {
+		start = length
+	}
+	if end > length {
+		end = length
+	}
+	return start, end
+}
+
+const jsonLines = `{"type":"movie","data":{"id":1,"title":"Movie","vote_count":100,"provider":"omdb","genres":[{"id":18,"name":"Drama"}]}}
+{"type":"credits","data":{"id":10,"movie_id":1,"cast":[{"id":5,"name":"Actor"}],"crew":[]}}
+
+{"type":"comment","data":{"id":3,"user_id":2,"movie_id":1,"content":"great"}}
+{"type":"rating","data":{"user_id":2,"movie_id":1,"rating":8}}
+{"type":"rating","data":{"user_id":3,"movie_id":1}}
+{"type":"review","data":{}}
+not json
+`
+
+func TestImporter_Import(t *testing.T) {
+	storage := &recordingStorage{}
+	errs := make([]*RecordError, 0)
+	importer := NewImporter(storage, func(err *RecordError) {
+		errs = append(errs, err)
+	})
+
+	summary, err := importer.Import(context.Background(), strings.NewReader(jsonLines))
+	if err != nil {
+		t.Fatalf("Import() err = %v", err)
+	}
+
+	for recordType, want := range map[string]int{MovieRecord: 1, CreditsRecord: 1, CommentRecord: 1, RatingRecord: 1} {
+		if summary.Records[recordType] != want {
+			t.Errorf("Import() %s records = %d, want %d", recordType, summary.Records[recordType], want)
+		}
+	}
+	if summary.Failed != 3 || len(errs) != 3 {
+		t.Fatalf("Import() failed = %d, errors = %v", summary.Failed, errs)
+	}
+	if errs[0].Line != 6 || errs[1].Line != 7 || errs[2].Line != 8 {
+		t.Errorf("Import() error lines = %d, %d, %d, want 6, 7, 8", errs[0].Line, errs[1].Line, errs[2].Line)
+	}
+
+	movie := storage.movies[0]
+	if movie.Provider != "omdb" || movie.VoteCount != 0 || len(movie.Genres) != 1 {
+		t.Errorf("Import() movie = %+v", movie)
+	}
+	credits := storage.credits[0]
+	if credits.MovieId != 1 || credits.Cast[0].CreditId != 10 {
+		t.Errorf("Import() credits = %+v", credits)
+	}
+}
+
+func TestImporter_Import_storageErr(t *testing.T) {
+	storage := &mock.Storage{AddRatingErr: true}
+	summary, err := NewImporter(storage, nil).Import(context.Background(), strings.NewReader(jsonLines))
+	if err != nil {
+		t.Fatalf("Import() err = %v", err)
+	}
+	if summary.Records[RatingRecord] != 0 || summary.Failed != 4 {
+		t.Errorf("Import() summary = %s", summary)
+	}
+}
+
+func TestExporter_Export(t *testing.T) {
+	storage := &recordingStorage{}
+	_, err := NewImporter(storage, nil).Import(context.Background(), strings.NewReader(jsonLines))
+	if err != nil {
+		t.Fatalf("Import() err = %v", err)
+	}
+	storage.movies = append(storage.movies, models.TmdbMovie{Id: 2}, models.TmdbMovie{Id: 3})
+
+	buf := &bytes.Buffer{}
+	summary, err := NewExporter(storage, 2).Export(context.Background(), buf, []string{RatingRecord, MovieRecord, CreditsRecord})
+	if err != nil {
+		t.Fatalf("Export() err = %v", err)
+	}
+	if summary.Records[MovieRecord] != 3 || summary.Records[CommentRecord] != 0 {
+		t.Errorf("Export() summary = %s", summary)
+	}
+
+	imported := &recordingStorage{}
+	summary, err = NewImporter(imported, nil).Import(context.Background(), buf)
+	if err != nil || summary.Failed != 0 {
+		t.Fatalf("Import() of exported records failed = %d, err = %v", summary.Failed, err)
+	}
+	if len(imported.movies) != 3 || imported.movies[0].Provider != "omdb" || imported.credits[0].MovieId != 1 {
+		t.Errorf("Import() of exported records = %+v", imported)
+	}
+	if *imported.ratings[0].Rating != 8 {
+		t.Errorf("Import() of exported rating = %d, want 8", *imported.ratings[0].Rating)
+	}
+}
+
+func TestExporter_Export_invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		storage *mock.Storage
+		types   []string
+	}{
+		{
+			name:    "negative_unknown_type",
+			storage: &mock.Storage{},
+			types:   []string{MovieRecord, "review"},
+		},
+		{
+			name:    "negative_storage_err",
+			storage: &mock.Storage{ExportCommentsErr: true},
+			types:   RecordTypes,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewExporter(tt.storage, 0).Export(context.Background(), &bytes.Buffer{}, tt.types)
+			if err == nil {
+				t.Error("Export() expected error")
+			}
+		})
+	}
+}
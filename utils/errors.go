@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/BarTar213/movies-service/models"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// RequestIdKey is the key of request id in gin context
+const RequestIdKey = "request_id"
+
+// validator of gin is global, so its tag name func is registered only once no matter how many apis are created
+var tagFieldNames sync.Once
+
+// returns id of request set by middleware RequestId, empty when the middleware wasn't used
+func GetRequestId(c *gin.Context) string {
+	return c.GetString(RequestIdKey)
+}
+
+// aborts request with structured error
+func RespondError(c *gin.Context, status int, code string, message string, fields ...models.FieldError) {
+	c.AbortWithStatusJSON(status, models.Response{
+		Error: message,
+		ErrorDetails: &models.ErrorDetails{
+			Code:      code,
+			Message:   message,
+			Fields:    fields,
+			RequestId: GetRequestId(c),
+		},
+	})
+}
+
+// aborts request with bad request error, fields which failed binding are listed in details
+func RespondBindingError(c *gin.Context, code string, message string, err error) {
+	RespondError(c, http.StatusBadRequest, code, message, BindingFields(err)...)
+}
+
+// returns fields rejected by binding, names of fields are taken from their tags
+func BindingFields(err error) []models.FieldError {
+	var validationErrs validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &validationErrs):
+		fields := make([]models.FieldError, 0, len(validationErrs))
+		for _, fieldErr := range validationErrs {
+			reason := fieldErr.Tag()
+			if len(fieldErr.Param()) > 0 {
+				reason = fmt.Sprintf("%s=%s", reason, fieldErr.Param())
+			}
+			fields = append(fields, models.FieldError{Field: fieldErr.Field(), Reason: reason})
+		}
+		return fields
+	case errors.As(err, &typeErr):
+		return []models.FieldError{{Field: typeErr.Field, Reason: fmt.Sprintf("type=%s", typeErr.Type)}}
+	}
+	return nil
+}
+
+// makes validator report fields by names used in requests instead of names of struct fields,
+// it is safe to call many times and concurrently
+func UseTagFieldNames() {
+	tagFieldNames.Do(registerTagFieldNames)
+}
+
+func registerTagFieldNames() {
+	validate, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, key := range []string{"json", "form", "header"} {
+			name := strings.Split(field.Tag.Get(key), ",")[0]
+			if len(name) > 0 && name != "-" {
+				return name
+			}
+		}
+		return field.Name
+	})
+}